@@ -450,6 +450,8 @@ type GlobalConfig struct {
 	Timeout        string `yaml:"timeout" json:"timeout"`
 	Memo           string `yaml:"memo" json:"memo"`
 	LightCacheSize int    `yaml:"light-cache-size" json:"light-cache-size"`
+
+	RetryPolicy relayer.RetryPolicy `yaml:"retry-policy,omitempty" json:"retry-policy,omitempty"`
 }
 
 // newDefaultGlobalConfig returns a global config with defaults set
@@ -537,6 +539,10 @@ func validateConfig(c *Config) error {
 		return fmt.Errorf("did you remember to run 'rly config init' error:%w", err)
 	}
 
+	if _, err := relayer.ResolveRetryPolicy(c.Global.RetryPolicy); err != nil {
+		return fmt.Errorf("invalid global retry policy: %w", err)
+	}
+
 	return nil
 }
 
@@ -574,11 +580,23 @@ func initConfig(cmd *cobra.Command, a *appState) error {
 		return err
 	}
 
-	// verify that the channel filter rule is valid for every path in the config
+	// verify that the channel filter rule and fee policy are valid for every path in the config
 	for _, p := range cfgWrapper.Paths {
 		if err := p.ValidateChannelFilterRule(); err != nil {
 			return fmt.Errorf("error initializing the relayer config for path %s: %w", p.String(), err)
 		}
+		if err := p.ValidateFeePolicy(); err != nil {
+			return fmt.Errorf("error initializing the relayer config for path %s: %w", p.String(), err)
+		}
+		if err := p.ValidatePacketFilter(); err != nil {
+			return fmt.Errorf("error initializing the relayer config for path %s: %w", p.String(), err)
+		}
+		if err := p.ValidateChannelLimits(); err != nil {
+			return fmt.Errorf("error initializing the relayer config for path %s: %w", p.String(), err)
+		}
+		if _, err := relayer.ResolveRetryPolicy(cfgWrapper.Global.RetryPolicy, p.RetryPolicy); err != nil {
+			return fmt.Errorf("error initializing the relayer config for path %s: invalid retry policy: %w", p.String(), err)
+		}
 	}
 
 	// build the config struct
@@ -54,6 +54,17 @@ const (
 	flagDstClientID             = "dst-client-id"
 	flagSrcConnID               = "src-connection-id"
 	flagDstConnID               = "dst-connection-id"
+	flagCounterpartyPayee       = "counterparty"
+	flagStateStore              = "state-store"
+	flagClientsOnly             = "clients-only"
+	flagSequences               = "seq"
+	flagOneShot                 = "one-shot"
+	flagSpendLimit              = "spend-limit"
+	flagPeriod                  = "period"
+	flagPeriodLimit             = "period-limit"
+	flagExpiration              = "expiration"
+	flagRecoverClientMsg        = "recover-client-msg"
+	flagDeposit                 = "deposit"
 )
 
 const (
@@ -225,8 +236,8 @@ func urlFlag(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
 }
 
 func strategyFlag(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
-	cmd.Flags().StringP(flagMaxTxSize, "s", "2", "strategy of path to generate of the messages in a relay transaction")
-	cmd.Flags().StringP(flagMaxMsgLength, "l", "5", "maximum number of messages in a relay transaction")
+	cmd.Flags().StringP(flagMaxTxSize, "s", "2", "maximum size of a relay transaction in MB, 0 for no limit")
+	cmd.Flags().StringP(flagMaxMsgLength, "l", "5", "maximum number of messages in a relay transaction, 0 for no limit")
 	if err := v.BindPFlag(flagMaxTxSize, cmd.Flags().Lookup(flagMaxTxSize)); err != nil {
 		panic(err)
 	}
@@ -364,6 +375,34 @@ func flushIntervalFlag(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
 	return cmd
 }
 
+func stateStoreFlag(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().Bool(flagStateStore, false, "persist relayer progress under the home directory and resume from it on restart")
+	if err := v.BindPFlag(flagStateStore, cmd.Flags().Lookup(flagStateStore)); err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
+func clientsOnlyFlag(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().Bool(flagClientsOnly, false, "only keep the clients of the paths from expiring, updating them by trusting period and --time-threshold, without relaying packets")
+	if err := v.BindPFlag(flagClientsOnly, cmd.Flags().Lookup(flagClientsOnly)); err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
+func clientRecoveryFlags(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().Bool(flagRecoverClientMsg, false, "generate a MsgRecoverClient, for chains with ibc-go v8 or later, instead of a ClientUpdateProposal")
+	cmd.Flags().String(flagDeposit, "", "deposit of the generated proposal, e.g. 10000000uatom")
+	if err := v.BindPFlag(flagRecoverClientMsg, cmd.Flags().Lookup(flagRecoverClientMsg)); err != nil {
+		panic(err)
+	}
+	if err := v.BindPFlag(flagDeposit, cmd.Flags().Lookup(flagDeposit)); err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
 func memoFlag(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
 	cmd.Flags().String(flagMemo, "", "a memo to include in relayed packets")
 	if err := v.BindPFlag(flagMemo, cmd.Flags().Lookup(flagMemo)); err != nil {
@@ -372,6 +411,43 @@ func memoFlag(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
 	return cmd
 }
 
+func sequencesFlag(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().UintSlice(flagSequences, nil, "only include the packets with these sequences")
+	if err := v.BindPFlag(flagSequences, cmd.Flags().Lookup(flagSequences)); err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
+func oneShotFlag(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().Bool(flagOneShot, false, "relay the messages now and exit, instead of leaving them to a running relayer")
+	if err := v.BindPFlag(flagOneShot, cmd.Flags().Lookup(flagOneShot)); err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
+func feeAllowanceFlags(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().String(flagSpendLimit, "", "total fees the grantees can spend, e.g. 10000000uatom (default: unlimited)")
+	cmd.Flags().Duration(flagPeriod, 0, "grant a periodic allowance which resets each period, e.g. 24h")
+	cmd.Flags().String(flagPeriodLimit, "", "fees the grantees can spend within each period, e.g. 1000000uatom")
+	cmd.Flags().Duration(flagExpiration, 0, "duration after which the allowance expires, e.g. 720h (default: never)")
+	for _, flag := range []string{flagSpendLimit, flagPeriod, flagPeriodLimit, flagExpiration} {
+		if err := v.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+	return cmd
+}
+
+func counterpartyPayeeFlag(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().Bool(flagCounterpartyPayee, false, "register the payee address on the counterparty chain, which receives the recv fees")
+	if err := v.BindPFlag(flagCounterpartyPayee, cmd.Flags().Lookup(flagCounterpartyPayee)); err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
 func OverwriteConfigFlag(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
 	cmd.Flags().BoolP(flagOverwriteConfig, "o", false,
 		"overwrite already configured paths - will clear channel filter(s)")
@@ -3,6 +3,7 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -12,6 +13,7 @@ import (
 	chantypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
 	"github.com/cosmos/relayer/v2/relayer"
 	"github.com/cosmos/relayer/v2/relayer/chains/cosmos"
+	"github.com/cosmos/relayer/v2/relayer/processor"
 	"github.com/spf13/cobra"
 )
 
@@ -27,6 +29,7 @@ func queryCmd(a *appState) *cobra.Command {
 	cmd.AddCommand(
 		queryUnrelayedPackets(a),
 		queryUnrelayedAcknowledgements(a),
+		queryDeadLetters(a),
 		lineBreakCommand(),
 		queryBalanceCmd(a),
 		queryHeaderCmd(a),
@@ -1001,6 +1004,57 @@ $ %s query unrelayed-pkts demo-path channel-0`,
 	return cmd
 }
 
+func queryDeadLetters(a *appState) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dead-letters [path_name]",
+		Short: "query the packet messages which the relayer gave up on after the maximum number of retries",
+		Args:  withUsage(cobra.RangeArgs(0, 1)),
+		Example: strings.TrimSpace(fmt.Sprintf(`
+$ %s query dead-letters
+$ %s q dead-letters demo-path`,
+			appName, appName,
+		)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var pathNames []string
+			if len(args) == 1 {
+				if _, err := a.Config.Paths.Get(args[0]); err != nil {
+					return err
+				}
+				pathNames = append(pathNames, args[0])
+			} else {
+				for n := range a.Config.Paths {
+					pathNames = append(pathNames, n)
+				}
+				sort.Strings(pathNames)
+			}
+
+			store, err := processor.NewDeadLetterStore(a.HomePath)
+			if err != nil {
+				return err
+			}
+
+			letters := []processor.DeadLetter{}
+			for _, n := range pathNames {
+				l, err := store.List(n)
+				if err != nil {
+					return err
+				}
+				letters = append(letters, l...)
+			}
+
+			out, err := json.Marshal(letters)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), string(out))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
 func queryUnrelayedAcknowledgements(a *appState) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "unrelayed-acknowledgements path src_channel_id",
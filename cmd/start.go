@@ -43,7 +43,8 @@ func startCmd(a *appState) *cobra.Command {
 $ %s start           # start all configured paths
 $ %s start demo-path # start the 'demo-path' path
 $ %s start demo-path --max-msgs 3
-$ %s start demo-path2 --max-tx-size 10`, appName, appName, appName, appName)),
+$ %s start demo-path2 --max-tx-size 10
+$ %s start --clients-only --time-threshold 24h`, appName, appName, appName, appName, appName)),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			chains := make(map[string]*relayer.Chain)
 			paths := make([]relayer.NamedPath, len(args))
@@ -144,6 +145,39 @@ $ %s start demo-path2 --max-tx-size 10`, appName, appName, appName, appName)),
 				return err
 			}
 
+			clientsOnly, err := cmd.Flags().GetBool(flagClientsOnly)
+			if err != nil {
+				return err
+			}
+			if clientsOnly {
+				return waitForRelayer(a.Log, relayer.StartClientKeepalive(
+					cmd.Context(),
+					a.Log,
+					chains,
+					paths,
+					maxMsgLength,
+					a.Config.memo(cmd),
+					clientUpdateThresholdTime,
+				))
+			}
+
+			var stateStore *processor.StateStore
+			useStateStore, err := cmd.Flags().GetBool(flagStateStore)
+			if err != nil {
+				return err
+			}
+			if useStateStore {
+				stateStore, err = processor.NewStateStore(a.HomePath)
+				if err != nil {
+					return err
+				}
+			}
+
+			deadLetterStore, err := processor.NewDeadLetterStore(a.HomePath)
+			if err != nil {
+				return err
+			}
+
 			rlyErrCh := relayer.StartRelayer(
 				cmd.Context(),
 				a.Log,
@@ -157,20 +191,12 @@ $ %s start demo-path2 --max-tx-size 10`, appName, appName, appName, appName)),
 				processorType,
 				initialBlockHistory,
 				prometheusMetrics,
+				stateStore,
+				deadLetterStore,
+				a.Config.Global.RetryPolicy,
 			)
 
-			// Block until the error channel sends a message.
-			// The context being canceled will cause the relayer to stop,
-			// so we don't want to separately monitor the ctx.Done channel,
-			// because we would risk returning before the relayer cleans up.
-			if err := <-rlyErrCh; err != nil && !errors.Is(err, context.Canceled) {
-				a.Log.Warn(
-					"Relayer start error",
-					zap.Error(err),
-				)
-				return err
-			}
-			return nil
+			return waitForRelayer(a.Log, rlyErrCh)
 		},
 	}
 	cmd = updateTimeFlags(a.Viper, cmd)
@@ -179,10 +205,28 @@ $ %s start demo-path2 --max-tx-size 10`, appName, appName, appName, appName)),
 	cmd = processorFlag(a.Viper, cmd)
 	cmd = initBlockFlag(a.Viper, cmd)
 	cmd = flushIntervalFlag(a.Viper, cmd)
+	cmd = stateStoreFlag(a.Viper, cmd)
+	cmd = clientsOnlyFlag(a.Viper, cmd)
 	cmd = memoFlag(a.Viper, cmd)
 	return cmd
 }
 
+// waitForRelayer blocks until the relayer stops and returns its error, if it did not stop because the context was canceled.
+func waitForRelayer(log *zap.Logger, rlyErrCh chan error) error {
+	// Block until the error channel sends a message.
+	// The context being canceled will cause the relayer to stop,
+	// so we don't want to separately monitor the ctx.Done channel,
+	// because we would risk returning before the relayer cleans up.
+	if err := <-rlyErrCh; err != nil && !errors.Is(err, context.Canceled) {
+		log.Warn(
+			"Relayer start error",
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
 // GetStartOptions sets strategy specific fields.
 func GetStartOptions(cmd *cobra.Command) (uint64, uint64, error) {
 	maxTxSize, err := cmd.Flags().GetString(flagMaxTxSize)
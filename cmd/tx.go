@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -9,8 +10,12 @@ import (
 
 	"github.com/avast/retry-go/v4"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	"github.com/cosmos/cosmos-sdk/x/feegrant"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
 	chantypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
 	"github.com/cosmos/relayer/v2/relayer"
+	"github.com/cosmos/relayer/v2/relayer/chains/cosmos"
 	"github.com/cosmos/relayer/v2/relayer/processor"
 	"github.com/cosmos/relayer/v2/relayer/provider"
 	"github.com/spf13/cobra"
@@ -37,14 +42,18 @@ Most of these commands take a [path] argument. Make sure:
 		linkCmd(a),
 		linkThenStartCmd(a),
 		flushCmd(a),
+		replayDeadLettersCmd(a),
 		relayMsgsCmd(a),
 		relayAcksCmd(a),
 		xfersend(a),
+		registerPayeeCmd(a),
+		feegrantCmd(a),
 		lineBreakCommand(),
 		createClientsCmd(a),
 		createClientCmd(a),
 		updateClientsCmd(a),
 		upgradeClientsCmd(a),
+		clientRecoveryCmd(a),
 		createConnectionCmd(a),
 		createChannelCmd(a),
 		closeChannelCmd(a),
@@ -307,6 +316,80 @@ func upgradeClientsCmd(a *appState) *cobra.Command {
 	return cmd
 }
 
+func clientRecoveryCmd(a *appState) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "client-recovery path_name chain_id",
+		Short: "create a substitute for the expired or frozen client on chain_id and generate the proposal to recover it",
+		Long: strings.TrimSpace(`Create a substitute client on chain_id, tracking the counterparty chain of the path,
+for the expired or frozen client of the path on chain_id. The governance proposal replacing the state
+of the expired or frozen client with the state of the substitute client is printed as JSON, to be
+submitted with 'tx gov submit-proposal' on chain_id. The path is not changed, as the client ID is kept
+once the proposal has passed, and 'rly start' resumes relaying on the path once the client is active again.`,
+		),
+		Args: withUsage(cobra.ExactArgs(2)),
+		Example: strings.TrimSpace(fmt.Sprintf(`
+$ %s transact client-recovery demo-path ibc-0 --deposit 10000000stake > proposal.json
+$ %s tx client-recovery demo-path ibc-0 --recover-client-msg`,
+			appName, appName,
+		)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			recoverClientMsg, err := cmd.Flags().GetBool(flagRecoverClientMsg)
+			if err != nil {
+				return err
+			}
+
+			deposit, err := cmd.Flags().GetString(flagDeposit)
+			if err != nil {
+				return err
+			}
+
+			c, src, dst, err := a.Config.ChainsFromPath(args[0])
+			if err != nil {
+				return err
+			}
+
+			host, counterparty := c[src], c[dst]
+			switch args[1] {
+			case src:
+			case dst:
+				host, counterparty = c[dst], c[src]
+			default:
+				return fmt.Errorf("chain %s is not part of path %s", args[1], args[0])
+			}
+
+			if exists := host.ChainProvider.KeyExists(host.ChainProvider.Key()); !exists {
+				return fmt.Errorf("key %s not found on chain %s", host.ChainProvider.Key(), host.ChainID())
+			}
+
+			cosmosProvider, ok := host.ChainProvider.(*cosmos.CosmosProvider)
+			if !ok {
+				return fmt.Errorf("client recovery is only supported for cosmos chains")
+			}
+			authority, err := cosmosProvider.EncodeBech32AccAddr(authtypes.NewModuleAddress(govtypes.ModuleName))
+			if err != nil {
+				return err
+			}
+
+			substituteClientID, err := relayer.CreateSubstituteClient(cmd.Context(), host, counterparty, a.Config.memo(cmd))
+			if err != nil {
+				return err
+			}
+
+			proposal, err := relayer.ClientRecoveryProposal(host.ClientID(), substituteClientID, authority, deposit, recoverClientMsg)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), string(proposal))
+			return nil
+		},
+	}
+
+	cmd = clientRecoveryFlags(a.Viper, cmd)
+	cmd = memoFlag(a.Viper, cmd)
+	return cmd
+}
+
 func createConnectionCmd(a *appState) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "connection path_name",
@@ -793,6 +876,11 @@ $ %s tx flush demo-path channel-0`,
 				}
 			}
 
+			deadLetterStore, err := processor.NewDeadLetterStore(a.HomePath)
+			if err != nil {
+				return err
+			}
+
 			ctx, cancel := context.WithTimeout(cmd.Context(), flushTimeout)
 			defer cancel()
 
@@ -809,6 +897,9 @@ $ %s tx flush demo-path channel-0`,
 				relayer.ProcessorEvents,
 				0,
 				nil,
+				nil,
+				deadLetterStore,
+				a.Config.Global.RetryPolicy,
 			)
 
 			// Block until the error channel sends a message.
@@ -831,6 +922,116 @@ $ %s tx flush demo-path channel-0`,
 	return cmd
 }
 
+func replayDeadLettersCmd(a *appState) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay-dead-letters path_name",
+		Short: "replay packet messages on a given path which the relayer gave up on after the maximum number of retries",
+		Long: strings.TrimSpace(`Marks the dead letters of the path, optionally only those with the given packet sequences, to be replayed.
+A running 'rly start' picks them up within seconds and relays them again with a fresh retry count.
+With --one-shot, they are instead relayed by a relayer which exits once they are flushed, like 'rly tx flush'.`,
+		),
+		Args: withUsage(cobra.ExactArgs(1)),
+		Example: strings.TrimSpace(fmt.Sprintf(`
+$ %s tx replay-dead-letters demo-path
+$ %s tx replay-dead-letters demo-path --seq 5,7
+$ %s tx replay-dead-letters demo-path --one-shot`,
+			appName, appName, appName,
+		)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pathName := args[0]
+			path, err := a.Config.Paths.Get(pathName)
+			if err != nil {
+				return err
+			}
+
+			seqs, err := cmd.Flags().GetUintSlice(flagSequences)
+			if err != nil {
+				return err
+			}
+			sequences := make([]uint64, len(seqs))
+			for i, seq := range seqs {
+				sequences[i] = uint64(seq)
+			}
+
+			oneShot, err := cmd.Flags().GetBool(flagOneShot)
+			if err != nil {
+				return err
+			}
+
+			deadLetterStore, err := processor.NewDeadLetterStore(a.HomePath)
+			if err != nil {
+				return err
+			}
+
+			marked, err := deadLetterStore.RequestReplay(pathName, sequences)
+			if err != nil {
+				return err
+			}
+			if marked == 0 {
+				return fmt.Errorf("no matching dead letters found for path %s", pathName)
+			}
+			a.Log.Info("Requested replay of dead letters",
+				zap.String("path_name", pathName),
+				zap.Int("count", marked),
+			)
+
+			if !oneShot {
+				return nil
+			}
+
+			chains, err := a.Config.Chains.Gets(path.Src.ChainID, path.Dst.ChainID)
+			if err != nil {
+				return err
+			}
+
+			if err := ensureKeysExist(chains); err != nil {
+				return err
+			}
+
+			maxTxSize, maxMsgLength, err := GetStartOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), flushTimeout)
+			defer cancel()
+
+			rlyErrCh := relayer.StartRelayer(
+				ctx,
+				a.Log,
+				chains,
+				[]relayer.NamedPath{{Name: pathName, Path: path}},
+				maxTxSize, maxMsgLength,
+				a.Config.memo(cmd),
+				0,
+				0,
+				&processor.FlushLifecycle{},
+				relayer.ProcessorEvents,
+				0,
+				nil,
+				nil,
+				deadLetterStore,
+				a.Config.Global.RetryPolicy,
+			)
+
+			if err := <-rlyErrCh; err != nil && !errors.Is(err, context.Canceled) {
+				a.Log.Warn(
+					"Relayer start error",
+					zap.Error(err),
+				)
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd = sequencesFlag(a.Viper, cmd)
+	cmd = oneShotFlag(a.Viper, cmd)
+	cmd = strategyFlag(a.Viper, cmd)
+	cmd = memoFlag(a.Viper, cmd)
+	return cmd
+}
+
 func relayMsgsCmd(a *appState) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "relay-packets path_name src_channel_id",
@@ -986,6 +1187,280 @@ $ %s tx raw send ibc-0 ibc-1 100000stake cosmos1skjwj5whet0lpe65qaq4rpq03hjxlwd9
 	return timeoutFlags(a.Viper, pathFlag(a.Viper, cmd))
 }
 
+func registerPayeeCmd(a *appState) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "register-payee chain_name port_id channel_id payee_addr",
+		Short: "register the address which receives the relayer fees on a fee enabled channel",
+		Long: `Register the payee address with the ICS-29 fee middleware for the configured key and the keys of the
+key pool on the given chain and channel. By default, the payee receives the ack and timeout fees earned on this chain.
+With --counterparty, the payee is an address on the counterparty chain which receives the recv fees for packets relayed
+to this chain.`,
+		Args: withUsage(cobra.ExactArgs(4)),
+		Example: strings.TrimSpace(fmt.Sprintf(`
+$ %s tx register-payee ibc-0 transfer channel-0 cosmos1skjwj5whet0lpe65qaq4rpq03hjxlwd9nf39lk
+$ %s tx register-payee ibc-1 transfer channel-0 cosmos1skjwj5whet0lpe65qaq4rpq03hjxlwd9nf39lk --counterparty`,
+			appName, appName,
+		)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			chain, ok := a.Config.Chains[args[0]]
+			if !ok {
+				return errChainNotFound(args[0])
+			}
+
+			if exists := chain.ChainProvider.KeyExists(chain.ChainProvider.Key()); !exists {
+				return fmt.Errorf("key %s not found on chain %s", chain.ChainProvider.Key(), chain.ChainID())
+			}
+
+			counterparty, err := cmd.Flags().GetBool(flagCounterpartyPayee)
+			if err != nil {
+				return err
+			}
+
+			return chain.RegisterPayee(cmd.Context(), args[1], args[2], args[3], counterparty, a.Config.memo(cmd))
+		},
+	}
+
+	cmd = counterpartyPayeeFlag(a.Viper, cmd)
+	cmd = memoFlag(a.Viper, cmd)
+	return cmd
+}
+
+func feegrantCmd(a *appState) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "feegrant",
+		Short: "manage the fee allowances granted to the relayer keys (x/feegrant)",
+		Long: `Grant, revoke and show the fee allowances with which a treasury key pays the fees of the relayer keys.
+Set fee-granter in the chain configuration to the address of the treasury key to use the allowances.`,
+	}
+
+	cmd.AddCommand(
+		feegrantGrantCmd(a),
+		feegrantRevokeCmd(a),
+		feegrantShowCmd(a),
+	)
+
+	return cmd
+}
+
+func feegrantGrantCmd(a *appState) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "grant chain_name granter_key [grantee_key...]",
+		Short: "grant a fee allowance from the granter key to the relayer keys",
+		Long: `Grant a BasicAllowance, or a PeriodicAllowance with --period, from the granter key to each grantee key.
+The grantees default to the key and key pool configured for the chain.`,
+		Args: withUsage(cobra.MinimumNArgs(2)),
+		Example: strings.TrimSpace(fmt.Sprintf(`
+$ %s tx feegrant grant cosmoshub treasury
+$ %s tx feegrant grant cosmoshub treasury default --spend-limit 10000000uatom --expiration 720h
+$ %s tx feegrant grant cosmoshub treasury --period 24h --period-limit 1000000uatom`,
+			appName, appName, appName,
+		)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ccp, grantees, err := feegrantChainAndGrantees(a, args)
+			if err != nil {
+				return err
+			}
+
+			allowance, err := feeAllowanceFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			res, err := ccp.GrantFeeAllowance(cmd.Context(), args[1], grantees, allowance, a.Config.memo(cmd))
+			if err != nil {
+				return err
+			}
+
+			a.Log.Info(
+				"Granted fee allowance",
+				zap.String("chain_id", ccp.ChainId()),
+				zap.String("granter_key", args[1]),
+				zap.Strings("grantees", grantees),
+				zap.String("tx_hash", res.TxHash),
+			)
+			return nil
+		},
+	}
+
+	cmd = feeAllowanceFlags(a.Viper, cmd)
+	cmd = memoFlag(a.Viper, cmd)
+	return cmd
+}
+
+func feegrantRevokeCmd(a *appState) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "revoke chain_name granter_key [grantee_key...]",
+		Short: "revoke the fee allowances from the granter key to the relayer keys",
+		Long:  "Revoke the fee allowance from the granter key to each grantee key. The grantees default to the key and key pool configured for the chain.",
+		Args:  withUsage(cobra.MinimumNArgs(2)),
+		Example: strings.TrimSpace(fmt.Sprintf(`
+$ %s tx feegrant revoke cosmoshub treasury
+$ %s tx feegrant revoke cosmoshub treasury relayer-1`,
+			appName, appName,
+		)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ccp, grantees, err := feegrantChainAndGrantees(a, args)
+			if err != nil {
+				return err
+			}
+
+			res, err := ccp.RevokeFeeAllowance(cmd.Context(), args[1], grantees, a.Config.memo(cmd))
+			if err != nil {
+				return err
+			}
+
+			a.Log.Info(
+				"Revoked fee allowance",
+				zap.String("chain_id", ccp.ChainId()),
+				zap.String("granter_key", args[1]),
+				zap.Strings("grantees", grantees),
+				zap.String("tx_hash", res.TxHash),
+			)
+			return nil
+		},
+	}
+
+	cmd = memoFlag(a.Viper, cmd)
+	return cmd
+}
+
+func feegrantShowCmd(a *appState) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show chain_name [granter]",
+		Short: "show the fee allowances granted to the relayer keys",
+		Long: `Show the fee allowance granted to each of the keys configured for the chain.
+The granter is a key name or an address, and defaults to the fee-granter configured for the chain.`,
+		Args: withUsage(cobra.RangeArgs(1, 2)),
+		Example: strings.TrimSpace(fmt.Sprintf(`
+$ %s tx feegrant show cosmoshub
+$ %s tx feegrant show cosmoshub treasury`,
+			appName, appName,
+		)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ccp, grantees, err := feegrantChainAndGrantees(a, args[:1])
+			if err != nil {
+				return err
+			}
+
+			granter := ccp.PCfg.FeeGranter
+			if len(args) > 1 {
+				granter = args[1]
+				if ccp.KeyExists(granter) {
+					if granter, err = ccp.ShowAddress(granter); err != nil {
+						return err
+					}
+				}
+			}
+			if granter == "" {
+				return fmt.Errorf("no fee-granter configured for chain %s, pass in the granter", args[0])
+			}
+
+			type feeAllowance struct {
+				Key       string          `json:"key"`
+				Grantee   string          `json:"grantee"`
+				Allowance json.RawMessage `json:"allowance"`
+				Error     string          `json:"error,omitempty"`
+			}
+
+			keys := ccp.SigningKeys()
+			allowances := make([]feeAllowance, len(grantees))
+			for i, grantee := range grantees {
+				allowances[i] = feeAllowance{Key: keys[i], Grantee: grantee, Allowance: json.RawMessage("null")}
+				grant, err := ccp.QueryFeeAllowance(cmd.Context(), granter, grantee)
+				if err != nil {
+					allowances[i].Error = err.Error()
+					continue
+				}
+				s, err := ccp.Sprint(grant)
+				if err != nil {
+					return err
+				}
+				allowances[i].Allowance = json.RawMessage(s)
+			}
+
+			out, err := json.Marshal(allowances)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(out))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// feegrantChainAndGrantees returns the cosmos chain provider of the chain named by args[0], and the addresses
+// of the grantee keys args[2:], or of the keys configured for the chain if none are passed.
+func feegrantChainAndGrantees(a *appState, args []string) (*cosmos.CosmosProvider, []string, error) {
+	chain, ok := a.Config.Chains[args[0]]
+	if !ok {
+		return nil, nil, errChainNotFound(args[0])
+	}
+	ccp, ok := chain.ChainProvider.(*cosmos.CosmosProvider)
+	if !ok {
+		return nil, nil, fmt.Errorf("fee grants are only supported on cosmos chains")
+	}
+
+	if len(args) > 1 && !ccp.KeyExists(args[1]) {
+		return nil, nil, fmt.Errorf("key %s not found on chain %s", args[1], chain.ChainID())
+	}
+
+	keys := ccp.SigningKeys()
+	if len(args) > 2 {
+		keys = args[2:]
+	}
+	grantees := make([]string, len(keys))
+	for i, key := range keys {
+		if !ccp.KeyExists(key) {
+			return nil, nil, fmt.Errorf("key %s not found on chain %s", key, chain.ChainID())
+		}
+		address, err := ccp.ShowAddress(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		grantees[i] = address
+	}
+	return ccp, grantees, nil
+}
+
+// feeAllowanceFromFlags builds the fee allowance to grant from the feeAllowanceFlags.
+func feeAllowanceFromFlags(cmd *cobra.Command) (feegrant.FeeAllowanceI, error) {
+	spendLimitStr, err := cmd.Flags().GetString(flagSpendLimit)
+	if err != nil {
+		return nil, err
+	}
+	spendLimit, err := sdk.ParseCoinsNormalized(spendLimitStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", flagSpendLimit, err)
+	}
+
+	period, err := cmd.Flags().GetDuration(flagPeriod)
+	if err != nil {
+		return nil, err
+	}
+	periodLimitStr, err := cmd.Flags().GetString(flagPeriodLimit)
+	if err != nil {
+		return nil, err
+	}
+	periodLimit, err := sdk.ParseCoinsNormalized(periodLimitStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", flagPeriodLimit, err)
+	}
+
+	expiresIn, err := cmd.Flags().GetDuration(flagExpiration)
+	if err != nil {
+		return nil, err
+	}
+	var expiration *time.Time
+	if expiresIn > 0 {
+		t := time.Now().Add(expiresIn)
+		expiration = &t
+	}
+
+	return cosmos.FeeAllowance(spendLimit, periodLimit, period, expiration)
+}
+
 func setPathsFromArgs(a *appState, src, dst *relayer.Chain, name string) (*relayer.Path, error) {
 	// find any configured paths between the chains
 	paths, err := a.Config.Paths.PathsFromChains(src.ChainID(), dst.ChainID())
@@ -1030,8 +1505,14 @@ func setPathsFromArgs(a *appState, src, dst *relayer.Chain, name string) (*relay
 // ensureKeysExist returns an error if a configured key for a given chain does not exist.
 func ensureKeysExist(chains map[string]*relayer.Chain) error {
 	for _, v := range chains {
-		if exists := v.ChainProvider.KeyExists(v.ChainProvider.Key()); !exists {
-			return fmt.Errorf("key %s not found on chain %s", v.ChainProvider.Key(), v.ChainID())
+		keys := []string{v.ChainProvider.Key()}
+		if ccp, ok := v.ChainProvider.(*cosmos.CosmosProvider); ok {
+			keys = ccp.SigningKeys()
+		}
+		for _, key := range keys {
+			if exists := v.ChainProvider.KeyExists(key); !exists {
+				return fmt.Errorf("key %s not found on chain %s", key, v.ChainID())
+			}
 		}
 	}
 
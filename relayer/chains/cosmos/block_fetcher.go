@@ -0,0 +1,87 @@
+package cosmos
+
+import (
+	"context"
+
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"golang.org/x/sync/errgroup"
+)
+
+// blockData is the data queried for each block to process it.
+type blockData struct {
+	blockRes  *ctypes.ResultBlockResults
+	ibcHeader provider.IBCHeader
+}
+
+// fetchResult is the result of fetching the data at a height.
+type fetchResult[T any] struct {
+	height int64
+	data   T
+	err    error
+}
+
+// fetchInOrder fetches the data at each height from `from` to `to` with up to `concurrency` fetches in flight,
+// and returns a channel of pending results in height order. Receiving from each pending result waits for it,
+// so that the results are processed strictly in height order while the following heights are fetched.
+// The caller must cancel the ctx if it stops receiving before the channel is closed.
+func fetchInOrder[T any](
+	ctx context.Context,
+	from, to int64,
+	concurrency int,
+	fetch func(ctx context.Context, height int64) (T, error),
+) <-chan chan fetchResult[T] {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	// the result being waited on by the caller is in flight in addition to the buffered results.
+	pending := make(chan chan fetchResult[T], concurrency-1)
+	go func() {
+		defer close(pending)
+		for h := from; h <= to; h++ {
+			res := make(chan fetchResult[T], 1)
+			select {
+			case pending <- res:
+			case <-ctx.Done():
+				return
+			}
+			go func(h int64) {
+				data, err := fetch(ctx, h)
+				res <- fetchResult[T]{height: h, data: data, err: err}
+			}(h)
+		}
+	}()
+	return pending
+}
+
+// queryConcurrency returns the number of heights queried concurrently while catching up.
+func (ccp *CosmosChainProcessor) queryConcurrency() int {
+	if c := ccp.chainProvider.PCfg.QueryConcurrency; c > 1 {
+		return c
+	}
+	return 1
+}
+
+// queryBlockData queries the block results and IBC header at the height.
+func (ccp *CosmosChainProcessor) queryBlockData(ctx context.Context, height int64) (blockData, error) {
+	var (
+		eg   errgroup.Group
+		data blockData
+	)
+	eg.Go(func() (err error) {
+		queryCtx, cancelQueryCtx := context.WithTimeout(ctx, blockResultsQueryTimeout)
+		defer cancelQueryCtx()
+		data.blockRes, err = ccp.chainProvider.RPCClient.BlockResults(queryCtx, &height)
+		return err
+	})
+	eg.Go(func() (err error) {
+		queryCtx, cancelQueryCtx := context.WithTimeout(ctx, queryTimeout)
+		defer cancelQueryCtx()
+		data.ibcHeader, err = ccp.chainProvider.QueryIBCHeader(queryCtx, height)
+		return err
+	})
+	if err := eg.Wait(); err != nil {
+		return blockData{}, err
+	}
+	return data, nil
+}
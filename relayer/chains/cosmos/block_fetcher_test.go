@@ -0,0 +1,71 @@
+package cosmos
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchInOrder(t *testing.T) {
+	const concurrency = 4
+	var inFlight, maxInFlight int32
+	fetch := func(ctx context.Context, height int64) (int64, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+		return height * 10, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	next := int64(1)
+	for pending := range fetchInOrder(ctx, 1, 50, concurrency, fetch) {
+		res := <-pending
+		require.NoError(t, res.err)
+		require.Equal(t, next, res.height)
+		require.Equal(t, next*10, res.data)
+		next++
+	}
+	require.Equal(t, int64(51), next)
+	require.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(concurrency))
+	require.Greater(t, atomic.LoadInt32(&maxInFlight), int32(1))
+}
+
+func TestFetchInOrderStop(t *testing.T) {
+	var fetched int32
+	fetch := func(ctx context.Context, height int64) (int64, error) {
+		atomic.AddInt32(&fetched, 1)
+		if height == 3 {
+			return 0, fmt.Errorf("block %d not found", height)
+		}
+		return height, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var processed []int64
+	for pending := range fetchInOrder(ctx, 1, 1000, 2, fetch) {
+		res := <-pending
+		if res.err != nil {
+			break
+		}
+		processed = append(processed, res.height)
+	}
+	cancel()
+
+	// heights after the failed one are not processed, and only a few are fetched ahead of it.
+	require.Equal(t, []int64{1, 2}, processed)
+	require.LessOrEqual(t, atomic.LoadInt32(&fetched), int32(5))
+}
@@ -0,0 +1,56 @@
+package cosmos
+
+import (
+	"context"
+	"time"
+
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"go.uber.org/zap"
+)
+
+const (
+	// How often the block limits are queried from the consensus params, which rarely change.
+	blockLimitsUpdateInterval = 10 * time.Minute
+	blockLimitsQueryTimeout   = 10 * time.Second
+)
+
+// QueryBlockLimits returns the maximum bytes and gas of a block from the consensus params of the chain,
+// queried at most once per blockLimitsUpdateInterval, so that the limits of every transaction and batch
+// are checked without a query. If the query fails, the error is returned along with the previous limits,
+// which are unlimited until the first successful query.
+func (cc *CosmosProvider) QueryBlockLimits(ctx context.Context) (provider.BlockLimits, error) {
+	cc.blockLimitsMu.Lock()
+	defer cc.blockLimitsMu.Unlock()
+
+	if time.Since(cc.blockLimitsUpdated) < blockLimitsUpdateInterval {
+		return cc.blockLimits, nil
+	}
+	// set before querying, so that a failing query is only retried after the interval.
+	cc.blockLimitsUpdated = time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, blockLimitsQueryTimeout)
+	defer cancel()
+
+	limits, err := cc.queryBlockLimits(ctx)
+	if err != nil {
+		return cc.blockLimits, err
+	}
+	cc.blockLimits = limits
+	return limits, nil
+}
+
+// checkBlockGasLimit returns a TxGasExceedsBlockLimitError if the gas wanted is more than the block max gas.
+func (cc *CosmosProvider) checkBlockGasLimit(ctx context.Context, gasWanted uint64) error {
+	limits, err := cc.QueryBlockLimits(ctx)
+	if err != nil {
+		cc.log.Warn("Failed to query block limits, keeping the previous limits", zap.Error(err))
+	}
+	if limits.MaxGas > 0 && gasWanted > uint64(limits.MaxGas) {
+		return &provider.TxGasExceedsBlockLimitError{
+			ChainID:   cc.PCfg.ChainID,
+			GasWanted: gasWanted,
+			MaxGas:    limits.MaxGas,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package cosmos
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	comettypes "github.com/cometbft/cometbft/types"
+	"go.uber.org/zap"
+)
+
+const (
+	// How often blocks are polled while subscribed to new blocks, to fill in any blocks the subscription missed.
+	subscribedMinQueryLoopDuration = 10 * time.Second
+
+	// If no new block is received for this long, the subscription is considered dropped and is restarted.
+	blockSubscriptionStaleTimeout = time.Minute
+
+	// Delay before subscribing again after the subscription failed or dropped.
+	blockSubscriptionRetryDelay = 5 * time.Second
+
+	blockSubscriptionCapacity = 10
+)
+
+var (
+	newBlockQuery = comettypes.QueryForEvent(comettypes.EventNewBlock).String()
+
+	errBlockSubscriptionStale = errors.New("no new block received within the stale timeout")
+)
+
+// subscribeNewBlocks subscribes to new blocks over the websocket of the RPC node, and signals newBlocks
+// for each new block so that it is queried without waiting for the next poll. While subscribed,
+// the query loop polls less often. If the subscription fails or drops, polling resumes at the regular
+// interval and the subscription is retried until ctx is done.
+func (ccp *CosmosChainProcessor) subscribeNewBlocks(ctx context.Context, newBlocks chan<- struct{}) {
+	for {
+		err := ccp.subscribeNewBlocksOnce(ctx, newBlocks)
+		ccp.subscribed.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+		ccp.log.Warn("New block subscription dropped, falling back to polling",
+			zap.Duration("retry_in", blockSubscriptionRetryDelay),
+			zap.Error(err),
+		)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(blockSubscriptionRetryDelay):
+		}
+	}
+}
+
+// subscribeNewBlocksOnce subscribes to new blocks until ctx is done or the subscription is stale.
+func (ccp *CosmosChainProcessor) subscribeNewBlocksOnce(ctx context.Context, newBlocks chan<- struct{}) error {
+	addr := ccp.chainProvider.PCfg.RPCAddr
+	if ccp.chainProvider.rpcPool != nil {
+		addr = ccp.chainProvider.rpcPool.bestAddr()
+	}
+	client, err := NewRPCClient(addr, queryTimeout)
+	if err != nil {
+		return err
+	}
+	if err := client.Start(); err != nil {
+		return err
+	}
+	defer func() {
+		if err := client.Stop(); err != nil {
+			ccp.log.Debug("Failed to stop websocket client", zap.Error(err))
+		}
+	}()
+
+	subscribeCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	events, err := client.Subscribe(subscribeCtx, "rly-"+ccp.chainProvider.ChainId(), newBlockQuery, blockSubscriptionCapacity)
+	if err != nil {
+		return err
+	}
+
+	ccp.log.Info("Subscribed to new blocks")
+	ccp.subscribed.Store(true)
+
+	stale := time.NewTimer(blockSubscriptionStaleTimeout)
+	defer stale.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stale.C:
+			return errBlockSubscriptionStale
+		case <-events:
+			if !stale.Stop() {
+				<-stale.C
+			}
+			stale.Reset(blockSubscriptionStaleTimeout)
+			select {
+			case newBlocks <- struct{}{}:
+			default:
+				// a query cycle is already pending.
+			}
+		}
+	}
+}
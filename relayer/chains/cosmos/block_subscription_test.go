@@ -0,0 +1,23 @@
+package cosmos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLoopDuration(t *testing.T) {
+	ccp := &CosmosChainProcessor{}
+	persistence := &queryCyclePersistence{minQueryLoopDuration: defaultMinQueryLoopDuration}
+
+	require.Equal(t, defaultMinQueryLoopDuration, ccp.queryLoopDuration(persistence))
+
+	ccp.subscribed.Store(true)
+	require.Equal(t, defaultMinQueryLoopDuration, ccp.queryLoopDuration(persistence), "poll while catching up")
+
+	ccp.inSync = true
+	require.Equal(t, subscribedMinQueryLoopDuration, ccp.queryLoopDuration(persistence))
+
+	ccp.subscribed.Store(false)
+	require.Equal(t, defaultMinQueryLoopDuration, ccp.queryLoopDuration(persistence), "poll when the subscription drops")
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sync/atomic"
 	"time"
 
 	"github.com/avast/retry-go/v4"
@@ -53,6 +54,18 @@ type CosmosChainProcessor struct {
 
 	// parsed gas prices accepted by the chain (only used for metrics)
 	parsedGasPrices *sdk.DecCoins
+
+	// checkpoints the latest queried block across restarts, nil if disabled
+	stateStore *processor.StateStore
+	// the latest checkpointed block and when it was saved, see checkpointLatestQueriedBlock
+	checkpointHeight int64
+	checkpointSaved  time.Time
+
+	// whether the websocket subscription to new blocks is active, see subscribeNewBlocks
+	subscribed atomic.Bool
+
+	// whether tx_search failed because the node has tx indexing disabled, see catchUpWithTxSearch
+	txSearchUnavailable bool
 }
 
 func NewCosmosChainProcessor(log *zap.Logger, provider *CosmosProvider, metrics *processor.PrometheusMetrics) *CosmosChainProcessor {
@@ -121,6 +134,12 @@ func (ccp *CosmosChainProcessor) SetPathProcessors(pathProcessors processor.Path
 	ccp.pathProcessors = pathProcessors
 }
 
+// SetStateStore enables checkpointing the latest queried block,
+// so that Run resumes from the checkpoint rather than the initial block history.
+func (ccp *CosmosChainProcessor) SetStateStore(stateStore *processor.StateStore) {
+	ccp.stateStore = stateStore
+}
+
 // latestHeightWithRetry will query for the latest height, retrying in case of failure.
 // It will delay by latestHeightQueryRetryDelay between attempts, up to latestHeightQueryRetries.
 func (ccp *CosmosChainProcessor) latestHeightWithRetry(ctx context.Context) (latestHeight int64, err error) {
@@ -224,6 +243,10 @@ func (ccp *CosmosChainProcessor) Run(ctx context.Context, initialBlockHistory ui
 		latestQueriedBlock = 0
 	}
 
+	if checkpoint, ok := ccp.checkpointedBlock(persistence.latestHeight); ok {
+		latestQueriedBlock = checkpoint
+	}
+
 	persistence.latestQueriedBlock = latestQueriedBlock
 
 	var eg errgroup.Group
@@ -239,9 +262,20 @@ func (ccp *CosmosChainProcessor) Run(ctx context.Context, initialBlockHistory ui
 
 	ccp.log.Debug("Entering main query loop")
 
+	// new blocks are signaled by the websocket subscription, if enabled.
+	var newBlocks chan struct{}
+	if ccp.chainProvider.PCfg.WebsocketSubscription {
+		newBlocks = make(chan struct{}, 1)
+		go ccp.subscribeNewBlocks(ctx, newBlocks)
+	}
+
 	ticker := time.NewTicker(persistence.minQueryLoopDuration)
 	defer ticker.Stop()
 
+	defer func() {
+		ccp.checkpointLatestQueriedBlock(persistence.latestQueriedBlock)
+	}()
+
 	for {
 		if err := ccp.queryCycle(ctx, &persistence); err != nil {
 			return err
@@ -250,11 +284,21 @@ func (ccp *CosmosChainProcessor) Run(ctx context.Context, initialBlockHistory ui
 		case <-ctx.Done():
 			return nil
 		case <-ticker.C:
-			ticker.Reset(persistence.minQueryLoopDuration)
+		case <-newBlocks:
 		}
+		ticker.Reset(ccp.queryLoopDuration(&persistence))
 	}
 }
 
+// queryLoopDuration returns how long to wait for the next query cycle if no new block is signaled.
+// While subscribed to new blocks and in sync, polling only fills in blocks which the subscription missed.
+func (ccp *CosmosChainProcessor) queryLoopDuration(persistence *queryCyclePersistence) time.Duration {
+	if ccp.subscribed.Load() && ccp.inSync {
+		return subscribedMinQueryLoopDuration
+	}
+	return persistence.minQueryLoopDuration
+}
+
 // initializeConnectionState will bootstrap the connectionStateCache with the open connection state.
 func (ccp *CosmosChainProcessor) initializeConnectionState(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
@@ -354,30 +398,32 @@ func (ccp *CosmosChainProcessor) queryCycle(ctx context.Context, persistence *qu
 
 	chainID := ccp.chainProvider.ChainId()
 
-	for i := persistence.latestQueriedBlock + 1; i <= persistence.latestHeight; i++ {
-		var eg errgroup.Group
-		var blockRes *ctypes.ResultBlockResults
-		var ibcHeader provider.IBCHeader
-		i := i
-		eg.Go(func() (err error) {
-			queryCtx, cancelQueryCtx := context.WithTimeout(ctx, blockResultsQueryTimeout)
-			defer cancelQueryCtx()
-			blockRes, err = ccp.chainProvider.RPCClient.BlockResults(queryCtx, &i)
-			return err
-		})
-		eg.Go(func() (err error) {
-			queryCtx, cancelQueryCtx := context.WithTimeout(ctx, queryTimeout)
-			defer cancelQueryCtx()
-			ibcHeader, err = ccp.chainProvider.QueryIBCHeader(queryCtx, i)
-			return err
-		})
+	firstBlock := persistence.latestQueriedBlock + 1
 
-		if err := eg.Wait(); err != nil {
-			ccp.log.Warn("Error querying block data", zap.Error(err))
+	// with the tx-search catch-up strategy, only the latest block is queried after searching the blocks before it.
+	// If the latest block can not be queried, the search is repeated next cycle.
+	if ccp.useTxSearch(persistence) {
+		if err := ccp.catchUpWithTxSearch(ctx, firstBlock, persistence.latestHeight-1, ibcMessagesCache); err != nil {
+			ccp.log.Warn("Failed to catch up with tx_search, querying every block instead", zap.Error(err))
+		} else {
+			firstBlock = persistence.latestHeight
+		}
+	}
+
+	// blocks are queried concurrently while catching up, and processed in height order.
+	fetchCtx, cancelFetch := context.WithCancel(ctx)
+	defer cancelFetch()
+	blocks := fetchInOrder(fetchCtx, firstBlock, persistence.latestHeight, ccp.queryConcurrency(), ccp.queryBlockData)
+
+	for pending := range blocks {
+		block := <-pending
+		if block.err != nil {
+			ccp.log.Warn("Error querying block data", zap.Int64("height", block.height), zap.Error(block.err))
 			break
 		}
+		i, blockRes := block.height, block.data.blockRes
 
-		latestHeader = ibcHeader.(provider.TendermintIBCHeader)
+		latestHeader = block.data.ibcHeader.(provider.TendermintIBCHeader)
 
 		heightUint64 := uint64(i)
 
@@ -455,9 +501,48 @@ func (ccp *CosmosChainProcessor) queryCycle(ctx context.Context, persistence *qu
 
 	persistence.latestQueriedBlock = newLatestQueriedBlock
 
+	if time.Since(ccp.checkpointSaved) >= processor.StateSaveInterval {
+		ccp.checkpointLatestQueriedBlock(newLatestQueriedBlock)
+	}
+
 	return nil
 }
 
+// checkpointLatestQueriedBlock saves the latest queried block to the state store, if it changed since the last checkpoint.
+// While running, it is called at most every processor.StateSaveInterval, and once more when the processor stops.
+func (ccp *CosmosChainProcessor) checkpointLatestQueriedBlock(height int64) {
+	if ccp.stateStore == nil || height == ccp.checkpointHeight {
+		return
+	}
+	ccp.checkpointSaved = time.Now()
+	if err := ccp.stateStore.SaveLatestQueriedBlock(ccp.chainProvider.ChainId(), height); err != nil {
+		ccp.log.Warn("Failed to checkpoint latest queried block", zap.Error(err))
+		return
+	}
+	ccp.checkpointHeight = height
+}
+
+// checkpointedBlock returns the latest queried block persisted by a previous run, if any.
+// Checkpoints ahead of the latest height, e.g. after a chain restart from a snapshot, are ignored.
+func (ccp *CosmosChainProcessor) checkpointedBlock(latestHeight int64) (int64, bool) {
+	if ccp.stateStore == nil {
+		return 0, false
+	}
+	checkpoint, ok, err := ccp.stateStore.LatestQueriedBlock(ccp.chainProvider.ChainId())
+	if err != nil {
+		ccp.log.Warn("Failed to load latest queried block checkpoint", zap.Error(err))
+		return 0, false
+	}
+	if !ok || checkpoint > latestHeight {
+		return 0, false
+	}
+	ccp.log.Info("Resuming from checkpoint",
+		zap.Int64("latest_queried_block", checkpoint),
+		zap.Int64("latest_height", latestHeight),
+	)
+	return checkpoint, true
+}
+
 func (ccp *CosmosChainProcessor) CollectMetrics(ctx context.Context, persistence *queryCyclePersistence) {
 	ccp.CurrentBlockHeight(ctx, persistence)
 
@@ -485,22 +570,25 @@ func (ccp *CosmosChainProcessor) CurrentRelayerBalance(ctx context.Context) {
 		ccp.parsedGasPrices = &gp
 	}
 
-	// Get the balance for the chain provider's key
-	relayerWalletBalance, err := ccp.chainProvider.QueryBalance(ctx, ccp.chainProvider.Key())
-	if err != nil {
-		ccp.log.Error(
-			"Failed to query relayer balance",
-			zap.Error(err),
-		)
-	}
+	// Get the balance for each of the chain provider's signing keys
+	for _, key := range ccp.chainProvider.SigningKeys() {
+		relayerWalletBalance, err := ccp.chainProvider.QueryBalance(ctx, key)
+		if err != nil {
+			ccp.log.Error(
+				"Failed to query relayer balance",
+				zap.String("key", key),
+				zap.Error(err),
+			)
+		}
 
-	// Print the relevant gas prices
-	for _, gasDenom := range *ccp.parsedGasPrices {
-		for _, balance := range relayerWalletBalance {
-			if balance.Denom == gasDenom.Denom {
-				// Convert to a big float to get a float64 for metrics
-				f, _ := big.NewFloat(0.0).SetInt(balance.Amount.BigInt()).Float64()
-				ccp.metrics.SetWalletBalance(ccp.chainProvider.ChainId(), ccp.chainProvider.Key(), balance.Denom, f)
+		// Print the relevant gas prices
+		for _, gasDenom := range *ccp.parsedGasPrices {
+			for _, balance := range relayerWalletBalance {
+				if balance.Denom == gasDenom.Denom {
+					// Convert to a big float to get a float64 for metrics
+					f, _ := big.NewFloat(0.0).SetInt(balance.Amount.BigInt()).Float64()
+					ccp.metrics.SetWalletBalance(ccp.chainProvider.ChainId(), key, balance.Denom, f)
+				}
 			}
 		}
 	}
@@ -0,0 +1,153 @@
+package cosmos
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+)
+
+// FeeBudget caps the fees which can be spent on the chain within each window.
+// The fees spent are only tracked in memory, so the budget starts from zero again when the relayer restarts.
+type FeeBudget struct {
+	// Key the budget applies to. When empty, the budget caps the fees spent by all keys used on the chain combined.
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+
+	// Window is the length of the budget window, either "hour" or "day".
+	// Windows are aligned to UTC, so an hourly budget resets on the hour and a daily budget at midnight.
+	Window string `json:"window" yaml:"window"`
+
+	// MaxFees is the maximum fees spent within a window, e.g. "5000000uatom".
+	// Only the listed denoms are capped.
+	MaxFees string `json:"max-fees" yaml:"max-fees"`
+}
+
+// windowDuration returns the length of the budget window.
+func (b FeeBudget) windowDuration() (time.Duration, error) {
+	switch b.Window {
+	case "hour":
+		return time.Hour, nil
+	case "day":
+		return 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("%s is not a valid fee budget window, please ensure your window is one of: [hour, day]", b.Window)
+}
+
+// Validate checks the window and maximum fees of the budget.
+func (b FeeBudget) Validate() error {
+	if _, err := b.windowDuration(); err != nil {
+		return err
+	}
+	maxFees, err := sdk.ParseCoinsNormalized(b.MaxFees)
+	if err != nil {
+		return fmt.Errorf("invalid fee budget max-fees %q: %w", b.MaxFees, err)
+	}
+	if maxFees.Empty() {
+		return fmt.Errorf("fee budget max-fees must not be empty")
+	}
+	return nil
+}
+
+// feeBudgetSpend is the fees spent within the current window of a budget.
+type feeBudgetSpend struct {
+	windowStart time.Time
+	spent       sdk.Coins
+}
+
+type resolvedFeeBudget struct {
+	key     string
+	window  time.Duration
+	maxFees sdk.Coins
+}
+
+// feeBudgetTracker enforces the fee budgets of a chain.
+type feeBudgetTracker struct {
+	chainID string
+	budgets []resolvedFeeBudget
+
+	mu    sync.Mutex
+	spent map[int]*feeBudgetSpend
+
+	// called for each capped denom on every check, used for metrics.
+	onCheck func(key, denom string, exceeded bool)
+}
+
+// newFeeBudgetTracker returns a tracker for the budgets, or nil if there are none.
+// The budgets must have been validated.
+func newFeeBudgetTracker(chainID string, budgets []FeeBudget) *feeBudgetTracker {
+	if len(budgets) == 0 {
+		return nil
+	}
+	t := &feeBudgetTracker{
+		chainID: chainID,
+		spent:   make(map[int]*feeBudgetSpend),
+	}
+	for _, b := range budgets {
+		window, _ := b.windowDuration()
+		maxFees, _ := sdk.ParseCoinsNormalized(b.MaxFees)
+		t.budgets = append(t.budgets, resolvedFeeBudget{key: b.Key, window: window, maxFees: maxFees})
+	}
+	return t
+}
+
+// spend returns the spend of the budget in the window containing now.
+// A budget without a key is shared by all keys, so its spend is chain-wide.
+// The spend is reset when the window has rolled over.
+// mu must be held.
+func (t *feeBudgetTracker) spend(i int, now time.Time) *feeBudgetSpend {
+	windowStart := now.UTC().Truncate(t.budgets[i].window)
+	s, ok := t.spent[i]
+	if !ok || !s.windowStart.Equal(windowStart) {
+		s = &feeBudgetSpend{windowStart: windowStart}
+		t.spent[i] = s
+	}
+	return s
+}
+
+// check returns a FeeBudgetExceededError if spending the fees with the key would exceed any budget.
+func (t *feeBudgetTracker) check(key string, fees sdk.Coins, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var exceededErr error
+	for i, b := range t.budgets {
+		if b.key != "" && b.key != key {
+			continue
+		}
+		s := t.spend(i, now)
+		for _, max := range b.maxFees {
+			spent := s.spent.AmountOf(max.Denom)
+			exceeded := spent.Add(fees.AmountOf(max.Denom)).GT(max.Amount)
+			if t.onCheck != nil {
+				t.onCheck(key, max.Denom, exceeded)
+			}
+			if exceeded && exceededErr == nil {
+				exceededErr = &provider.FeeBudgetExceededError{
+					ChainID: t.chainID,
+					Key:     key,
+					Denom:   max.Denom,
+					Limit:   max.String(),
+					Spent:   sdk.NewCoin(max.Denom, spent).String(),
+					ResetAt: s.windowStart.Add(b.window),
+				}
+			}
+		}
+	}
+	return exceededErr
+}
+
+// record adds the fees spent with the key to each budget which applies to it.
+func (t *feeBudgetTracker) record(key string, fees sdk.Coins, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, b := range t.budgets {
+		if b.key != "" && b.key != key {
+			continue
+		}
+		s := t.spend(i, now)
+		s.spent = s.spent.Add(fees...)
+	}
+}
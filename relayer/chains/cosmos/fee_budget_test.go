@@ -0,0 +1,48 @@
+package cosmos
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeBudgetValidate(t *testing.T) {
+	require.NoError(t, FeeBudget{Window: "hour", MaxFees: "1000uatom"}.Validate())
+	require.NoError(t, FeeBudget{Key: "default", Window: "day", MaxFees: "1000uatom,5uosmo"}.Validate())
+	require.Error(t, FeeBudget{Window: "week", MaxFees: "1000uatom"}.Validate())
+	require.Error(t, FeeBudget{Window: "day", MaxFees: ""}.Validate())
+	require.Error(t, FeeBudget{Window: "day", MaxFees: "uatom"}.Validate())
+}
+
+func TestFeeBudgetTracker(t *testing.T) {
+	tracker := newFeeBudgetTracker("cosmoshub-4", []FeeBudget{
+		{Window: "hour", MaxFees: "1000uatom"},
+		{Key: "other", Window: "day", MaxFees: "10uatom"},
+	})
+	start := time.Date(2023, 3, 1, 10, 15, 0, 0, time.UTC)
+	fees := sdk.NewCoins(sdk.NewInt64Coin("uatom", 400), sdk.NewInt64Coin("uosmo", 10000))
+
+	require.NoError(t, tracker.check("default", fees, start))
+	tracker.record("default", fees, start)
+	require.NoError(t, tracker.check("default", fees, start))
+	tracker.record("default", fees, start)
+
+	err := tracker.check("default", fees, start.Add(10*time.Minute))
+	var budgetErr *provider.FeeBudgetExceededError
+	require.True(t, errors.As(err, &budgetErr))
+	require.Equal(t, "uatom", budgetErr.Denom)
+	require.Equal(t, "800uatom", budgetErr.Spent)
+	require.Equal(t, time.Date(2023, 3, 1, 11, 0, 0, 0, time.UTC), budgetErr.ResetAt)
+
+	err = tracker.check("hot-wallet", fees, start.Add(10*time.Minute))
+	require.True(t, errors.As(err, &budgetErr), "budget without a key should be shared by all keys")
+	require.Equal(t, "800uatom", budgetErr.Spent)
+
+	require.NoError(t, tracker.check("default", fees, start.Add(45*time.Minute)), "budget should reset when the window rolls over")
+
+	require.Error(t, tracker.check("other", fees, start), "budget for a specific key should only apply to that key")
+}
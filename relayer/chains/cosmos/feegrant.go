@@ -0,0 +1,127 @@
+package cosmos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/feegrant"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+)
+
+// FeeAllowance builds the allowance granted to relayer keys to pay for their fees.
+// spendLimit is the total fees which can be spent, unlimited if empty. With a non-zero period,
+// a PeriodicAllowance is returned, which additionally limits the fees spent within each period to periodLimit.
+func FeeAllowance(spendLimit, periodLimit sdk.Coins, period time.Duration, expiration *time.Time) (feegrant.FeeAllowanceI, error) {
+	basic := feegrant.BasicAllowance{
+		SpendLimit: spendLimit,
+		Expiration: expiration,
+	}
+	if period == 0 {
+		if !periodLimit.Empty() {
+			return nil, fmt.Errorf("period limit requires a period")
+		}
+		return &basic, nil
+	}
+	if periodLimit.Empty() {
+		return nil, fmt.Errorf("period requires a period limit")
+	}
+	periodic := &feegrant.PeriodicAllowance{
+		Basic:            basic,
+		Period:           period,
+		PeriodSpendLimit: periodLimit,
+		PeriodCanSpend:   periodLimit,
+		PeriodReset:      time.Now().Add(period),
+	}
+	if err := periodic.ValidateBasic(); err != nil {
+		return nil, err
+	}
+	return periodic, nil
+}
+
+// GrantFeeAllowance grants the allowance from the granter key to each of the grantee addresses in a single transaction.
+func (cc *CosmosProvider) GrantFeeAllowance(
+	ctx context.Context,
+	granterKey string,
+	grantees []string,
+	allowance feegrant.FeeAllowanceI,
+	memo string,
+) (*provider.RelayerTxResponse, error) {
+	granter, err := cc.keyAddress(granterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var msgs []provider.RelayerMessage
+	for _, g := range grantees {
+		grantee, err := cc.DecodeBech32AccAddr(g)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grantee %s: %w", g, err)
+		}
+		msg, err := feegrant.NewMsgGrantAllowance(allowance, granter, grantee)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, NewCosmosMessage(msg))
+	}
+
+	return cc.sendFeeGrantMessages(ctx, granterKey, msgs, memo)
+}
+
+// RevokeFeeAllowance revokes the allowances from the granter key to each of the grantee addresses in a single transaction.
+func (cc *CosmosProvider) RevokeFeeAllowance(
+	ctx context.Context,
+	granterKey string,
+	grantees []string,
+	memo string,
+) (*provider.RelayerTxResponse, error) {
+	granter, err := cc.keyAddress(granterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var msgs []provider.RelayerMessage
+	for _, g := range grantees {
+		grantee, err := cc.DecodeBech32AccAddr(g)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grantee %s: %w", g, err)
+		}
+		msg := feegrant.NewMsgRevokeAllowance(granter, grantee)
+		msgs = append(msgs, NewCosmosMessage(&msg))
+	}
+
+	return cc.sendFeeGrantMessages(ctx, granterKey, msgs, memo)
+}
+
+func (cc *CosmosProvider) sendFeeGrantMessages(
+	ctx context.Context,
+	granterKey string,
+	msgs []provider.RelayerMessage,
+	memo string,
+) (*provider.RelayerTxResponse, error) {
+	res, success, err := cc.SendMessagesWithKey(ctx, granterKey, msgs, memo)
+	if err != nil {
+		if res != nil {
+			cc.LogFailedTx(res, err, msgs)
+		}
+		return nil, fmt.Errorf("failed to send messages on chain{%s}: %w", cc.ChainId(), err)
+	}
+	if !success {
+		cc.LogFailedTx(res, nil, msgs)
+		return nil, fmt.Errorf("tx failed on chain{%s}: %s", cc.ChainId(), res.Data)
+	}
+	return res, nil
+}
+
+// QueryFeeAllowance returns the allowance granted by the granter to the grantee.
+func (cc *CosmosProvider) QueryFeeAllowance(ctx context.Context, granter, grantee string) (*feegrant.Grant, error) {
+	res, err := feegrant.NewQueryClient(cc).Allowance(ctx, &feegrant.QueryAllowanceRequest{
+		Granter: granter,
+		Grantee: grantee,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Allowance, nil
+}
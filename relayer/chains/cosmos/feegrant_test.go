@@ -0,0 +1,37 @@
+package cosmos
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/feegrant"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeAllowance(t *testing.T) {
+	limit := sdk.NewCoins(sdk.NewInt64Coin("uatom", 10000000))
+	periodLimit := sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000000))
+
+	allowance, err := FeeAllowance(limit, nil, 0, nil)
+	require.NoError(t, err)
+	basic, ok := allowance.(*feegrant.BasicAllowance)
+	require.True(t, ok)
+	require.Equal(t, limit, basic.SpendLimit)
+
+	allowance, err = FeeAllowance(nil, periodLimit, 24*time.Hour, nil)
+	require.NoError(t, err)
+	periodic, ok := allowance.(*feegrant.PeriodicAllowance)
+	require.True(t, ok)
+	require.Equal(t, periodLimit, periodic.PeriodSpendLimit)
+	require.Equal(t, periodLimit, periodic.PeriodCanSpend)
+
+	_, err = FeeAllowance(nil, periodLimit, 0, nil)
+	require.Error(t, err, "period limit without a period should be rejected")
+
+	_, err = FeeAllowance(nil, nil, 24*time.Hour, nil)
+	require.Error(t, err, "period without a period limit should be rejected")
+
+	_, err = FeeAllowance(limit, sdk.NewCoins(sdk.NewInt64Coin("uosmo", 1000000)), 24*time.Hour, nil)
+	require.Error(t, err, "period limit in a denom without a spend limit should be rejected")
+}
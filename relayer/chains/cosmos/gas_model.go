@@ -0,0 +1,197 @@
+package cosmos
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"go.uber.org/zap"
+)
+
+const (
+	// Number of recent gas samples kept for each combination of message types.
+	gasModelSamples = 20
+
+	// Minimum number of gas samples required before gas is estimated without simulating.
+	gasModelMinSamples = 3
+)
+
+// gasSample is the gas used by a transaction with a number of messages.
+type gasSample struct {
+	msgs    int
+	gasUsed uint64
+}
+
+// gasModel estimates the gas used by transactions from the gas used by recent transactions,
+// so that the transactions do not need to be simulated before they are broadcast.
+// Transactions are grouped by the message types they contain, e.g. MsgUpdateClient with MsgRecvPacket,
+// and by the channels of the packets they relay, since the gas used by a packet depends on the application
+// of its channel, e.g. a transfer or an interchain account transaction. The gas used by each group is modeled as a fixed cost plus a cost per message, fit to the samples.
+type gasModel struct {
+	mu      sync.Mutex
+	samples map[string][]gasSample
+}
+
+func newGasModel() *gasModel {
+	return &gasModel{samples: make(map[string][]gasSample)}
+}
+
+// gasModelKey returns the sorted, distinct type URLs of the msgs,
+// along with the port and channel of the msgs which relay packets.
+func gasModelKey(msgs []sdk.Msg) string {
+	seen := make(map[string]bool)
+	var types []string
+	for _, msg := range msgs {
+		t := sdk.MsgTypeURL(msg)
+		if portID, channelID, ok := packetChannel(msg); ok {
+			t += "@" + portID + "/" + channelID
+		}
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return strings.Join(types, ",")
+}
+
+// record adds the gas used by a simulated or executed transaction with the msgs.
+func (m *gasModel) record(msgs []sdk.Msg, gasUsed uint64) {
+	if len(msgs) == 0 || gasUsed == 0 {
+		return
+	}
+	k := gasModelKey(msgs)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	samples := append(m.samples[k], gasSample{msgs: len(msgs), gasUsed: gasUsed})
+	if len(samples) > gasModelSamples {
+		samples = samples[len(samples)-gasModelSamples:]
+	}
+	m.samples[k] = samples
+}
+
+// reset drops the samples for transactions with the msgs, e.g. after running out of gas,
+// so that they are simulated again.
+func (m *gasModel) reset(msgs []sdk.Msg) {
+	k := gasModelKey(msgs)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.samples, k)
+}
+
+// estimate returns the estimated gas used by a transaction with the msgs.
+// ok is false if there are not enough samples to estimate it, in which case the transaction must be simulated.
+// The estimate is fit to the samples with a least squares line, raised by the largest amount
+// by which the line underestimates a sample. Only batch sizes up to twice the largest sample are estimated.
+func (m *gasModel) estimate(msgs []sdk.Msg) (gasUsed uint64, ok bool) {
+	if len(msgs) == 0 {
+		return 0, false
+	}
+	k := gasModelKey(msgs)
+
+	m.mu.Lock()
+	samples := append([]gasSample(nil), m.samples[k]...)
+	m.mu.Unlock()
+
+	if len(samples) < gasModelMinSamples {
+		return 0, false
+	}
+
+	n := float64(len(msgs))
+	var sumN, sumGas, maxN float64
+	for _, s := range samples {
+		sumN += float64(s.msgs)
+		sumGas += float64(s.gasUsed)
+		if float64(s.msgs) > maxN {
+			maxN = float64(s.msgs)
+		}
+	}
+	if n > 2*maxN {
+		return 0, false
+	}
+	meanN := sumN / float64(len(samples))
+	meanGas := sumGas / float64(len(samples))
+
+	var cov, variance float64
+	for _, s := range samples {
+		dn := float64(s.msgs) - meanN
+		cov += dn * (float64(s.gasUsed) - meanGas)
+		variance += dn * dn
+	}
+
+	var perMsg float64
+	if variance == 0 {
+		// all samples have the same number of messages.
+		if n != meanN {
+			return 0, false
+		}
+	} else {
+		perMsg = cov / variance
+		if perMsg < 0 {
+			return 0, false
+		}
+	}
+	base := meanGas - perMsg*meanN
+
+	var margin float64
+	for _, s := range samples {
+		if r := float64(s.gasUsed) - (base + perMsg*float64(s.msgs)); r > margin {
+			margin = r
+		}
+	}
+
+	estimate := base + perMsg*n + margin
+	if estimate <= 0 {
+		return 0, false
+	}
+	return uint64(estimate), true
+}
+
+// estimateGas returns the gas limit for a transaction with the msgs. If the gas estimation cache is enabled
+// and the gas model has an estimate, it is used instead of simulating the transaction.
+func (cc *CosmosProvider) estimateGas(ctx context.Context, txf tx.Factory, keyName string, msgs []sdk.Msg) (uint64, error) {
+	if cc.gasModel != nil {
+		if gasUsed, ok := cc.gasModel.estimate(msgs); ok {
+			adjusted := uint64(txf.GasAdjustment() * float64(gasUsed))
+			cc.log.Debug("Estimated gas without simulation",
+				zap.String("msg_types", gasModelKey(msgs)),
+				zap.Int("msgs", len(msgs)),
+				zap.Uint64("gas_used", gasUsed),
+				zap.Uint64("gas_wanted", adjusted),
+			)
+			return adjusted, nil
+		}
+	}
+
+	simRes, adjusted, err := cc.calculateGas(ctx, txf, keyName, msgs...)
+	if err != nil {
+		return 0, err
+	}
+	if cc.gasModel != nil && simRes.GasInfo != nil {
+		cc.gasModel.record(msgs, simRes.GasInfo.GasUsed)
+	}
+	return adjusted, nil
+}
+
+// handleOutOfGas drops the gas samples for transactions with the msgs if the transaction ran out of gas,
+// so that they are simulated again.
+func (cc *CosmosProvider) handleOutOfGas(msgs []provider.RelayerMessage, err error) {
+	if cc.gasModel == nil || !errors.Is(err, sdkerrors.ErrOutOfGas) {
+		return
+	}
+	cc.log.Warn("Transaction ran out of gas, simulating transactions with the same message types again",
+		zap.String("msg_types", gasModelKey(CosmosMsgs(msgs...))),
+	)
+	cc.gasModel.reset(CosmosMsgs(msgs...))
+}
@@ -0,0 +1,80 @@
+package cosmos
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+	chantypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/stretchr/testify/require"
+)
+
+func updateAndRecvMsgs(recvs int) []sdk.Msg {
+	msgs := []sdk.Msg{&clienttypes.MsgUpdateClient{}}
+	for i := 0; i < recvs; i++ {
+		msgs = append(msgs, &chantypes.MsgRecvPacket{})
+	}
+	return msgs
+}
+
+func TestGasModelEstimate(t *testing.T) {
+	m := newGasModel()
+
+	_, ok := m.estimate(updateAndRecvMsgs(1))
+	require.False(t, ok, "no estimate without samples")
+
+	// 100000 for the update client, 50000 per recv, with some noise.
+	m.record(updateAndRecvMsgs(1), 150000)
+	m.record(updateAndRecvMsgs(2), 200000)
+	_, ok = m.estimate(updateAndRecvMsgs(1))
+	require.False(t, ok, "no estimate with too few samples")
+
+	m.record(updateAndRecvMsgs(4), 302000)
+	gas, ok := m.estimate(updateAndRecvMsgs(3))
+	require.True(t, ok)
+	require.InDelta(t, 250000, gas, 2000)
+	require.GreaterOrEqual(t, gas, uint64(250000), "estimate should include the margin of the worst sample")
+
+	_, ok = m.estimate(updateAndRecvMsgs(20))
+	require.False(t, ok, "batches much larger than the samples should be simulated")
+
+	_, ok = m.estimate([]sdk.Msg{&chantypes.MsgAcknowledgement{}})
+	require.False(t, ok, "other message types should be simulated")
+
+	m.reset(updateAndRecvMsgs(1))
+	_, ok = m.estimate(updateAndRecvMsgs(3))
+	require.False(t, ok, "no estimate after reset")
+}
+
+func TestGasModelSameBatchSize(t *testing.T) {
+	m := newGasModel()
+	for _, gas := range []uint64{140000, 150000, 145000} {
+		m.record(updateAndRecvMsgs(1), gas)
+	}
+
+	gas, ok := m.estimate(updateAndRecvMsgs(1))
+	require.True(t, ok)
+	require.Equal(t, uint64(150000), gas)
+
+	_, ok = m.estimate(updateAndRecvMsgs(2))
+	require.False(t, ok, "cost per message is unknown with samples of one batch size")
+}
+
+func TestGasModelKeyByChannel(t *testing.T) {
+	recv := func(portID, channelID string) sdk.Msg {
+		return &chantypes.MsgRecvPacket{Packet: chantypes.Packet{DestinationPort: portID, DestinationChannel: channelID}}
+	}
+
+	transfer := gasModelKey([]sdk.Msg{&clienttypes.MsgUpdateClient{}, recv("transfer", "channel-0"), recv("transfer", "channel-0")})
+	require.Equal(t, "/ibc.core.channel.v1.MsgRecvPacket@transfer/channel-0,/ibc.core.client.v1.MsgUpdateClient", transfer)
+
+	ica := gasModelKey([]sdk.Msg{&clienttypes.MsgUpdateClient{}, recv("icahost", "channel-1")})
+	require.NotEqual(t, transfer, ica, "packets of other channels should be modeled separately")
+
+	m := newGasModel()
+	for i := 0; i < gasModelMinSamples; i++ {
+		m.record([]sdk.Msg{&clienttypes.MsgUpdateClient{}, recv("transfer", "channel-0")}, 150000)
+	}
+	_, ok := m.estimate([]sdk.Msg{&clienttypes.MsgUpdateClient{}, recv("icahost", "channel-1")})
+	require.False(t, ok, "packets of a channel without samples should be simulated")
+}
@@ -0,0 +1,258 @@
+package cosmos
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// GasPriceSourceFeemarket queries the gas price from the feemarket module (x/feemarket).
+	GasPriceSourceFeemarket = "feemarket"
+
+	// GasPriceSourceEIP1559 queries the EIP-1559 base fee from the ethermint feemarket module.
+	GasPriceSourceEIP1559 = "eip1559"
+
+	defaultGasPriceUpdateInterval = time.Minute
+	gasPriceQueryTimeout          = 10 * time.Second
+
+	feemarketGasPriceQueryPath = "/feemarket.feemarket.v1.Query/GasPrice"
+	ethermintBaseFeeQueryPath  = "/ethermint.feemarket.v1.Query/BaseFee"
+)
+
+// DynamicGasPrice configures the gas price to be queried from the chain's fee market,
+// instead of using the static gas-prices. The denom of gas-prices is used, which is also
+// the gas price until the first successful query.
+type DynamicGasPrice struct {
+	// Source of the gas price, either "feemarket" or "eip1559".
+	Source string `json:"source" yaml:"source"`
+
+	// How often the gas price is queried, e.g. "30s". Defaults to 1m.
+	UpdateInterval string `json:"update-interval,omitempty" yaml:"update-interval,omitempty"`
+
+	// Multiplier applied to the queried gas price, e.g. 1.2 to outbid a rising base fee. Defaults to 1.
+	Multiplier float64 `json:"multiplier,omitempty" yaml:"multiplier,omitempty"`
+
+	// Bounds applied to the gas price after the multiplier, e.g. "0.0025". Unbounded if empty.
+	MinGasPrice string `json:"min-gas-price,omitempty" yaml:"min-gas-price,omitempty"`
+	MaxGasPrice string `json:"max-gas-price,omitempty" yaml:"max-gas-price,omitempty"`
+}
+
+// Validate checks the source, interval, multiplier and bounds of the dynamic gas price.
+func (d DynamicGasPrice) Validate() error {
+	switch d.Source {
+	case GasPriceSourceFeemarket, GasPriceSourceEIP1559:
+	default:
+		return fmt.Errorf("%s is not a valid gas price source, please ensure your source is one of: [%s, %s]",
+			d.Source, GasPriceSourceFeemarket, GasPriceSourceEIP1559)
+	}
+	if _, err := d.updateInterval(); err != nil {
+		return err
+	}
+	if d.Multiplier < 0 {
+		return fmt.Errorf("gas price multiplier must not be negative")
+	}
+	min, max, err := d.bounds()
+	if err != nil {
+		return err
+	}
+	if min != nil && max != nil && min.GT(*max) {
+		return fmt.Errorf("min-gas-price %s is greater than max-gas-price %s", min, max)
+	}
+	return nil
+}
+
+func (d DynamicGasPrice) updateInterval() (time.Duration, error) {
+	if d.UpdateInterval == "" {
+		return defaultGasPriceUpdateInterval, nil
+	}
+	interval, err := time.ParseDuration(d.UpdateInterval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid gas price update-interval: %w", err)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("gas price update-interval must be positive")
+	}
+	return interval, nil
+}
+
+// bounds returns the minimum and maximum gas price, nil if not set.
+func (d DynamicGasPrice) bounds() (min *sdk.Dec, max *sdk.Dec, err error) {
+	if d.MinGasPrice != "" {
+		v, err := sdk.NewDecFromStr(d.MinGasPrice)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid min-gas-price: %w", err)
+		}
+		min = &v
+	}
+	if d.MaxGasPrice != "" {
+		v, err := sdk.NewDecFromStr(d.MaxGasPrice)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid max-gas-price: %w", err)
+		}
+		max = &v
+	}
+	return min, max, nil
+}
+
+// apply returns the gas price to use for the gas price queried from the chain,
+// after applying the multiplier and the bounds. The config must have been validated.
+func (d DynamicGasPrice) apply(price sdk.Dec) sdk.Dec {
+	if d.Multiplier > 0 {
+		multiplier, err := sdk.NewDecFromStr(strconv.FormatFloat(d.Multiplier, 'f', -1, 64))
+		if err == nil {
+			price = price.Mul(multiplier)
+		}
+	}
+	min, max, _ := d.bounds()
+	if min != nil && price.LT(*min) {
+		price = *min
+	}
+	if max != nil && price.GT(*max) {
+		price = *max
+	}
+	return price
+}
+
+// gasPrices returns the gas prices for transactions, which are the latest dynamic gas price if any,
+// otherwise the configured gas-prices.
+func (cc *CosmosProvider) gasPrices() string {
+	cc.gasPriceMu.Lock()
+	defer cc.gasPriceMu.Unlock()
+	if cc.dynamicGasPrice != "" {
+		return cc.dynamicGasPrice
+	}
+	return cc.PCfg.GasPrices
+}
+
+// runGasPriceUpdates queries the gas price from the chain's fee market every update interval until ctx is done,
+// so that transactions are built with the latest gas price without waiting for the query.
+func (cc *CosmosProvider) runGasPriceUpdates(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		cc.updateGasPrice(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// updateGasPrice queries the gas price from the chain's fee market if a dynamic gas price is configured.
+// If the query fails, the previous gas price is kept.
+func (cc *CosmosProvider) updateGasPrice(ctx context.Context) {
+	cfg := cc.PCfg.DynamicGasPrice
+	if cfg == nil {
+		return
+	}
+
+	staticPrice, err := sdk.ParseDecCoin(cc.PCfg.GasPrices)
+	if err != nil {
+		cc.log.Warn("Failed to parse gas-prices for dynamic gas price", zap.Error(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, gasPriceQueryTimeout)
+	defer cancel()
+
+	queried, err := cc.queryGasPrice(ctx, cfg.Source, staticPrice.Denom)
+	if err != nil {
+		cc.log.Warn("Failed to query dynamic gas price, keeping the previous gas price",
+			zap.String("source", cfg.Source),
+			zap.String("gas_prices", cc.gasPrices()),
+			zap.Error(err),
+		)
+		return
+	}
+
+	gasPrices := sdk.NewDecCoinFromDec(staticPrice.Denom, cfg.apply(queried)).String()
+
+	cc.gasPriceMu.Lock()
+	changed := cc.dynamicGasPrice != gasPrices
+	cc.dynamicGasPrice = gasPrices
+	cc.gasPriceMu.Unlock()
+
+	if changed {
+		cc.log.Debug("Updated dynamic gas price",
+			zap.String("source", cfg.Source),
+			zap.String("queried", queried.String()),
+			zap.String("gas_prices", gasPrices),
+		)
+	}
+}
+
+// queryGasPrice queries the current gas price for the denom from the fee market of the chain.
+func (cc *CosmosProvider) queryGasPrice(ctx context.Context, source, denom string) (sdk.Dec, error) {
+	switch source {
+	case GasPriceSourceFeemarket:
+		// GasPriceRequest{denom = 1}
+		req := protowire.AppendTag(nil, 1, protowire.BytesType)
+		req = protowire.AppendString(req, denom)
+		res, err := cc.QueryABCI(ctx, abci.RequestQuery{Path: feemarketGasPriceQueryPath, Data: req})
+		if err != nil {
+			return sdk.Dec{}, err
+		}
+		// GasPriceResponse{price = 1}
+		bz, err := protoBytesField(res.Value, 1)
+		if err != nil {
+			return sdk.Dec{}, err
+		}
+		var price sdk.DecCoin
+		if err := price.Unmarshal(bz); err != nil {
+			return sdk.Dec{}, err
+		}
+		if price.Denom != denom {
+			return sdk.Dec{}, fmt.Errorf("queried gas price denom %s does not match gas-prices denom %s", price.Denom, denom)
+		}
+		return price.Amount, nil
+
+	case GasPriceSourceEIP1559:
+		res, err := cc.QueryABCI(ctx, abci.RequestQuery{Path: ethermintBaseFeeQueryPath})
+		if err != nil {
+			return sdk.Dec{}, err
+		}
+		// QueryBaseFeeResponse{base_fee = 1}
+		bz, err := protoBytesField(res.Value, 1)
+		if err != nil {
+			return sdk.Dec{}, err
+		}
+		var baseFee sdk.Int
+		if err := baseFee.Unmarshal(bz); err != nil {
+			return sdk.Dec{}, err
+		}
+		return sdk.NewDecFromInt(baseFee), nil
+	}
+	return sdk.Dec{}, fmt.Errorf("unknown gas price source: %s", source)
+}
+
+// protoBytesField returns the value of the length-delimited field of the protobuf encoded message.
+func protoBytesField(msg []byte, field protowire.Number) ([]byte, error) {
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		msg = msg[n:]
+		if num == field && typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(msg)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			return v, nil
+		}
+		n = protowire.ConsumeFieldValue(num, typ, msg)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		msg = msg[n:]
+	}
+	return nil, fmt.Errorf("field %d not found in response", field)
+}
@@ -0,0 +1,49 @@
+package cosmos
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestDynamicGasPriceValidate(t *testing.T) {
+	require.NoError(t, DynamicGasPrice{Source: GasPriceSourceFeemarket}.Validate())
+	require.NoError(t, DynamicGasPrice{Source: GasPriceSourceEIP1559, UpdateInterval: "30s", Multiplier: 1.2, MinGasPrice: "0.0025", MaxGasPrice: "0.1"}.Validate())
+	require.Error(t, DynamicGasPrice{Source: "oracle"}.Validate())
+	require.Error(t, DynamicGasPrice{Source: GasPriceSourceFeemarket, UpdateInterval: "soon"}.Validate())
+	require.Error(t, DynamicGasPrice{Source: GasPriceSourceFeemarket, Multiplier: -1}.Validate())
+	require.Error(t, DynamicGasPrice{Source: GasPriceSourceFeemarket, MinGasPrice: "0.1", MaxGasPrice: "0.01"}.Validate())
+}
+
+func TestDynamicGasPriceApply(t *testing.T) {
+	d := DynamicGasPrice{Source: GasPriceSourceFeemarket, Multiplier: 1.5, MinGasPrice: "0.01", MaxGasPrice: "0.1"}
+
+	require.Equal(t, sdk.MustNewDecFromStr("0.03"), d.apply(sdk.MustNewDecFromStr("0.02")))
+	require.Equal(t, sdk.MustNewDecFromStr("0.01"), d.apply(sdk.MustNewDecFromStr("0.001")), "gas price should be raised to the minimum")
+	require.Equal(t, sdk.MustNewDecFromStr("0.1"), d.apply(sdk.MustNewDecFromStr("1")), "gas price should be capped at the maximum")
+
+	d = DynamicGasPrice{Source: GasPriceSourceFeemarket}
+	require.Equal(t, sdk.MustNewDecFromStr("0.02"), d.apply(sdk.MustNewDecFromStr("0.02")), "gas price should be used as is by default")
+}
+
+func TestProtoBytesField(t *testing.T) {
+	price := sdk.NewDecCoinFromDec("uatom", sdk.MustNewDecFromStr("0.0025"))
+	bz, err := price.Marshal()
+	require.NoError(t, err)
+
+	msg := protowire.AppendTag(nil, 2, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, 7)
+	msg = protowire.AppendTag(msg, 1, protowire.BytesType)
+	msg = protowire.AppendBytes(msg, bz)
+
+	field, err := protoBytesField(msg, 1)
+	require.NoError(t, err)
+	var decoded sdk.DecCoin
+	require.NoError(t, decoded.Unmarshal(field))
+	require.Equal(t, price, decoded)
+
+	_, err = protoBytesField(msg, 3)
+	require.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package cosmos
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// grpcTarget returns the target to dial for the grpc-addr, and whether to connect with TLS.
+// The address is either "host:port", "http://host:port" or "https://host:port".
+// Without a port, an https address defaults to port 443.
+func grpcTarget(addr string) (target string, useTLS bool, err error) {
+	switch {
+	case strings.HasPrefix(addr, "https://"):
+		target, useTLS = strings.TrimPrefix(addr, "https://"), true
+	case strings.HasPrefix(addr, "http://"):
+		target = strings.TrimPrefix(addr, "http://")
+	default:
+		target = addr
+	}
+	target = strings.TrimSuffix(target, "/")
+
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		if !useTLS {
+			return "", false, fmt.Errorf("grpc address %s must include a port: %w", addr, err)
+		}
+		target = net.JoinHostPort(target, "443")
+	}
+	return target, useTLS, nil
+}
+
+// dialGRPC connects to the grpc-addr. The connection is established in the background,
+// so a node which is not reachable yet only fails the queries sent to it.
+func dialGRPC(addr string) (*grpc.ClientConn, error) {
+	target, useTLS, err := grpcTarget(addr)
+	if err != nil {
+		return nil, err
+	}
+	creds := insecure.NewCredentials()
+	if useTLS {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	return grpc.Dial(target, grpc.WithTransportCredentials(creds))
+}
+
+// grpcFallback returns true if a query which failed over gRPC with the err should be retried as an ABCI query over RPC:
+// the gRPC node could not be reached or timed out, or does not serve the query, e.g. a module without a gRPC query service.
+// Errors returned by the query itself, e.g. not found, are not retried.
+func grpcFallback(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Unimplemented, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// invokeGRPC sends the query over gRPC. ok is false if the query must be sent as an ABCI query over RPC instead,
+// either because it requires a proof, which is only returned by ABCI queries, or because the gRPC query failed.
+func (cc *CosmosProvider) invokeGRPC(ctx context.Context, method string, req, reply interface{}, prove bool, opts ...grpc.CallOption) (ok bool, err error) {
+	if cc.grpcConn == nil || prove {
+		return false, nil
+	}
+	err = cc.grpcConn.Invoke(ctx, method, req, reply, opts...)
+	if err != nil && grpcFallback(ctx, err) {
+		cc.log.Debug("gRPC query failed, falling back to RPC",
+			zap.String("method", method),
+			zap.Error(err),
+		)
+		return false, nil
+	}
+	return true, err
+}
@@ -0,0 +1,103 @@
+package cosmos
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const healthCheckMethod = "/grpc.health.v1.Health/Check"
+
+func TestGRPCTarget(t *testing.T) {
+	for _, tc := range []struct {
+		addr   string
+		target string
+		tls    bool
+		err    bool
+	}{
+		{addr: "localhost:9090", target: "localhost:9090"},
+		{addr: "http://localhost:9090", target: "localhost:9090"},
+		{addr: "https://grpc.example.com", target: "grpc.example.com:443", tls: true},
+		{addr: "https://grpc.example.com:9443/", target: "grpc.example.com:9443", tls: true},
+		{addr: "localhost", err: true},
+	} {
+		target, useTLS, err := grpcTarget(tc.addr)
+		if tc.err {
+			require.Error(t, err, tc.addr)
+			continue
+		}
+		require.NoError(t, err, tc.addr)
+		require.Equal(t, tc.target, target, tc.addr)
+		require.Equal(t, tc.tls, useTLS, tc.addr)
+	}
+}
+
+// testGRPCProvider returns a provider connected to a gRPC server serving the health service,
+// and to an RPC server answering every ABCI query with a serving health check response.
+func testGRPCProvider(t *testing.T, rpcRequests *int32) *CosmosProvider {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	// HealthCheckResponse{status: SERVING}
+	rpc := jsonRPCServer(t, `{"response":{"code":0,"value":"CAE=","height":"5"}}`, "", rpcRequests)
+	t.Cleanup(rpc.Close)
+
+	pool, err := newRPCPool(zap.NewNop(), "test", []string{rpc.URL}, time.Second)
+	require.NoError(t, err)
+	pool.lastHealthCheck = time.Now()
+
+	conn, err := dialGRPC(lis.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return &CosmosProvider{log: zap.NewNop(), RPCClient: pool, grpcConn: conn}
+}
+
+func TestInvokeGRPC(t *testing.T) {
+	var rpcRequests int32
+	cc := testGRPCProvider(t, &rpcRequests)
+	ctx := context.Background()
+
+	var res healthpb.HealthCheckResponse
+	require.NoError(t, cc.Invoke(ctx, healthCheckMethod, &healthpb.HealthCheckRequest{}, &res))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, res.Status)
+	require.Equal(t, int32(0), atomic.LoadInt32(&rpcRequests))
+
+	// errors returned by the query are not retried over RPC.
+	err := cc.Invoke(ctx, healthCheckMethod, &healthpb.HealthCheckRequest{Service: "unknown"}, &res)
+	require.Equal(t, codes.NotFound, status.Code(err))
+	require.Equal(t, int32(0), atomic.LoadInt32(&rpcRequests))
+
+	// queries which require a proof are sent over RPC.
+	proveCtx := metadata.AppendToOutgoingContext(ctx, "x-cosmos-query-prove", "true")
+	res = healthpb.HealthCheckResponse{}
+	require.NoError(t, cc.Invoke(proveCtx, healthCheckMethod, &healthpb.HealthCheckRequest{}, &res))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, res.Status)
+	require.Equal(t, int32(1), atomic.LoadInt32(&rpcRequests))
+}
+
+func TestInvokeGRPCFallback(t *testing.T) {
+	var rpcRequests int32
+	cc := testGRPCProvider(t, &rpcRequests)
+
+	// the gRPC server does not serve the query, so it is sent over RPC.
+	var res healthpb.HealthCheckResponse
+	require.NoError(t, cc.Invoke(context.Background(), "/custom.module.v1.Query/Health", &healthpb.HealthCheckRequest{}, &res))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, res.Status)
+	require.Equal(t, int32(1), atomic.LoadInt32(&rpcRequests))
+}
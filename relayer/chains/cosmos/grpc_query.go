@@ -58,8 +58,22 @@ func (cc *CosmosProvider) Invoke(ctx context.Context, method string, req, reply
 		return err
 	}
 
-	// Case 2. Querying state.
+	// Case 2. Querying state, over gRPC if a grpc-addr is configured and the query does not require a proof.
 	inMd, _ := metadata.FromOutgoingContext(ctx)
+	prove, err := GetProveFromMetadata(inMd)
+	if err != nil {
+		return err
+	}
+	if ok, err := cc.invokeGRPC(ctx, method, req, reply, prove, opts...); ok {
+		if err != nil {
+			return err
+		}
+		if cc.Cdc.InterfaceRegistry != nil {
+			return types.UnpackInterfaces(reply, cc.Cdc.Marshaler)
+		}
+		return nil
+	}
+
 	abciRes, outMd, err := cc.RunGRPCQuery(ctx, method, req, inMd)
 	if err != nil {
 		return err
@@ -85,8 +99,12 @@ func (cc *CosmosProvider) Invoke(ctx context.Context, method string, req, reply
 	return nil
 }
 
-// NewStream implements the grpc ClientConn.NewStream method
-func (cc *CosmosProvider) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+// NewStream implements the grpc ClientConn.NewStream method.
+// Streaming is only supported over gRPC, which requires a grpc-addr.
+func (cc *CosmosProvider) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if cc.grpcConn != nil {
+		return cc.grpcConn.NewStream(ctx, desc, method, opts...)
+	}
 	return nil, fmt.Errorf("streaming rpc not supported")
 }
 
@@ -177,7 +195,7 @@ func (cc *CosmosProvider) TxServiceBroadcast(ctx context.Context, req *tx.Broadc
 
 	wg.Add(1)
 
-	if err := cc.broadcastTx(ctx, req.TxBytes, nil, nil, ctx, blockTimeout, callback); err != nil {
+	if err := cc.broadcastTx(ctx, req.TxBytes, nil, cc.Key(), nil, nil, ctx, blockTimeout, callback); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,285 @@
+package cosmos
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strconv"
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+	chantypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"go.uber.org/zap"
+)
+
+const (
+	// KeyPoolStrategyByPath signs the transactions of each channel, or client, with the same key of the key pool, the default.
+	KeyPoolStrategyByPath = "by-path"
+
+	// KeyPoolStrategyRoundRobin signs the transactions with the keys of the key pool in turn,
+	// except for the packets of ordered channels which are signed with the same key.
+	KeyPoolStrategyRoundRobin = "round-robin"
+)
+
+// signingKey is a key used to sign transactions, along with its account sequence tracking.
+type signingKey struct {
+	name string
+
+	// Guard against account sequence number mismatch errors by locking for the specific wallet for
+	// the account sequence query all the way through the transaction broadcast success/fail.
+	mu sync.Mutex
+
+	nextAccountSeq uint64
+}
+
+func (k *signingKey) updateNextAccountSequence(seq uint64) {
+	if seq > k.nextAccountSeq {
+		k.nextAccountSeq = seq
+	}
+}
+
+// handleAccountSequenceMismatchError will parse the error string, e.g.:
+// "account sequence mismatch, expected 10, got 9: incorrect account sequence"
+// and update the next account sequence with the expected value.
+func (k *signingKey) handleAccountSequenceMismatchError(err error) {
+	sequences := numRegex.FindAllString(err.Error(), -1)
+	if len(sequences) != 2 {
+		return
+	}
+	nextSeq, err := strconv.ParseUint(sequences[0], 10, 64)
+	if err != nil {
+		return
+	}
+	k.nextAccountSeq = nextSeq
+}
+
+// SigningKeys returns the names of the keys used to sign transactions:
+// the configured key, followed by the keys of the key pool.
+func (pc CosmosProviderConfig) SigningKeys() []string {
+	keys := []string{pc.Key}
+	seen := map[string]bool{pc.Key: true}
+	for _, k := range pc.KeyPool {
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// SigningKeys returns the names of the keys used to sign transactions.
+func (cc *CosmosProvider) SigningKeys() []string {
+	return cc.PCfg.SigningKeys()
+}
+
+// acquireSigningKey locks and returns the key to sign a transaction containing the msgs with.
+// Transactions of a channel, or of a client if they do not relay packets, are pinned to the same key
+// so that they are included in the order they were sent, see keyAffinity. Other transactions use the keys
+// round-robin, skipping ahead to the first key which is not already in use so that transactions are built
+// and broadcast in parallel. If all keys are in use, it waits for the next key in turn. Messages which can
+// not be signed by another key, see withSigner, are always signed with the configured key.
+// The key must be unlocked once the transaction has been broadcast.
+func (cc *CosmosProvider) acquireSigningKey(ctx context.Context, msgs []provider.RelayerMessage) *signingKey {
+	names := cc.SigningKeys()
+
+	pooled := len(names) > 1 && canChangeSigner(msgs)
+	affinity := ""
+	if pooled {
+		affinity = cc.keyAffinity(ctx, msgs)
+	}
+
+	cc.signingKeysMu.Lock()
+	keys := make([]*signingKey, len(names))
+	for i, name := range names {
+		keys[i] = cc.signingKey(name)
+	}
+	start := 0
+	switch {
+	case pooled && affinity != "":
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(affinity))
+		start = int(h.Sum32() % uint32(len(keys)))
+	case pooled:
+		start = cc.nextSigningKey % len(keys)
+		cc.nextSigningKey = start + 1
+	}
+	cc.signingKeysMu.Unlock()
+
+	if pooled && affinity == "" {
+		for i := range keys {
+			k := keys[(start+i)%len(keys)]
+			if k.mu.TryLock() {
+				return k
+			}
+		}
+	}
+	k := keys[start]
+	k.mu.Lock()
+	return k
+}
+
+// keyAffinity returns the channel or client which the transaction containing the msgs is pinned to,
+// or an empty string if it can be signed with any key.
+// The key pool strategy by-path pins all transactions, by the channel of the first packet in the msgs
+// or else by the client which is updated. A packet of an ordered channel can only be received once
+// the previous packet has been, so the key pool strategy round-robin only pins the packets of ordered channels.
+func (cc *CosmosProvider) keyAffinity(ctx context.Context, msgs []provider.RelayerMessage) string {
+	if cc.PCfg.KeyPoolStrategy == KeyPoolStrategyRoundRobin {
+		for _, msg := range msgs {
+			if portID, channelID, ok := packetChannel(CosmosMsg(msg)); ok && cc.isOrderedChannel(ctx, portID, channelID) {
+				return portID + "/" + channelID
+			}
+		}
+		return ""
+	}
+
+	for _, msg := range msgs {
+		if portID, channelID, ok := packetChannel(CosmosMsg(msg)); ok {
+			return portID + "/" + channelID
+		}
+	}
+	for _, msg := range msgs {
+		if m, ok := CosmosMsg(msg).(*clienttypes.MsgUpdateClient); ok {
+			return m.ClientId
+		}
+	}
+	return ""
+}
+
+// packetChannel returns the port and channel on this chain of the packet relayed by the msg, if any.
+func packetChannel(msg sdk.Msg) (portID, channelID string, ok bool) {
+	switch m := msg.(type) {
+	case *chantypes.MsgRecvPacket:
+		return m.Packet.DestinationPort, m.Packet.DestinationChannel, true
+	case *chantypes.MsgAcknowledgement:
+		return m.Packet.SourcePort, m.Packet.SourceChannel, true
+	case *chantypes.MsgTimeout:
+		return m.Packet.SourcePort, m.Packet.SourceChannel, true
+	case *chantypes.MsgTimeoutOnClose:
+		return m.Packet.SourcePort, m.Packet.SourceChannel, true
+	}
+	return "", "", false
+}
+
+// isOrderedChannel returns true if the channel is ordered, querying its ordering on first use.
+// If the query fails, the channel is assumed to be ordered, so that its packets are not sent out of order.
+func (cc *CosmosProvider) isOrderedChannel(ctx context.Context, portID, channelID string) bool {
+	k := portID + "/" + channelID
+
+	cc.orderedChannelsMu.Lock()
+	ordered, ok := cc.orderedChannels[k]
+	cc.orderedChannelsMu.Unlock()
+	if ok {
+		return ordered
+	}
+
+	res, err := cc.QueryChannel(ctx, 0, channelID, portID)
+	if err != nil {
+		cc.log.Debug("Failed to query ordering of channel, signing its packets with the same key",
+			zap.String("port_id", portID),
+			zap.String("channel_id", channelID),
+			zap.Error(err),
+		)
+		return true
+	}
+	ordered = res.Channel.Ordering == chantypes.ORDERED
+
+	cc.orderedChannelsMu.Lock()
+	if cc.orderedChannels == nil {
+		cc.orderedChannels = make(map[string]bool)
+	}
+	cc.orderedChannels[k] = ordered
+	cc.orderedChannelsMu.Unlock()
+	return ordered
+}
+
+// lockSigningKey locks and returns the named key, which does not need to be one of the signing keys of the chain.
+// The key must be unlocked once the transaction has been broadcast.
+func (cc *CosmosProvider) lockSigningKey(name string) *signingKey {
+	cc.signingKeysMu.Lock()
+	k := cc.signingKey(name)
+	cc.signingKeysMu.Unlock()
+
+	k.mu.Lock()
+	return k
+}
+
+// signingKey returns the named key, creating it on first use. signingKeysMu must be held.
+func (cc *CosmosProvider) signingKey(name string) *signingKey {
+	if cc.signingKeys == nil {
+		cc.signingKeys = make(map[string]*signingKey)
+	}
+	k, ok := cc.signingKeys[name]
+	if !ok {
+		k = &signingKey{name: name}
+		cc.signingKeys[name] = k
+	}
+	return k
+}
+
+// canChangeSigner returns true if all of the msgs have a Signer which can be changed with withSigner.
+func canChangeSigner(msgs []provider.RelayerMessage) bool {
+	for _, msg := range msgs {
+		if _, ok := withSigner(msg, ""); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// withSigner returns a copy of the msg with its Signer set to the signer address.
+// The IBC core messages relayed are assembled with the address of the configured key as the Signer,
+// which must be changed to sign them with another key of the pool. ok is false for messages without
+// a Signer, e.g. MsgTransfer or MsgRegisterPayee, which must be signed with the configured key.
+func withSigner(msg provider.RelayerMessage, signer string) (provider.RelayerMessage, bool) {
+	cm, ok := msg.(CosmosMessage)
+	if !ok || cm.Msg == nil {
+		return nil, false
+	}
+	v := reflect.ValueOf(cm.Msg)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, false
+	}
+	if f := v.Elem().FieldByName("Signer"); !f.IsValid() || f.Kind() != reflect.String {
+		return nil, false
+	}
+
+	cp := reflect.New(v.Elem().Type())
+	cp.Elem().Set(v.Elem())
+	cp.Elem().FieldByName("Signer").SetString(signer)
+
+	sdkMsg, ok := cp.Interface().(sdk.Msg)
+	if !ok {
+		return nil, false
+	}
+	return CosmosMessage{Msg: sdkMsg}, true
+}
+
+// signMsgsWith returns the msgs to sign with the key, with the Signer of each
+// changed to the address of the key if it is not the configured key.
+func (cc *CosmosProvider) signMsgsWith(key *signingKey, msgs []provider.RelayerMessage) ([]provider.RelayerMessage, error) {
+	if key.name == cc.PCfg.Key {
+		return msgs, nil
+	}
+	acc, err := cc.keyAddress(key.name)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := cc.EncodeBech32AccAddr(acc)
+	if err != nil {
+		return nil, err
+	}
+	signed := make([]provider.RelayerMessage, len(msgs))
+	for i, msg := range msgs {
+		m, ok := withSigner(msg, signer)
+		if !ok {
+			return nil, fmt.Errorf("message of type %s can not be signed with key %s", msg.Type(), key.name)
+		}
+		signed[i] = m
+	}
+	return signed, nil
+}
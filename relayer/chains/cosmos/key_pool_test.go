@@ -0,0 +1,117 @@
+package cosmos
+
+import (
+	"context"
+	"testing"
+
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+	chantypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningKeys(t *testing.T) {
+	pc := CosmosProviderConfig{Key: "default"}
+	require.Equal(t, []string{"default"}, pc.SigningKeys())
+
+	pc.KeyPool = []string{"relayer-1", "default", "relayer-2", "relayer-1"}
+	require.Equal(t, []string{"default", "relayer-1", "relayer-2"}, pc.SigningKeys())
+}
+
+func TestAcquireSigningKey(t *testing.T) {
+	cc := &CosmosProvider{PCfg: CosmosProviderConfig{Key: "default", KeyPool: []string{"relayer-1", "relayer-2"}}}
+
+	k1 := cc.acquireSigningKey(context.Background(), nil)
+	k2 := cc.acquireSigningKey(context.Background(), nil)
+	k3 := cc.acquireSigningKey(context.Background(), nil)
+	require.Equal(t, []string{"default", "relayer-1", "relayer-2"}, []string{k1.name, k2.name, k3.name})
+
+	k2.mu.Unlock()
+
+	// default is next in turn but still in use, so the free relayer-1 is used instead.
+	k := cc.acquireSigningKey(context.Background(), nil)
+	require.Equal(t, "relayer-1", k.name)
+	k.mu.Unlock()
+
+	k1.mu.Unlock()
+	k3.mu.Unlock()
+
+	// relayer-1 is next in turn.
+	k = cc.acquireSigningKey(context.Background(), nil)
+	require.Equal(t, "relayer-1", k.name)
+}
+
+func TestAcquireSigningKeyWithoutSigner(t *testing.T) {
+	cc := &CosmosProvider{PCfg: CosmosProviderConfig{Key: "default", KeyPool: []string{"relayer-1"}}}
+	transfer := []provider.RelayerMessage{NewCosmosMessage(&transfertypes.MsgTransfer{Sender: "cosmos1sender"})}
+
+	k := cc.acquireSigningKey(context.Background(), transfer)
+	require.Equal(t, "default", k.name)
+	k.mu.Unlock()
+
+	k = cc.acquireSigningKey(context.Background(), transfer)
+	require.Equal(t, "default", k.name, "messages without a signer should always be signed with the configured key")
+	k.mu.Unlock()
+}
+
+func TestAcquireSigningKeyPinned(t *testing.T) {
+	cc := &CosmosProvider{PCfg: CosmosProviderConfig{Key: "default", KeyPool: []string{"relayer-1", "relayer-2"}}}
+	recv := func(channelID string) []provider.RelayerMessage {
+		return []provider.RelayerMessage{
+			NewCosmosMessage(&clienttypes.MsgUpdateClient{ClientId: "07-tendermint-0", Signer: "cosmos1default"}),
+			NewCosmosMessage(&chantypes.MsgRecvPacket{
+				Packet: chantypes.Packet{DestinationPort: "transfer", DestinationChannel: channelID},
+				Signer: "cosmos1default",
+			}),
+		}
+	}
+
+	k := cc.acquireSigningKey(context.Background(), recv("channel-0"))
+	pinned := k.name
+	k.mu.Unlock()
+
+	for i := 0; i < 5; i++ {
+		k = cc.acquireSigningKey(context.Background(), recv("channel-0"))
+		require.Equal(t, pinned, k.name, "packets of a channel should be signed with the same key")
+		k.mu.Unlock()
+	}
+
+	update := []provider.RelayerMessage{NewCosmosMessage(&clienttypes.MsgUpdateClient{ClientId: "07-tendermint-0", Signer: "cosmos1default"})}
+	require.Equal(t, "07-tendermint-0", cc.keyAffinity(context.Background(), update))
+	require.Equal(t, "transfer/channel-0", cc.keyAffinity(context.Background(), recv("channel-0")))
+}
+
+func TestKeyAffinityRoundRobin(t *testing.T) {
+	cc := &CosmosProvider{PCfg: CosmosProviderConfig{
+		Key:             "default",
+		KeyPool:         []string{"relayer-1"},
+		KeyPoolStrategy: KeyPoolStrategyRoundRobin,
+	}}
+	cc.orderedChannels = map[string]bool{"transfer/channel-0": false, "icahost/channel-1": true}
+
+	ack := func(portID, channelID string) []provider.RelayerMessage {
+		return []provider.RelayerMessage{NewCosmosMessage(&chantypes.MsgAcknowledgement{
+			Packet: chantypes.Packet{SourcePort: portID, SourceChannel: channelID},
+			Signer: "cosmos1default",
+		})}
+	}
+
+	require.Empty(t, cc.keyAffinity(context.Background(), ack("transfer", "channel-0")))
+	require.Equal(t, "icahost/channel-1", cc.keyAffinity(context.Background(), ack("icahost", "channel-1")))
+
+	update := []provider.RelayerMessage{NewCosmosMessage(&clienttypes.MsgUpdateClient{ClientId: "07-tendermint-0", Signer: "cosmos1default"})}
+	require.Empty(t, cc.keyAffinity(context.Background(), update))
+}
+
+func TestWithSigner(t *testing.T) {
+	recv := &chantypes.MsgRecvPacket{Signer: "cosmos1default"}
+
+	msg, ok := withSigner(NewCosmosMessage(recv), "cosmos1pool")
+	require.True(t, ok)
+	require.Equal(t, "cosmos1pool", CosmosMsg(msg).(*chantypes.MsgRecvPacket).Signer)
+	require.Equal(t, "cosmos1default", recv.Signer, "original message should not be modified")
+
+	_, ok = withSigner(NewCosmosMessage(&transfertypes.MsgTransfer{Sender: "cosmos1sender"}), "cosmos1pool")
+	require.False(t, ok)
+}
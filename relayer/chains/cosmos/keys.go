@@ -183,7 +183,12 @@ func (cc *CosmosProvider) ExportPrivKeyArmor(keyName string) (armor string, err
 
 // GetKeyAddress returns the account address representation for the currently configured key.
 func (cc *CosmosProvider) GetKeyAddress() (sdk.AccAddress, error) {
-	info, err := cc.Keybase.Key(cc.PCfg.Key)
+	return cc.keyAddress(cc.PCfg.Key)
+}
+
+// keyAddress returns the account address representation for the named key.
+func (cc *CosmosProvider) keyAddress(keyName string) (sdk.AccAddress, error) {
+	info, err := cc.Keybase.Key(keyName)
 	if err != nil {
 		return nil, err
 	}
@@ -209,3 +214,8 @@ func CreateMnemonic() (string, error) {
 func (cc *CosmosProvider) EncodeBech32AccAddr(addr sdk.AccAddress) (string, error) {
 	return sdk.Bech32ifyAddressBytes(cc.PCfg.AccountPrefix, addr)
 }
+
+// DecodeBech32AccAddr decodes a bech32 account address with the chain's account prefix.
+func (cc *CosmosProvider) DecodeBech32AccAddr(addr string) (sdk.AccAddress, error) {
+	return sdk.GetFromBech32(addr, cc.PCfg.AccountPrefix)
+}
@@ -0,0 +1,185 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	dbm "github.com/cometbft/cometbft-db"
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+	cmtmath "github.com/cometbft/cometbft/libs/math"
+	"github.com/cometbft/cometbft/light"
+	provtypes "github.com/cometbft/cometbft/light/provider"
+	prov "github.com/cometbft/cometbft/light/provider/http"
+	lightdb "github.com/cometbft/cometbft/light/store/db"
+	comettypes "github.com/cometbft/cometbft/types"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultLightClientTrustLevel = "1/3"
+
+	// Number of verified light blocks kept in memory by the light client.
+	lightClientPruningSize = 1000
+)
+
+// LightClientVerification configures verifying the headers queried from the RPC node with the CometBFT light client
+// before they are used to update IBC clients. Headers are verified with skipping verification from a trusted header,
+// and cross-checked against the witnesses, so that a faulty or malicious RPC node can not make the relayer submit
+// invalid headers.
+type LightClientVerification struct {
+	// RPC addresses of nodes of the chain which the headers are cross-checked against. At least one is required.
+	Witnesses []string `json:"witnesses" yaml:"witnesses"`
+
+	// Height and hex-encoded hash of the trusted header which headers are verified from, e.g. from a block explorer.
+	// If not set, the latest header of the RPC node is trusted when the relayer starts.
+	TrustHeight int64  `json:"trust-height,omitempty" yaml:"trust-height,omitempty"`
+	TrustHash   string `json:"trust-hash,omitempty" yaml:"trust-hash,omitempty"`
+
+	// Period within which a trusted header can be used to verify new headers, e.g. "336h".
+	// Defaults to the trusting period of IBC clients of the chain.
+	TrustingPeriod string `json:"trusting-period,omitempty" yaml:"trusting-period,omitempty"`
+
+	// Fraction of the trusted validator set which must have signed a header to skip to it, e.g. "1/3". Defaults to 1/3.
+	TrustLevel string `json:"trust-level,omitempty" yaml:"trust-level,omitempty"`
+}
+
+// Validate checks the witnesses, trusted header, trusting period and trust level.
+func (v LightClientVerification) Validate() error {
+	if len(v.Witnesses) == 0 {
+		return fmt.Errorf("light client verification requires at least one witness")
+	}
+	if (v.TrustHeight == 0) != (v.TrustHash == "") {
+		return fmt.Errorf("trust-height and trust-hash must be set together")
+	}
+	if v.TrustHash != "" {
+		if _, err := v.trustHash(); err != nil {
+			return err
+		}
+	}
+	if v.TrustingPeriod != "" {
+		if _, err := time.ParseDuration(v.TrustingPeriod); err != nil {
+			return fmt.Errorf("invalid light client trusting-period: %w", err)
+		}
+	}
+	if _, err := v.trustLevel(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (v LightClientVerification) trustHash() ([]byte, error) {
+	hash, err := hex.DecodeString(v.TrustHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid light client trust-hash: %w", err)
+	}
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("invalid light client trust-hash: expected 32 bytes, got %d", len(hash))
+	}
+	return hash, nil
+}
+
+func (v LightClientVerification) trustLevel() (cmtmath.Fraction, error) {
+	s := v.TrustLevel
+	if s == "" {
+		s = defaultLightClientTrustLevel
+	}
+	level, err := cmtmath.ParseFraction(s)
+	if err != nil {
+		return cmtmath.Fraction{}, fmt.Errorf("invalid light client trust-level: %w", err)
+	}
+	if err := light.ValidateTrustLevel(level); err != nil {
+		return cmtmath.Fraction{}, fmt.Errorf("invalid light client trust-level: %w", err)
+	}
+	return level, nil
+}
+
+// verifiedLightBlock returns the light block at the height, verified by the light client.
+// The light client is created on first use, and again after a failure to create it.
+func (cc *CosmosProvider) verifiedLightBlock(ctx context.Context, h int64) (*comettypes.LightBlock, error) {
+	cc.lightClientMu.Lock()
+	defer cc.lightClientMu.Unlock()
+
+	if cc.lightClient == nil {
+		witnesses, err := cc.lightClientWitnesses()
+		if err != nil {
+			return nil, err
+		}
+		c, err := cc.newLightClient(ctx, witnesses)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create light client: %w", err)
+		}
+		cc.lightClient = c
+	}
+
+	lightBlock, err := cc.lightClient.VerifyLightBlockAtHeight(ctx, h, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify header at height %d with the light client: %w", h, err)
+	}
+	return lightBlock, nil
+}
+
+func (cc *CosmosProvider) lightClientWitnesses() ([]provtypes.Provider, error) {
+	var witnesses []provtypes.Provider
+	for _, addr := range cc.PCfg.LightClientVerification.Witnesses {
+		w, err := prov.New(cc.PCfg.ChainID, addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid light client witness %s: %w", endpointLabel(addr), err)
+		}
+		witnesses = append(witnesses, w)
+	}
+	return witnesses, nil
+}
+
+// newLightClient creates a light client which verifies the light blocks of LightProvider from the trusted header.
+func (cc *CosmosProvider) newLightClient(ctx context.Context, witnesses []provtypes.Provider) (*light.Client, error) {
+	cfg := cc.PCfg.LightClientVerification
+
+	var (
+		period time.Duration
+		err    error
+	)
+	if cfg.TrustingPeriod != "" {
+		period, err = time.ParseDuration(cfg.TrustingPeriod)
+	} else {
+		period, err = cc.TrustingPeriod(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	level, err := cfg.trustLevel()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := light.TrustOptions{Period: period, Height: cfg.TrustHeight}
+	if cfg.TrustHash != "" {
+		if opts.Hash, err = cfg.trustHash(); err != nil {
+			return nil, err
+		}
+	} else {
+		latest, err := cc.LightProvider.LightBlock(ctx, 0)
+		if err != nil {
+			return nil, err
+		}
+		opts.Height, opts.Hash = latest.Height, latest.Hash()
+		cc.log.Warn("No trusted header configured for light client verification, trusting the latest header of the RPC node",
+			zap.Int64("height", opts.Height),
+			zap.String("hash", latest.Hash().String()),
+		)
+	}
+
+	return light.NewClient(
+		ctx,
+		cc.PCfg.ChainID,
+		opts,
+		cc.LightProvider,
+		witnesses,
+		lightdb.New(dbm.NewMemDB(), cc.PCfg.ChainID),
+		light.SkippingVerification(level),
+		light.PruningSize(lightClientPruningSize),
+		light.Logger(cmtlog.NewNopLogger()),
+	)
+}
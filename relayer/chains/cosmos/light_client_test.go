@@ -0,0 +1,144 @@
+package cosmos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cometbft/cometbft/crypto/tmhash"
+	provtypes "github.com/cometbft/cometbft/light/provider"
+	"github.com/cometbft/cometbft/light/provider/mock"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	cmtversion "github.com/cometbft/cometbft/proto/tendermint/version"
+	comettypes "github.com/cometbft/cometbft/types"
+	"github.com/cometbft/cometbft/version"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const lightClientTestChainID = "test-1"
+
+func TestLightClientVerificationValidate(t *testing.T) {
+	hash := "0123456789012345678901234567890123456789012345678901234567890123"
+	valid := LightClientVerification{Witnesses: []string{"http://localhost:26657"}}
+	require.NoError(t, valid.Validate())
+
+	for _, tc := range []struct {
+		name string
+		v    LightClientVerification
+		ok   bool
+	}{
+		{name: "trusted header", v: LightClientVerification{Witnesses: valid.Witnesses, TrustHeight: 5, TrustHash: hash}, ok: true},
+		{name: "no witnesses", v: LightClientVerification{}},
+		{name: "height without hash", v: LightClientVerification{Witnesses: valid.Witnesses, TrustHeight: 5}},
+		{name: "short hash", v: LightClientVerification{Witnesses: valid.Witnesses, TrustHeight: 5, TrustHash: "0123"}},
+		{name: "trusting period", v: LightClientVerification{Witnesses: valid.Witnesses, TrustingPeriod: "2w"}},
+		{name: "trust level", v: LightClientVerification{Witnesses: valid.Witnesses, TrustLevel: "1/4"}},
+	} {
+		err := tc.v.Validate()
+		if tc.ok {
+			require.NoError(t, err, tc.name)
+		} else {
+			require.Error(t, err, tc.name)
+		}
+	}
+}
+
+// testLightChain returns the headers of a chain with a single validator from height 1 to n, one per minute from start,
+// with the app hash of each header from forkHeight onwards changed to fork the chain.
+func testLightChain(
+	t *testing.T,
+	pv comettypes.PrivValidator,
+	start time.Time,
+	n, forkHeight int64,
+) (map[int64]*comettypes.SignedHeader, map[int64]*comettypes.ValidatorSet) {
+	pubKey, err := pv.GetPubKey()
+	require.NoError(t, err)
+	val := comettypes.NewValidator(pubKey, 10)
+	vals := comettypes.NewValidatorSet([]*comettypes.Validator{val})
+
+	headers := make(map[int64]*comettypes.SignedHeader)
+	valSets := make(map[int64]*comettypes.ValidatorSet)
+	var lastBlockID comettypes.BlockID
+	for h := int64(1); h <= n; h++ {
+		header := &comettypes.Header{
+			Version:            cmtversion.Consensus{Block: version.BlockProtocol},
+			ChainID:            lightClientTestChainID,
+			Height:             h,
+			Time:               start.Add(time.Duration(h) * time.Minute),
+			LastBlockID:        lastBlockID,
+			ValidatorsHash:     vals.Hash(),
+			NextValidatorsHash: vals.Hash(),
+			ProposerAddress:    val.Address,
+		}
+		if forkHeight > 0 && h >= forkHeight {
+			header.AppHash = tmhash.Sum([]byte("fork"))
+		}
+		blockID := comettypes.BlockID{
+			Hash:          header.Hash(),
+			PartSetHeader: comettypes.PartSetHeader{Total: 1, Hash: tmhash.Sum([]byte("parts"))},
+		}
+		voteSet := comettypes.NewVoteSet(lightClientTestChainID, h, 0, cmtproto.PrecommitType, vals)
+		commit, err := comettypes.MakeCommit(blockID, h, 0, voteSet, []comettypes.PrivValidator{pv}, header.Time.Add(time.Second))
+		require.NoError(t, err)
+
+		headers[h] = &comettypes.SignedHeader{Header: header, Commit: commit}
+		valSets[h] = vals
+		lastBlockID = blockID
+	}
+	valSets[n+1] = vals
+	return headers, valSets
+}
+
+func testLightClientProvider(primary provtypes.Provider, trusted *comettypes.SignedHeader) *CosmosProvider {
+	return &CosmosProvider{
+		log: zap.NewNop(),
+		PCfg: CosmosProviderConfig{
+			ChainID: lightClientTestChainID,
+			LightClientVerification: &LightClientVerification{
+				TrustHeight:    trusted.Height,
+				TrustHash:      trusted.Hash().String(),
+				TrustingPeriod: "24h",
+			},
+		},
+		LightProvider: primary,
+	}
+}
+
+func TestQueryIBCHeaderVerified(t *testing.T) {
+	ctx := context.Background()
+	pv := comettypes.NewMockPV()
+	start := time.Now().Add(-time.Hour)
+	headers, vals := testLightChain(t, pv, start, 5, 0)
+	primary := mock.New(lightClientTestChainID, headers, vals)
+
+	cc := testLightClientProvider(primary, headers[1])
+	c, err := cc.newLightClient(ctx, []provtypes.Provider{mock.New(lightClientTestChainID, headers, vals)})
+	require.NoError(t, err)
+	cc.lightClient = c
+
+	h, err := cc.QueryIBCHeader(ctx, 4)
+	require.NoError(t, err)
+	require.Equal(t, headers[4].Hash(), h.(provider.TendermintIBCHeader).SignedHeader.Hash())
+}
+
+func TestQueryIBCHeaderWitnessConflict(t *testing.T) {
+	ctx := context.Background()
+	pv := comettypes.NewMockPV()
+	start := time.Now().Add(-time.Hour)
+	headers, vals := testLightChain(t, pv, start, 5, 0)
+	primary := mock.New(lightClientTestChainID, headers, vals)
+
+	// the validator also signed a fork of the chain from height 3, which the witness follows.
+	forkHeaders, forkVals := testLightChain(t, pv, start, 5, 3)
+	witness := mock.New(lightClientTestChainID, forkHeaders, forkVals)
+
+	cc := testLightClientProvider(primary, headers[1])
+	c, err := cc.newLightClient(ctx, []provtypes.Provider{witness})
+	require.NoError(t, err)
+	cc.lightClient = c
+
+	_, err = cc.QueryIBCHeader(ctx, 4)
+	require.ErrorContains(t, err, "failed to verify header at height 4")
+}
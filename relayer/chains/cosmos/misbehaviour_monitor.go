@@ -0,0 +1,235 @@
+package cosmos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cometbft/cometbft/light"
+	provtypes "github.com/cometbft/cometbft/light/provider"
+	prov "github.com/cometbft/cometbft/light/provider/http"
+	comettypes "github.com/cometbft/cometbft/types"
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+	ibcexported "github.com/cosmos/ibc-go/v7/modules/core/exported"
+	tmclient "github.com/cosmos/ibc-go/v7/modules/light-clients/07-tendermint"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"go.uber.org/zap"
+)
+
+const (
+	// Directory under the relayer home where misbehaviour evidence is saved, in a directory for each chain.
+	evidenceDir = "evidence"
+
+	// How far the time of a header may be ahead of the local clock, the default of IBC tendermint clients.
+	misbehaviourMaxClockDrift = 10 * time.Second
+)
+
+// misbehaviourWitness is an RPC node, independent of the RPC endpoints of the chain,
+// which the headers of the chain are compared with to detect misbehaviour.
+type misbehaviourWitness struct {
+	addr     string
+	provider provtypes.Provider
+}
+
+// misbehaviourEvidence is the evidence of misbehaviour which is saved for forensics.
+type misbehaviourEvidence struct {
+	ChainID       string          `json:"chain_id"`
+	ClientID      string          `json:"client_id"`
+	Height        int64           `json:"height"`
+	TrustedHeight uint64          `json:"trusted_height"`
+	Witness       string          `json:"witness"`
+	DetectedAt    time.Time       `json:"detected_at"`
+	Misbehaviour  json.RawMessage `json:"misbehaviour"`
+}
+
+func newMisbehaviourWitnesses(chainID string, addrs []string) ([]misbehaviourWitness, error) {
+	var witnesses []misbehaviourWitness
+	for _, addr := range addrs {
+		p, err := prov.New(chainID, addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid misbehaviour witness %s: %w", endpointLabel(addr), err)
+		}
+		witnesses = append(witnesses, misbehaviourWitness{addr: addr, provider: p})
+	}
+	return witnesses, nil
+}
+
+// QueryWitnessMisbehaviour compares the header at height h from the RPC node with the headers from the misbehaviour
+// witnesses. If a witness has a conflicting header, and both headers can be verified from the header at trustedHeight,
+// which is trusted by the client, the chain has forked or been subject to a lunatic attack. The misbehaviour evidence
+// for the client is then saved to disk and returned. Nil is returned if no misbehaviour witnesses are configured.
+func (cc *CosmosProvider) QueryWitnessMisbehaviour(
+	ctx context.Context,
+	clientID string,
+	trustedHeight clienttypes.Height,
+	h int64,
+	trustingPeriod time.Duration,
+) (ibcexported.ClientMessage, error) {
+	if len(cc.misbehaviourWitnesses) == 0 {
+		return nil, nil
+	}
+	if int64(trustedHeight.RevisionHeight) >= h {
+		return nil, fmt.Errorf("trusted height %d must be below height %d", trustedHeight.RevisionHeight, h)
+	}
+
+	primary, err := cc.LightProvider.LightBlock(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+
+	witnessBlocks := make([]*comettypes.LightBlock, len(cc.misbehaviourWitnesses))
+	var wg sync.WaitGroup
+	for i, w := range cc.misbehaviourWitnesses {
+		wg.Add(1)
+		go func(i int, w misbehaviourWitness) {
+			defer wg.Done()
+			lightBlock, err := w.provider.LightBlock(ctx, h)
+			if err != nil {
+				cc.log.Debug("Failed to query header from misbehaviour witness",
+					zap.String("witness", endpointLabel(w.addr)),
+					zap.Int64("height", h),
+					zap.Error(err),
+				)
+				return
+			}
+			witnessBlocks[i] = lightBlock
+		}(i, w)
+	}
+	wg.Wait()
+
+	for i, conflicting := range witnessBlocks {
+		if conflicting == nil || bytes.Equal(conflicting.Hash(), primary.Hash()) {
+			continue
+		}
+		witness := endpointLabel(cc.misbehaviourWitnesses[i].addr)
+
+		misbehaviour, err := cc.witnessMisbehaviour(ctx, clientID, trustedHeight, primary, conflicting, trustingPeriod)
+		if err != nil {
+			cc.log.Warn("Header of misbehaviour witness conflicts with the header of the RPC node, but is not evidence of misbehaviour",
+				zap.String("witness", witness),
+				zap.Int64("height", h),
+				zap.String("hash", primary.Hash().String()),
+				zap.String("witness_hash", conflicting.Hash().String()),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		cc.log.Error("Misbehaviour detected, header of misbehaviour witness conflicts with the header of the RPC node",
+			zap.String("client_id", clientID),
+			zap.String("witness", witness),
+			zap.Int64("height", h),
+			zap.String("hash", primary.Hash().String()),
+			zap.String("witness_hash", conflicting.Hash().String()),
+		)
+
+		if err := cc.saveMisbehaviourEvidence(clientID, trustedHeight, h, witness, misbehaviour); err != nil {
+			cc.log.Error("Failed to save misbehaviour evidence", zap.Error(err))
+		}
+
+		return misbehaviour, nil
+	}
+
+	return nil, nil
+}
+
+// witnessMisbehaviour returns the misbehaviour for the conflicting light blocks of the RPC node and a witness,
+// after verifying both from the trusted height in the same way as the client would. If either can not be verified,
+// the node which returned it is faulty, rather than the chain, and the client would reject the misbehaviour.
+func (cc *CosmosProvider) witnessMisbehaviour(
+	ctx context.Context,
+	clientID string,
+	trustedHeight clienttypes.Height,
+	primary, conflicting *comettypes.LightBlock,
+	trustingPeriod time.Duration,
+) (*tmclient.Misbehaviour, error) {
+	trusted, err := cc.LightProvider.LightBlock(ctx, int64(trustedHeight.RevisionHeight))
+	if err != nil {
+		return nil, err
+	}
+	// the validators at the trusted height + 1, which the client trusts through the next validators hash of the trusted header.
+	trustedNext, err := cc.LightProvider.LightBlock(ctx, int64(trustedHeight.RevisionHeight)+1)
+	if err != nil {
+		return nil, err
+	}
+
+	if trustingPeriod == 0 {
+		if trustingPeriod, err = cc.TrustingPeriod(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	for _, lightBlock := range []*comettypes.LightBlock{primary, conflicting} {
+		if err := light.Verify(
+			trusted.SignedHeader, trustedNext.ValidatorSet,
+			lightBlock.SignedHeader, lightBlock.ValidatorSet,
+			trustingPeriod, now, misbehaviourMaxClockDrift, light.DefaultTrustLevel,
+		); err != nil {
+			return nil, fmt.Errorf("failed to verify header with hash %s: %w", lightBlock.Hash(), err)
+		}
+	}
+
+	trustedHeader := provider.TendermintIBCHeader{ValidatorSet: trustedNext.ValidatorSet}
+	var headers [2]*tmclient.Header
+	for i, lightBlock := range []*comettypes.LightBlock{conflicting, primary} {
+		header, err := cc.MsgUpdateClientHeader(provider.TendermintIBCHeader{
+			SignedHeader: lightBlock.SignedHeader,
+			ValidatorSet: lightBlock.ValidatorSet,
+		}, trustedHeight, trustedHeader)
+		if err != nil {
+			return nil, err
+		}
+		headers[i] = header.(*tmclient.Header)
+	}
+
+	misbehaviour := tmclient.NewMisbehaviour(clientID, headers[0], headers[1])
+	if err := misbehaviour.ValidateBasic(); err != nil {
+		return nil, err
+	}
+	return misbehaviour, nil
+}
+
+// saveMisbehaviourEvidence writes the misbehaviour to a file in the evidence directory of the chain.
+func (cc *CosmosProvider) saveMisbehaviourEvidence(
+	clientID string,
+	trustedHeight clienttypes.Height,
+	h int64,
+	witness string,
+	misbehaviour *tmclient.Misbehaviour,
+) error {
+	bz, err := cc.Cdc.Marshaler.MarshalJSON(misbehaviour)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	evidence, err := json.MarshalIndent(misbehaviourEvidence{
+		ChainID:       cc.PCfg.ChainID,
+		ClientID:      clientID,
+		Height:        h,
+		TrustedHeight: trustedHeight.RevisionHeight,
+		Witness:       witness,
+		DetectedAt:    now,
+		Misbehaviour:  bz,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(cc.evidenceDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create evidence directory: %w", err)
+	}
+	file := filepath.Join(cc.evidenceDir, fmt.Sprintf("%s-%d-%d.json", clientID, h, now.Unix()))
+	if err := os.WriteFile(file, evidence, 0o600); err != nil {
+		return err
+	}
+
+	cc.log.Info("Saved misbehaviour evidence", zap.String("file", file))
+	return nil
+}
@@ -0,0 +1,96 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cometbft/cometbft/light/provider/mock"
+	comettypes "github.com/cometbft/cometbft/types"
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+	tmclient "github.com/cosmos/ibc-go/v7/modules/light-clients/07-tendermint"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func testMisbehaviourProvider(t *testing.T, primary, witness *mock.Mock) *CosmosProvider {
+	cc := &CosmosProvider{
+		log:           zap.NewNop(),
+		PCfg:          CosmosProviderConfig{ChainID: lightClientTestChainID},
+		LightProvider: primary,
+		Cdc:           MakeCodec(ModuleBasics, nil),
+		evidenceDir:   t.TempDir(),
+	}
+	if witness != nil {
+		cc.misbehaviourWitnesses = []misbehaviourWitness{{addr: "http://witness:26657", provider: witness}}
+	}
+	return cc
+}
+
+func TestQueryWitnessMisbehaviour(t *testing.T) {
+	ctx := context.Background()
+	pv := comettypes.NewMockPV()
+	start := time.Now().Add(-time.Hour)
+	headers, vals := testLightChain(t, pv, start, 5, 0)
+	primary := mock.New(lightClientTestChainID, headers, vals)
+
+	// the validator also signed a fork of the chain from height 3, which the witness follows.
+	forkHeaders, forkVals := testLightChain(t, pv, start, 5, 3)
+	witness := mock.New(lightClientTestChainID, forkHeaders, forkVals)
+
+	cc := testMisbehaviourProvider(t, primary, witness)
+	msg, err := cc.QueryWitnessMisbehaviour(ctx, "07-tendermint-0", clienttypes.NewHeight(1, 2), 4, 24*time.Hour)
+	require.NoError(t, err)
+
+	misbehaviour, ok := msg.(*tmclient.Misbehaviour)
+	require.True(t, ok)
+	require.Equal(t, "07-tendermint-0", misbehaviour.ClientId)
+	require.Equal(t, forkHeaders[4].Hash().Bytes(), []byte(misbehaviour.Header1.Commit.BlockID.Hash))
+	require.Equal(t, headers[4].Hash().Bytes(), []byte(misbehaviour.Header2.Commit.BlockID.Hash))
+	require.Equal(t, clienttypes.NewHeight(1, 2), misbehaviour.Header1.TrustedHeight)
+
+	files, err := os.ReadDir(cc.evidenceDir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	bz, err := os.ReadFile(filepath.Join(cc.evidenceDir, files[0].Name()))
+	require.NoError(t, err)
+	var evidence misbehaviourEvidence
+	require.NoError(t, json.Unmarshal(bz, &evidence))
+	require.Equal(t, int64(4), evidence.Height)
+	require.Equal(t, "http://witness:26657", evidence.Witness)
+	require.Contains(t, string(evidence.Misbehaviour), `"client_id": "07-tendermint-0"`)
+}
+
+func TestQueryWitnessMisbehaviourNoConflict(t *testing.T) {
+	ctx := context.Background()
+	pv := comettypes.NewMockPV()
+	start := time.Now().Add(-time.Hour)
+	headers, vals := testLightChain(t, pv, start, 5, 0)
+	primary := mock.New(lightClientTestChainID, headers, vals)
+
+	// no witnesses configured
+	cc := testMisbehaviourProvider(t, primary, nil)
+	msg, err := cc.QueryWitnessMisbehaviour(ctx, "07-tendermint-0", clienttypes.NewHeight(1, 2), 4, 24*time.Hour)
+	require.NoError(t, err)
+	require.Nil(t, msg)
+
+	// witness with the same headers
+	cc = testMisbehaviourProvider(t, primary, mock.New(lightClientTestChainID, headers, vals))
+	msg, err = cc.QueryWitnessMisbehaviour(ctx, "07-tendermint-0", clienttypes.NewHeight(1, 2), 4, 24*time.Hour)
+	require.NoError(t, err)
+	require.Nil(t, msg)
+
+	// faulty witness with headers which are not signed by the trusted validators
+	otherHeaders, otherVals := testLightChain(t, comettypes.NewMockPV(), start, 5, 0)
+	cc = testMisbehaviourProvider(t, primary, mock.New(lightClientTestChainID, otherHeaders, otherVals))
+	msg, err = cc.QueryWitnessMisbehaviour(ctx, "07-tendermint-0", clienttypes.NewHeight(1, 2), 4, 24*time.Hour)
+	require.NoError(t, err)
+	require.Nil(t, msg)
+
+	files, err := os.ReadDir(cc.evidenceDir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cometbft/cometbft/light"
 	provtypes "github.com/cometbft/cometbft/light/provider"
 	prov "github.com/cometbft/cometbft/light/provider/http"
 	rpcclient "github.com/cometbft/cometbft/rpc/client"
@@ -24,6 +25,7 @@ import (
 	"github.com/cosmos/relayer/v2/relayer/provider"
 	"go.uber.org/zap"
 	"golang.org/x/mod/semver"
+	"google.golang.org/grpc"
 )
 
 var (
@@ -35,31 +37,91 @@ var (
 const cometEncodingThreshold = "v0.37.0-alpha"
 
 type CosmosProviderConfig struct {
-	KeyDirectory   string                  `json:"key-directory" yaml:"key-directory"`
-	Key            string                  `json:"key" yaml:"key"`
-	ChainName      string                  `json:"-" yaml:"-"`
-	ChainID        string                  `json:"chain-id" yaml:"chain-id"`
-	RPCAddr        string                  `json:"rpc-addr" yaml:"rpc-addr"`
-	AccountPrefix  string                  `json:"account-prefix" yaml:"account-prefix"`
-	KeyringBackend string                  `json:"keyring-backend" yaml:"keyring-backend"`
-	GasAdjustment  float64                 `json:"gas-adjustment" yaml:"gas-adjustment"`
-	GasPrices      string                  `json:"gas-prices" yaml:"gas-prices"`
-	MinGasAmount   uint64                  `json:"min-gas-amount" yaml:"min-gas-amount"`
-	Debug          bool                    `json:"debug" yaml:"debug"`
-	Timeout        string                  `json:"timeout" yaml:"timeout"`
-	BlockTimeout   string                  `json:"block-timeout" yaml:"block-timeout"`
-	OutputFormat   string                  `json:"output-format" yaml:"output-format"`
-	SignModeStr    string                  `json:"sign-mode" yaml:"sign-mode"`
-	ExtraCodecs    []string                `json:"extra-codecs" yaml:"extra-codecs"`
-	Modules        []module.AppModuleBasic `json:"-" yaml:"-"`
-	Slip44         int                     `json:"coin-type" yaml:"coin-type"`
-	Broadcast      provider.BroadcastMode  `json:"broadcast-mode" yaml:"broadcast-mode"`
+	KeyDirectory            string                   `json:"key-directory" yaml:"key-directory"`
+	Key                     string                   `json:"key" yaml:"key"`
+	KeyPool                 []string                 `json:"key-pool,omitempty" yaml:"key-pool,omitempty"`
+	KeyPoolStrategy         string                   `json:"key-pool-strategy,omitempty" yaml:"key-pool-strategy,omitempty"`
+	ChainName               string                   `json:"-" yaml:"-"`
+	ChainID                 string                   `json:"chain-id" yaml:"chain-id"`
+	RPCAddr                 string                   `json:"rpc-addr" yaml:"rpc-addr"`
+	RPCAddrs                []string                 `json:"rpc-addrs,omitempty" yaml:"rpc-addrs,omitempty"`
+	GRPCAddr                string                   `json:"grpc-addr,omitempty" yaml:"grpc-addr,omitempty"`
+	LightClientVerification *LightClientVerification `json:"light-client-verification,omitempty" yaml:"light-client-verification,omitempty"`
+	MisbehaviourWitnesses   []string                 `json:"misbehaviour-witnesses,omitempty" yaml:"misbehaviour-witnesses,omitempty"`
+	CatchUpStrategy         string                   `json:"catch-up-strategy,omitempty" yaml:"catch-up-strategy,omitempty"`
+	QueryConcurrency        int                      `json:"query-concurrency,omitempty" yaml:"query-concurrency,omitempty"`
+	WebsocketSubscription   bool                     `json:"websocket-subscription,omitempty" yaml:"websocket-subscription,omitempty"`
+	AccountPrefix           string                   `json:"account-prefix" yaml:"account-prefix"`
+	KeyringBackend          string                   `json:"keyring-backend" yaml:"keyring-backend"`
+	GasAdjustment           float64                  `json:"gas-adjustment" yaml:"gas-adjustment"`
+	GasPrices               string                   `json:"gas-prices" yaml:"gas-prices"`
+	DynamicGasPrice         *DynamicGasPrice         `json:"dynamic-gas-price,omitempty" yaml:"dynamic-gas-price,omitempty"`
+	MinGasAmount            uint64                   `json:"min-gas-amount" yaml:"min-gas-amount"`
+	GasEstimationCache      bool                     `json:"gas-estimation-cache,omitempty" yaml:"gas-estimation-cache,omitempty"`
+	StuckTxReplacement      *StuckTxReplacement      `json:"stuck-tx-replacement,omitempty" yaml:"stuck-tx-replacement,omitempty"`
+	Debug                   bool                     `json:"debug" yaml:"debug"`
+	Timeout                 string                   `json:"timeout" yaml:"timeout"`
+	BlockTimeout            string                   `json:"block-timeout" yaml:"block-timeout"`
+	OutputFormat            string                   `json:"output-format" yaml:"output-format"`
+	SignModeStr             string                   `json:"sign-mode" yaml:"sign-mode"`
+	ExtraCodecs             []string                 `json:"extra-codecs" yaml:"extra-codecs"`
+	Modules                 []module.AppModuleBasic  `json:"-" yaml:"-"`
+	Slip44                  int                      `json:"coin-type" yaml:"coin-type"`
+	Broadcast               provider.BroadcastMode   `json:"broadcast-mode" yaml:"broadcast-mode"`
+	FeeBudgets              []FeeBudget              `json:"fee-budgets,omitempty" yaml:"fee-budgets,omitempty"`
+	FeeGranter              string                   `json:"fee-granter,omitempty" yaml:"fee-granter,omitempty"`
 }
 
 func (pc CosmosProviderConfig) Validate() error {
 	if _, err := time.ParseDuration(pc.Timeout); err != nil {
 		return fmt.Errorf("invalid Timeout: %w", err)
 	}
+	if pc.DynamicGasPrice != nil {
+		if err := pc.DynamicGasPrice.Validate(); err != nil {
+			return fmt.Errorf("invalid DynamicGasPrice: %w", err)
+		}
+		if _, err := sdk.ParseDecCoin(pc.GasPrices); err != nil {
+			return fmt.Errorf("invalid GasPrices, a dynamic gas price requires a single gas price: %w", err)
+		}
+	}
+	if pc.StuckTxReplacement != nil {
+		if err := pc.StuckTxReplacement.Validate(); err != nil {
+			return fmt.Errorf("invalid StuckTxReplacement: %w", err)
+		}
+	}
+	switch pc.KeyPoolStrategy {
+	case "", KeyPoolStrategyByPath, KeyPoolStrategyRoundRobin:
+	default:
+		return fmt.Errorf("invalid KeyPoolStrategy: %s is not one of [%s, %s]", pc.KeyPoolStrategy, KeyPoolStrategyByPath, KeyPoolStrategyRoundRobin)
+	}
+	switch pc.CatchUpStrategy {
+	case "", CatchUpStrategyBlocks, CatchUpStrategyTxSearch:
+	default:
+		return fmt.Errorf("invalid CatchUpStrategy: %s is not one of [%s, %s]", pc.CatchUpStrategy, CatchUpStrategyBlocks, CatchUpStrategyTxSearch)
+	}
+	if pc.QueryConcurrency < 0 {
+		return fmt.Errorf("invalid QueryConcurrency: must not be negative")
+	}
+	if pc.LightClientVerification != nil {
+		if err := pc.LightClientVerification.Validate(); err != nil {
+			return fmt.Errorf("invalid LightClientVerification: %w", err)
+		}
+	}
+	if pc.GRPCAddr != "" {
+		if _, _, err := grpcTarget(pc.GRPCAddr); err != nil {
+			return fmt.Errorf("invalid GRPCAddr: %w", err)
+		}
+	}
+	if pc.FeeGranter != "" {
+		if _, err := sdk.GetFromBech32(pc.FeeGranter, pc.AccountPrefix); err != nil {
+			return fmt.Errorf("invalid FeeGranter: %w", err)
+		}
+	}
+	for _, b := range pc.FeeBudgets {
+		if err := b.Validate(); err != nil {
+			return fmt.Errorf("invalid FeeBudgets: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -91,6 +153,14 @@ func (pc CosmosProviderConfig) NewProvider(log *zap.Logger, homepath string, deb
 
 		// TODO: this is a bit of a hack, we should probably have a better way to inject modules
 		Cdc: MakeCodec(pc.Modules, pc.ExtraCodecs),
+
+		feeBudgets: newFeeBudgetTracker(pc.ChainID, pc.FeeBudgets),
+
+		evidenceDir: path.Join(homepath, evidenceDir, pc.ChainID),
+	}
+
+	if pc.GasEstimationCache {
+		cp.gasModel = newGasModel()
 	}
 
 	return cp, nil
@@ -107,15 +177,57 @@ type CosmosProvider struct {
 	Input          io.Reader
 	Output         io.Writer
 	Cdc            Codec
-	// TODO: GRPC Client type?
 
-	nextAccountSeq uint64
-	txMu           sync.Mutex
+	// verifies the headers of LightProvider, nil until first used with light client verification enabled.
+	lightClient   *light.Client
+	lightClientMu sync.Mutex
+
+	// nodes which headers are compared with to detect misbehaviour, nil if not configured.
+	misbehaviourWitnesses []misbehaviourWitness
+
+	// where evidence of misbehaviour is saved, see QueryWitnessMisbehaviour.
+	evidenceDir string
+
+	// connection to the grpc-addr for queries without proofs, nil if not configured.
+	grpcConn *grpc.ClientConn
+
+	// the RPC endpoints which RPCClient sends requests to, nil until Init.
+	rpcPool *rpcPool
+
+	// keys used to sign transactions, created as they are first used.
+	signingKeys    map[string]*signingKey
+	nextSigningKey int
+	signingKeysMu  sync.Mutex
+
+	// the ordering of the channels on this chain, by port and channel ID, to pin the keys of ordered channels.
+	orderedChannels   map[string]bool
+	orderedChannelsMu sync.Mutex
 
 	// metrics to monitor the provider
 	TotalFees   sdk.Coins
+	keyFees     map[string]sdk.Coins
 	totalFeesMu sync.Mutex
 
+	// gas price queried from the chain's fee market, used instead of GasPrices when set.
+	dynamicGasPrice string
+	gasPriceMu      sync.Mutex
+
+	// latest height of the chain, shared by the transactions checked for being stuck, see stuckTxLatestHeight.
+	stuckTxHeight        int64
+	stuckTxHeightUpdated time.Time
+	stuckTxHeightMu      sync.Mutex
+
+	// consensus limits of each block, see QueryBlockLimits.
+	blockLimits        provider.BlockLimits
+	blockLimitsUpdated time.Time
+	blockLimitsMu      sync.Mutex
+
+	// learned gas used by recent transactions, nil unless the gas estimation cache is enabled.
+	gasModel *gasModel
+
+	// spend caps enforced when sending transactions, nil if none are configured.
+	feeBudgets *feeBudgetTracker
+
 	metrics *processor.PrometheusMetrics
 
 	// for comet < v0.37, decode tm events as base64
@@ -228,19 +340,40 @@ func (cc *CosmosProvider) Init(ctx context.Context) error {
 		return err
 	}
 
-	rpcClient, err := NewRPCClient(cc.PCfg.RPCAddr, timeout)
+	rpcPool, err := newRPCPool(cc.log, cc.PCfg.ChainID, cc.PCfg.RPCEndpoints(), timeout)
 	if err != nil {
 		return err
 	}
+	rpcPool.setMetrics(cc.metrics)
 
-	lightprovider, err := prov.New(cc.PCfg.ChainID, cc.PCfg.RPCAddr)
-	if err != nil {
-		return err
+	cc.rpcPool = rpcPool
+	cc.RPCClient = rpcPool
+	cc.LightProvider = prov.NewWithClient(cc.PCfg.ChainID, rpcPool)
+	cc.Keybase = keybase
+
+	if len(cc.PCfg.MisbehaviourWitnesses) > 0 {
+		witnesses, err := newMisbehaviourWitnesses(cc.PCfg.ChainID, cc.PCfg.MisbehaviourWitnesses)
+		if err != nil {
+			return err
+		}
+		cc.misbehaviourWitnesses = witnesses
 	}
 
-	cc.RPCClient = rpcClient
-	cc.LightProvider = lightprovider
-	cc.Keybase = keybase
+	if cc.PCfg.DynamicGasPrice != nil {
+		interval, err := cc.PCfg.DynamicGasPrice.updateInterval()
+		if err != nil {
+			return err
+		}
+		go cc.runGasPriceUpdates(ctx, interval)
+	}
+
+	if cc.PCfg.GRPCAddr != "" {
+		grpcConn, err := dialGRPC(cc.PCfg.GRPCAddr)
+		if err != nil {
+			return err
+		}
+		cc.grpcConn = grpcConn
+	}
 
 	status, err := cc.QueryStatus(ctx)
 	if err != nil {
@@ -291,11 +424,13 @@ func (cc *CosmosProvider) BlockTime(ctx context.Context, height int64) (time.Tim
 
 func (cc *CosmosProvider) SetMetrics(m *processor.PrometheusMetrics) {
 	cc.metrics = m
-}
-
-func (cc *CosmosProvider) updateNextAccountSequence(seq uint64) {
-	if seq > cc.nextAccountSeq {
-		cc.nextAccountSeq = seq
+	if cc.rpcPool != nil {
+		cc.rpcPool.setMetrics(m)
+	}
+	if cc.feeBudgets != nil && m != nil {
+		cc.feeBudgets.onCheck = func(key, denom string, exceeded bool) {
+			m.SetFeeBudgetExceeded(cc.ChainId(), key, denom, exceeded)
+		}
 	}
 }
 
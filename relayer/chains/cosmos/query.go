@@ -21,6 +21,7 @@ import (
 	"github.com/cosmos/cosmos-sdk/x/params/types/proposal"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 	upgradetypes "github.com/cosmos/cosmos-sdk/x/upgrade/types"
+	feetypes "github.com/cosmos/ibc-go/v7/modules/apps/29-fee/types"
 	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
 	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
 	conntypes "github.com/cosmos/ibc-go/v7/modules/core/03-connection/types"
@@ -273,6 +274,15 @@ func (cc *CosmosProvider) QueryTendermintProof(ctx context.Context, height int64
 	return res.Value, proofBz, clienttypes.NewHeight(revision, uint64(res.Height)+1), nil
 }
 
+// QueryClientStatus returns the current status of a client, e.g. Active, Expired or Frozen.
+func (cc *CosmosProvider) QueryClientStatus(ctx context.Context, clientID string) (ibcexported.Status, error) {
+	res, err := clienttypes.NewQueryClient(cc).ClientStatus(ctx, &clienttypes.QueryClientStatusRequest{ClientId: clientID})
+	if err != nil {
+		return ibcexported.Unknown, err
+	}
+	return ibcexported.Status(res.Status), nil
+}
+
 // QueryClientStateResponse retrieves the latest consensus state for a client in state at a given height
 func (cc *CosmosProvider) QueryClientStateResponse(ctx context.Context, height int64, srcClientId string) (*clienttypes.QueryClientStateResponse, error) {
 	key := host.FullClientStateKey(srcClientId)
@@ -1028,6 +1038,23 @@ func (cc *CosmosProvider) QueryLatestHeight(ctx context.Context) (int64, error)
 	return stat.SyncInfo.LatestBlockHeight, nil
 }
 
+// queryBlockLimits queries the maximum bytes and gas of a block from the consensus params of the chain.
+func (cc *CosmosProvider) queryBlockLimits(ctx context.Context) (provider.BlockLimits, error) {
+	res, err := cc.RPCClient.ConsensusParams(ctx, nil)
+	if err != nil {
+		return provider.BlockLimits{}, fmt.Errorf("failed to query consensus params: %w", err)
+	}
+	var limits provider.BlockLimits
+	// -1 means unlimited.
+	if maxBytes := res.ConsensusParams.Block.MaxBytes; maxBytes > 0 {
+		limits.MaxBytes = maxBytes
+	}
+	if maxGas := res.ConsensusParams.Block.MaxGas; maxGas > 0 {
+		limits.MaxGas = maxGas
+	}
+	return limits, nil
+}
+
 // Query current node status
 func (cc *CosmosProvider) QueryStatus(ctx context.Context) (*coretypes.ResultStatus, error) {
 	status, err := cc.RPCClient.Status(ctx)
@@ -1076,6 +1103,35 @@ func (cc *CosmosProvider) QueryDenomTraces(ctx context.Context, offset, limit ui
 	return transfers, nil
 }
 
+// QueryIncentivizedPacketsForChannel returns the fees escrowed by the ICS-29 fee middleware
+// for all incentivized packets on the given channel.
+func (cc *CosmosProvider) QueryIncentivizedPacketsForChannel(ctx context.Context, height uint64, channelid, portid string) ([]*feetypes.IdentifiedPacketFees, error) {
+	qc := feetypes.NewQueryClient(cc)
+	p := DefaultPageRequest()
+	var packets []*feetypes.IdentifiedPacketFees
+	for {
+		res, err := qc.IncentivizedPacketsForChannel(ctx, &feetypes.QueryIncentivizedPacketsForChannelRequest{
+			PortId:      portid,
+			ChannelId:   channelid,
+			QueryHeight: height,
+			Pagination:  p,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		packets = append(packets, res.IncentivizedPackets...)
+		// the response of ibc-go v7 has no pagination, so pages are requested by offset until one is not full.
+		if uint64(len(res.IncentivizedPackets)) < p.Limit {
+			break
+		}
+
+		time.Sleep(PaginationDelay)
+		p.Offset += p.Limit
+	}
+	return packets, nil
+}
+
 func (cc *CosmosProvider) QueryStakingParams(ctx context.Context) (*stakingtypes.Params, error) {
 	res, err := stakingtypes.NewQueryClient(cc).Params(ctx, &stakingtypes.QueryParamsRequest{})
 	if err != nil {
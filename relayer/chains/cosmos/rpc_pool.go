@@ -0,0 +1,520 @@
+package cosmos
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cometbft/cometbft/libs/bytes"
+	"github.com/cometbft/cometbft/libs/log"
+	rpcclient "github.com/cometbft/cometbft/rpc/client"
+	rpchttp "github.com/cometbft/cometbft/rpc/client/http"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	jsonrpctypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
+	comettypes "github.com/cometbft/cometbft/types"
+	"github.com/cosmos/relayer/v2/relayer/processor"
+	"go.uber.org/zap"
+)
+
+var _ rpcclient.RemoteClient = &rpcPool{}
+
+const (
+	// Weight of the latest request in the moving averages of the latency and error rate of an endpoint.
+	rpcHealthSmoothing = 0.2
+
+	// An endpoint is unhealthy after this many consecutive failed requests,
+	// until rpcUnhealthyCooldown has passed since the last failure.
+	rpcUnhealthyAfterErrors = 3
+	rpcUnhealthyCooldown    = 30 * time.Second
+
+	// How often the status of every endpoint is queried.
+	rpcHealthCheckInterval = 30 * time.Second
+
+	// An endpoint this many blocks behind the highest endpoint is treated as catching up.
+	rpcMaxHeightLag = 5
+)
+
+// RPCEndpoints returns the addresses of the RPC endpoints of the chain:
+// the configured rpc-addr, followed by the additional rpc-addrs.
+func (pc CosmosProviderConfig) RPCEndpoints() []string {
+	addrs := []string{pc.RPCAddr}
+	seen := map[string]bool{pc.RPCAddr: true}
+	for _, addr := range pc.RPCAddrs {
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// rpcEndpoint is an RPC node of the chain along with its health.
+type rpcEndpoint struct {
+	addr   string
+	client *rpchttp.HTTP
+
+	mu                sync.Mutex
+	latency           time.Duration // moving average of the latency of successful requests
+	errorRate         float64       // moving average of the rate of failed requests, from 0 to 1
+	consecutiveErrors int
+	lastError         time.Time
+	catchingUp        bool
+	height            int64
+}
+
+func (e *rpcEndpoint) recordSuccess(latency time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.latency == 0 {
+		e.latency = latency
+	} else {
+		e.latency = time.Duration(rpcHealthSmoothing*float64(latency) + (1-rpcHealthSmoothing)*float64(e.latency))
+	}
+	e.errorRate *= 1 - rpcHealthSmoothing
+	e.consecutiveErrors = 0
+}
+
+func (e *rpcEndpoint) recordFailure(now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errorRate = rpcHealthSmoothing + (1-rpcHealthSmoothing)*e.errorRate
+	e.consecutiveErrors++
+	e.lastError = now
+}
+
+func (e *rpcEndpoint) recordStatus(status *coretypes.ResultStatus) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.catchingUp = status.SyncInfo.CatchingUp
+	e.height = status.SyncInfo.LatestBlockHeight
+}
+
+// healthy returns false if the endpoint is catching up or has failed repeatedly.
+// mu must be held.
+func (e *rpcEndpoint) healthy(now time.Time) bool {
+	if e.catchingUp {
+		return false
+	}
+	return e.consecutiveErrors < rpcUnhealthyAfterErrors || now.Sub(e.lastError) >= rpcUnhealthyCooldown
+}
+
+// score ranks healthy endpoints, lower is better. It is the average latency, raised by the error rate.
+// mu must be held.
+func (e *rpcEndpoint) score() float64 {
+	return e.latency.Seconds() * (1 + 10*e.errorRate)
+}
+
+// rpcPool is an RPC client which sends each request to the healthiest of the RPC endpoints of the chain,
+// failing over to the next endpoint if the request fails. Requests which reach the node and are answered
+// with an error, e.g. a tx which is not found, are not retried.
+type rpcPool struct {
+	log     *zap.Logger
+	chainID string
+
+	endpoints []*rpcEndpoint
+
+	mu              sync.Mutex
+	lastHealthCheck time.Time
+	best            string
+	metrics         *processor.PrometheusMetrics
+}
+
+func newRPCPool(log *zap.Logger, chainID string, addrs []string, timeout time.Duration) (*rpcPool, error) {
+	p := &rpcPool{log: log, chainID: chainID}
+	for _, addr := range addrs {
+		client, err := NewRPCClient(addr, timeout)
+		if err != nil {
+			return nil, err
+		}
+		p.endpoints = append(p.endpoints, &rpcEndpoint{addr: addr, client: client})
+	}
+	return p, nil
+}
+
+func (p *rpcPool) setMetrics(m *processor.PrometheusMetrics) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.metrics = m
+}
+
+// ordered returns the endpoints from the healthiest to the least healthy.
+// The order of the configuration breaks ties, so the rpc-addr is preferred while nothing is known.
+func (p *rpcPool) ordered() []*rpcEndpoint {
+	now := time.Now()
+	type ranked struct {
+		e       *rpcEndpoint
+		healthy bool
+		score   float64
+	}
+	endpoints := make([]ranked, len(p.endpoints))
+	for i, e := range p.endpoints {
+		e.mu.Lock()
+		endpoints[i] = ranked{e: e, healthy: e.healthy(now), score: e.score()}
+		e.mu.Unlock()
+	}
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		if endpoints[i].healthy != endpoints[j].healthy {
+			return endpoints[i].healthy
+		}
+		return endpoints[i].score < endpoints[j].score
+	})
+
+	ordered := make([]*rpcEndpoint, len(endpoints))
+	for i, r := range endpoints {
+		ordered[i] = r.e
+	}
+
+	p.mu.Lock()
+	if best := ordered[0].addr; best != p.best {
+		if p.best != "" {
+			p.log.Warn("Switching to healthier RPC endpoint",
+				zap.String("previous", endpointLabel(p.best)),
+				zap.String("endpoint", endpointLabel(best)),
+			)
+		}
+		p.best = best
+	}
+	p.mu.Unlock()
+
+	return ordered
+}
+
+// bestAddr returns the address of the healthiest endpoint.
+func (p *rpcPool) bestAddr() string {
+	return p.ordered()[0].addr
+}
+
+// isEndpointError returns true if err is a failure to reach the endpoint or get a valid response from it,
+// rather than an error response from the node.
+func isEndpointError(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var rpcErr *jsonrpctypes.RPCError
+	return !errors.As(err, &rpcErr)
+}
+
+// rpcCall sends the request to the healthiest endpoint, failing over to the next on endpoint errors.
+func rpcCall[T any](ctx context.Context, p *rpcPool, call func(*rpcEndpoint) (T, error)) (T, error) {
+	p.maybeCheckHealth()
+
+	var (
+		res T
+		err error
+	)
+	for _, e := range p.ordered() {
+		start := time.Now()
+		res, err = call(e)
+		if !isEndpointError(ctx, err) {
+			if err == nil || ctx.Err() == nil {
+				e.recordSuccess(time.Since(start))
+			}
+			return res, err
+		}
+		e.recordFailure(time.Now())
+		p.reportFailure(e, err)
+	}
+	return res, err
+}
+
+func (p *rpcPool) reportFailure(e *rpcEndpoint, err error) {
+	p.log.Warn("RPC request failed",
+		zap.String("endpoint", endpointLabel(e.addr)),
+		zap.Error(err),
+	)
+	p.mu.Lock()
+	m := p.metrics
+	p.mu.Unlock()
+	if m != nil {
+		m.IncRPCEndpointErrors(p.chainID, endpointLabel(e.addr))
+	}
+}
+
+// maybeCheckHealth starts a health check in the background if rpcHealthCheckInterval has passed since the last one.
+func (p *rpcPool) maybeCheckHealth() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Since(p.lastHealthCheck) < rpcHealthCheckInterval {
+		return
+	}
+	p.lastHealthCheck = time.Now()
+	go p.checkHealth(context.Background())
+}
+
+// checkHealth queries the status of every endpoint, recording its latency and whether it is catching up,
+// and updates the health metrics.
+func (p *rpcPool) checkHealth(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, e := range p.endpoints {
+		e := e
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			status, err := e.client.Status(ctx)
+			if err != nil {
+				e.recordFailure(time.Now())
+				p.reportFailure(e, err)
+				return
+			}
+			e.recordSuccess(time.Since(start))
+			e.recordStatus(status)
+		}()
+	}
+	wg.Wait()
+
+	var maxHeight int64
+	for _, e := range p.endpoints {
+		e.mu.Lock()
+		if e.height > maxHeight {
+			maxHeight = e.height
+		}
+		e.mu.Unlock()
+	}
+
+	p.mu.Lock()
+	m := p.metrics
+	p.mu.Unlock()
+
+	now := time.Now()
+	for _, e := range p.endpoints {
+		e.mu.Lock()
+		if e.height > 0 && maxHeight-e.height > rpcMaxHeightLag {
+			e.catchingUp = true
+		}
+		healthy, latency := e.healthy(now), e.latency
+		e.mu.Unlock()
+		if m != nil {
+			m.SetRPCEndpointHealth(p.chainID, endpointLabel(e.addr), healthy, latency)
+		}
+	}
+}
+
+// endpointLabel returns the address without credentials or query parameters, which may contain API keys,
+// for use in logs and metrics.
+func endpointLabel(addr string) string {
+	u, err := url.Parse(addr)
+	if err != nil || u.Host == "" {
+		return addr
+	}
+	return u.Scheme + "://" + u.Host + u.Path
+}
+
+// Remote returns the address of the healthiest endpoint.
+func (p *rpcPool) Remote() string {
+	return p.bestAddr()
+}
+
+// The service and event subscription methods are handled by the rpc-addr endpoint,
+// since subscriptions are bound to the client which made them.
+
+func (p *rpcPool) primary() *rpchttp.HTTP { return p.endpoints[0].client }
+
+func (p *rpcPool) Start() error                { return p.primary().Start() }
+func (p *rpcPool) OnStart() error              { return p.primary().OnStart() }
+func (p *rpcPool) Stop() error                 { return p.primary().Stop() }
+func (p *rpcPool) OnStop()                     { p.primary().OnStop() }
+func (p *rpcPool) Reset() error                { return p.primary().Reset() }
+func (p *rpcPool) OnReset() error              { return p.primary().OnReset() }
+func (p *rpcPool) IsRunning() bool             { return p.primary().IsRunning() }
+func (p *rpcPool) Quit() <-chan struct{}       { return p.primary().Quit() }
+func (p *rpcPool) String() string              { return p.primary().String() }
+func (p *rpcPool) SetLogger(logger log.Logger) { p.primary().SetLogger(logger) }
+
+func (p *rpcPool) Subscribe(ctx context.Context, subscriber, query string, outCapacity ...int) (<-chan coretypes.ResultEvent, error) {
+	return p.primary().Subscribe(ctx, subscriber, query, outCapacity...)
+}
+
+func (p *rpcPool) Unsubscribe(ctx context.Context, subscriber, query string) error {
+	return p.primary().Unsubscribe(ctx, subscriber, query)
+}
+
+func (p *rpcPool) UnsubscribeAll(ctx context.Context, subscriber string) error {
+	return p.primary().UnsubscribeAll(ctx, subscriber)
+}
+
+func (p *rpcPool) ABCIInfo(ctx context.Context) (*coretypes.ResultABCIInfo, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultABCIInfo, error) {
+		return e.client.ABCIInfo(ctx)
+	})
+}
+
+func (p *rpcPool) ABCIQuery(ctx context.Context, path string, data bytes.HexBytes) (*coretypes.ResultABCIQuery, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultABCIQuery, error) {
+		return e.client.ABCIQuery(ctx, path, data)
+	})
+}
+
+func (p *rpcPool) ABCIQueryWithOptions(ctx context.Context, path string, data bytes.HexBytes, opts rpcclient.ABCIQueryOptions) (*coretypes.ResultABCIQuery, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultABCIQuery, error) {
+		return e.client.ABCIQueryWithOptions(ctx, path, data, opts)
+	})
+}
+
+func (p *rpcPool) BroadcastTxCommit(ctx context.Context, tx comettypes.Tx) (*coretypes.ResultBroadcastTxCommit, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultBroadcastTxCommit, error) {
+		return e.client.BroadcastTxCommit(ctx, tx)
+	})
+}
+
+func (p *rpcPool) BroadcastTxAsync(ctx context.Context, tx comettypes.Tx) (*coretypes.ResultBroadcastTx, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultBroadcastTx, error) {
+		return e.client.BroadcastTxAsync(ctx, tx)
+	})
+}
+
+func (p *rpcPool) BroadcastTxSync(ctx context.Context, tx comettypes.Tx) (*coretypes.ResultBroadcastTx, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultBroadcastTx, error) {
+		return e.client.BroadcastTxSync(ctx, tx)
+	})
+}
+
+func (p *rpcPool) Block(ctx context.Context, height *int64) (*coretypes.ResultBlock, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultBlock, error) {
+		return e.client.Block(ctx, height)
+	})
+}
+
+func (p *rpcPool) BlockByHash(ctx context.Context, hash []byte) (*coretypes.ResultBlock, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultBlock, error) {
+		return e.client.BlockByHash(ctx, hash)
+	})
+}
+
+func (p *rpcPool) BlockResults(ctx context.Context, height *int64) (*coretypes.ResultBlockResults, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultBlockResults, error) {
+		return e.client.BlockResults(ctx, height)
+	})
+}
+
+func (p *rpcPool) Header(ctx context.Context, height *int64) (*coretypes.ResultHeader, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultHeader, error) {
+		return e.client.Header(ctx, height)
+	})
+}
+
+func (p *rpcPool) HeaderByHash(ctx context.Context, hash bytes.HexBytes) (*coretypes.ResultHeader, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultHeader, error) {
+		return e.client.HeaderByHash(ctx, hash)
+	})
+}
+
+func (p *rpcPool) Commit(ctx context.Context, height *int64) (*coretypes.ResultCommit, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultCommit, error) {
+		return e.client.Commit(ctx, height)
+	})
+}
+
+func (p *rpcPool) Validators(ctx context.Context, height *int64, page, perPage *int) (*coretypes.ResultValidators, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultValidators, error) {
+		return e.client.Validators(ctx, height, page, perPage)
+	})
+}
+
+func (p *rpcPool) Tx(ctx context.Context, hash []byte, prove bool) (*coretypes.ResultTx, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultTx, error) {
+		return e.client.Tx(ctx, hash, prove)
+	})
+}
+
+func (p *rpcPool) TxSearch(ctx context.Context, query string, prove bool, page, perPage *int, orderBy string) (*coretypes.ResultTxSearch, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultTxSearch, error) {
+		return e.client.TxSearch(ctx, query, prove, page, perPage, orderBy)
+	})
+}
+
+func (p *rpcPool) BlockSearch(ctx context.Context, query string, page, perPage *int, orderBy string) (*coretypes.ResultBlockSearch, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultBlockSearch, error) {
+		return e.client.BlockSearch(ctx, query, page, perPage, orderBy)
+	})
+}
+
+func (p *rpcPool) Genesis(ctx context.Context) (*coretypes.ResultGenesis, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultGenesis, error) {
+		return e.client.Genesis(ctx)
+	})
+}
+
+func (p *rpcPool) GenesisChunked(ctx context.Context, id uint) (*coretypes.ResultGenesisChunk, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultGenesisChunk, error) {
+		return e.client.GenesisChunked(ctx, id)
+	})
+}
+
+func (p *rpcPool) BlockchainInfo(ctx context.Context, minHeight, maxHeight int64) (*coretypes.ResultBlockchainInfo, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultBlockchainInfo, error) {
+		return e.client.BlockchainInfo(ctx, minHeight, maxHeight)
+	})
+}
+
+// Status also records whether the endpoint which answered is catching up.
+func (p *rpcPool) Status(ctx context.Context) (*coretypes.ResultStatus, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultStatus, error) {
+		status, err := e.client.Status(ctx)
+		if err == nil {
+			e.recordStatus(status)
+		}
+		return status, err
+	})
+}
+
+func (p *rpcPool) NetInfo(ctx context.Context) (*coretypes.ResultNetInfo, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultNetInfo, error) {
+		return e.client.NetInfo(ctx)
+	})
+}
+
+func (p *rpcPool) DumpConsensusState(ctx context.Context) (*coretypes.ResultDumpConsensusState, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultDumpConsensusState, error) {
+		return e.client.DumpConsensusState(ctx)
+	})
+}
+
+func (p *rpcPool) ConsensusState(ctx context.Context) (*coretypes.ResultConsensusState, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultConsensusState, error) {
+		return e.client.ConsensusState(ctx)
+	})
+}
+
+func (p *rpcPool) ConsensusParams(ctx context.Context, height *int64) (*coretypes.ResultConsensusParams, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultConsensusParams, error) {
+		return e.client.ConsensusParams(ctx, height)
+	})
+}
+
+func (p *rpcPool) Health(ctx context.Context) (*coretypes.ResultHealth, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultHealth, error) {
+		return e.client.Health(ctx)
+	})
+}
+
+func (p *rpcPool) BroadcastEvidence(ctx context.Context, ev comettypes.Evidence) (*coretypes.ResultBroadcastEvidence, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultBroadcastEvidence, error) {
+		return e.client.BroadcastEvidence(ctx, ev)
+	})
+}
+
+func (p *rpcPool) UnconfirmedTxs(ctx context.Context, limit *int) (*coretypes.ResultUnconfirmedTxs, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultUnconfirmedTxs, error) {
+		return e.client.UnconfirmedTxs(ctx, limit)
+	})
+}
+
+func (p *rpcPool) NumUnconfirmedTxs(ctx context.Context) (*coretypes.ResultUnconfirmedTxs, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultUnconfirmedTxs, error) {
+		return e.client.NumUnconfirmedTxs(ctx)
+	})
+}
+
+func (p *rpcPool) CheckTx(ctx context.Context, tx comettypes.Tx) (*coretypes.ResultCheckTx, error) {
+	return rpcCall(ctx, p, func(e *rpcEndpoint) (*coretypes.ResultCheckTx, error) {
+		return e.client.CheckTx(ctx, tx)
+	})
+}
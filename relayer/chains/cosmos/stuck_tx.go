@@ -0,0 +1,261 @@
+package cosmos
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultStuckTxAfterBlocks     = 5
+	defaultStuckTxFeeMultiplier   = 1.5
+	defaultStuckTxMaxReplacements = 3
+
+	// How often the latest height is queried to check whether a transaction is stuck in the mempool.
+	stuckTxCheckInterval = time.Second
+)
+
+// StuckTxReplacement configures replacing transactions which stay in the mempool without being included
+// in a block with a transaction with the same sequence and a higher fee. Only enable it on chains
+// whose mempool accepts replacing a transaction by fee, the default CometBFT mempool rejects the replacement.
+type StuckTxReplacement struct {
+	// Number of blocks a transaction can stay in the mempool before it is replaced. Defaults to 5.
+	AfterBlocks uint64 `json:"after-blocks,omitempty" yaml:"after-blocks,omitempty"`
+
+	// Factor by which the fees are raised for each replacement, must be greater than 1. Defaults to 1.5.
+	FeeMultiplier float64 `json:"fee-multiplier,omitempty" yaml:"fee-multiplier,omitempty"`
+
+	// Maximum number of times a transaction is replaced. Defaults to 3.
+	MaxReplacements int `json:"max-replacements,omitempty" yaml:"max-replacements,omitempty"`
+
+	// Maximum gas price of a replacement, e.g. "0.1uatom". The fees in its denom are capped
+	// at the gas price times the gas wanted. Unlimited if empty.
+	MaxGasPrice string `json:"max-gas-price,omitempty" yaml:"max-gas-price,omitempty"`
+}
+
+// Validate checks the fee multiplier, maximum replacements and maximum gas price.
+func (r StuckTxReplacement) Validate() error {
+	if r.FeeMultiplier != 0 && r.FeeMultiplier <= 1 {
+		return fmt.Errorf("stuck tx fee-multiplier must be greater than 1")
+	}
+	if r.MaxReplacements < 0 {
+		return fmt.Errorf("stuck tx max-replacements must not be negative")
+	}
+	if r.MaxGasPrice != "" {
+		if _, err := sdk.ParseDecCoin(r.MaxGasPrice); err != nil {
+			return fmt.Errorf("invalid stuck tx max-gas-price: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r StuckTxReplacement) afterBlocks() uint64 {
+	if r.AfterBlocks == 0 {
+		return defaultStuckTxAfterBlocks
+	}
+	return r.AfterBlocks
+}
+
+func (r StuckTxReplacement) maxReplacements() int {
+	if r.MaxReplacements == 0 {
+		return defaultStuckTxMaxReplacements
+	}
+	return r.MaxReplacements
+}
+
+// bumpFees returns the fees of a replacement for a transaction with the fees and gas wanted.
+// ok is false if the fees can not be raised, because they are already at the maximum gas price.
+// The config must have been validated.
+func (r StuckTxReplacement) bumpFees(fees sdk.Coins, gas uint64) (bumped sdk.Coins, ok bool) {
+	multiplier := defaultStuckTxFeeMultiplier
+	if r.FeeMultiplier != 0 {
+		multiplier = r.FeeMultiplier
+	}
+	m, err := sdk.NewDecFromStr(strconv.FormatFloat(multiplier, 'f', -1, 64))
+	if err != nil {
+		return nil, false
+	}
+
+	var maxGasPrice *sdk.DecCoin
+	if r.MaxGasPrice != "" {
+		p, err := sdk.ParseDecCoin(r.MaxGasPrice)
+		if err != nil {
+			return nil, false
+		}
+		maxGasPrice = &p
+	}
+
+	for _, fee := range fees {
+		amount := sdk.NewDecFromInt(fee.Amount).Mul(m).Ceil().TruncateInt()
+		if maxGasPrice != nil && fee.Denom == maxGasPrice.Denom {
+			max := maxGasPrice.Amount.MulInt64(int64(gas)).Ceil().TruncateInt()
+			if amount.GT(max) {
+				amount = max
+			}
+		}
+		if amount.GT(fee.Amount) {
+			ok = true
+		} else {
+			amount = fee.Amount
+		}
+		bumped = bumped.Add(sdk.NewCoin(fee.Denom, amount))
+	}
+	return bumped, ok
+}
+
+// stuckTx is a broadcast transaction along with what is needed to replace it if it is stuck in the mempool.
+type stuckTx struct {
+	key      string
+	msgs     []provider.RelayerMessage
+	memo     string
+	sequence uint64
+	gas      uint64
+	fees     sdk.Coins
+
+	// height at which the latest transaction was first seen not to be included, zero until checked.
+	since        int64
+	replacements int
+}
+
+// replaceIfStuck replaces the transaction with one with a higher fee if it has not been included in a block
+// within the configured number of blocks. It returns the hash of the replacement, nil if it was not replaced.
+func (cc *CosmosProvider) replaceIfStuck(ctx context.Context, stuck *stuckTx) []byte {
+	cfg := cc.PCfg.StuckTxReplacement
+	if cfg == nil || stuck.replacements >= cfg.maxReplacements() {
+		return nil
+	}
+
+	height, err := cc.stuckTxLatestHeight(ctx)
+	if err != nil {
+		return nil
+	}
+	if stuck.since == 0 {
+		stuck.since = height
+		return nil
+	}
+	if height-stuck.since < int64(cfg.afterBlocks()) {
+		return nil
+	}
+
+	fees, ok := cfg.bumpFees(stuck.fees, stuck.gas)
+	if !ok {
+		cc.log.Warn("Transaction is stuck in the mempool, but its fees are already at the max gas price",
+			zap.String("key", stuck.key),
+			zap.Uint64("sequence", stuck.sequence),
+			zap.Stringer("fees", stuck.fees),
+		)
+		stuck.replacements = cfg.maxReplacements()
+		return nil
+	}
+
+	hash, err := cc.replaceTx(ctx, stuck, fees)
+	if err != nil {
+		cc.log.Warn("Failed to replace transaction stuck in the mempool",
+			zap.String("key", stuck.key),
+			zap.Uint64("sequence", stuck.sequence),
+			zap.Stringer("fees", fees),
+			zap.Error(err),
+		)
+		// the mempool does not accept the replacement, so it will not accept a later one either.
+		stuck.replacements = cfg.maxReplacements()
+		return nil
+	}
+	if hash == nil {
+		// the sequence has already been used, so one of the transactions was included.
+		return nil
+	}
+
+	stuck.replacements++
+	cc.log.Info("Replaced transaction stuck in the mempool with a higher fee",
+		zap.String("key", stuck.key),
+		zap.Uint64("sequence", stuck.sequence),
+		zap.Int64("stuck_blocks", height-stuck.since),
+		zap.Stringer("previous_fees", stuck.fees),
+		zap.Stringer("fees", fees),
+		zap.Int("replacement", stuck.replacements),
+		zap.String("tx_hash", fmt.Sprintf("%X", hash)),
+	)
+	if cc.metrics != nil {
+		cc.metrics.IncTxReplacements(cc.ChainId(), stuck.key)
+	}
+	stuck.fees = fees
+	stuck.since = height
+	return hash
+}
+
+// stuckTxLatestHeight returns the latest height of the chain, which is shared by all the transactions
+// waiting for block inclusion, so that it is queried once per check rather than once per transaction.
+func (cc *CosmosProvider) stuckTxLatestHeight(ctx context.Context) (int64, error) {
+	cc.stuckTxHeightMu.Lock()
+	defer cc.stuckTxHeightMu.Unlock()
+
+	// half the check interval, so that the height is queried again by the next check.
+	if time.Since(cc.stuckTxHeightUpdated) < stuckTxCheckInterval/2 {
+		return cc.stuckTxHeight, nil
+	}
+	height, err := cc.QueryLatestHeight(ctx)
+	if err != nil {
+		return 0, err
+	}
+	cc.stuckTxHeight = height
+	cc.stuckTxHeightUpdated = time.Now()
+	return height, nil
+}
+
+// replaceTx broadcasts a transaction with the same messages and sequence as the stuck transaction, with the fees.
+// It returns the hash of the replacement, or nil if the sequence has already been used.
+func (cc *CosmosProvider) replaceTx(ctx context.Context, stuck *stuckTx, fees sdk.Coins) ([]byte, error) {
+	key := cc.lockSigningKey(stuck.key)
+	defer key.mu.Unlock()
+
+	txf, err := cc.keyTxFactory(stuck.key, stuck.memo)
+	if err != nil {
+		return nil, err
+	}
+	if txf.Sequence() > stuck.sequence {
+		return nil, nil
+	}
+
+	// the increase in fees is spent in addition to the fees of the stuck transaction.
+	extraFees := fees.Sub(stuck.fees...)
+	if cc.feeBudgets != nil {
+		if err := cc.feeBudgets.check(stuck.key, extraFees, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	txf = txf.
+		WithSequence(stuck.sequence).
+		WithGas(stuck.gas).
+		WithGasPrices("").
+		WithFees(fees.String())
+
+	txBytes, _, err := cc.signTx(ctx, txf, stuck.key, stuck.msgs)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := cc.RPCClient.BroadcastTxSync(ctx, txBytes)
+	if err != nil {
+		return nil, err
+	}
+	if res.Code != 0 {
+		err := cc.sdkError(res.Codespace, res.Code)
+		if err == nil {
+			err = fmt.Errorf("replacement rejected with code %d: %s", res.Code, res.Log)
+		}
+		return nil, err
+	}
+
+	cc.UpdateFeesSpent(cc.ChainId(), stuck.key, extraFees)
+	if cc.feeBudgets != nil {
+		cc.feeBudgets.record(stuck.key, extraFees, time.Now())
+	}
+
+	return res.Hash, nil
+}
@@ -0,0 +1,276 @@
+package cosmos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/tmhash"
+	"github.com/cometbft/cometbft/libs/bytes"
+	rpcclient "github.com/cometbft/cometbft/rpc/client"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	comettypes "github.com/cometbft/cometbft/types"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestStuckTxReplacementValidate(t *testing.T) {
+	require.NoError(t, StuckTxReplacement{}.Validate())
+	require.NoError(t, StuckTxReplacement{FeeMultiplier: 1.2, MaxReplacements: 5, MaxGasPrice: "0.1uatom"}.Validate())
+	require.Error(t, StuckTxReplacement{FeeMultiplier: 1}.Validate())
+	require.Error(t, StuckTxReplacement{MaxReplacements: -1}.Validate())
+	require.Error(t, StuckTxReplacement{MaxGasPrice: "uatom"}.Validate())
+}
+
+func TestStuckTxBumpFees(t *testing.T) {
+	fees := sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000))
+
+	bumped, ok := StuckTxReplacement{}.bumpFees(fees, 100000)
+	require.True(t, ok)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1500)), bumped)
+
+	bumped, ok = StuckTxReplacement{FeeMultiplier: 1.11}.bumpFees(sdk.NewCoins(sdk.NewInt64Coin("uatom", 999)), 100000)
+	require.True(t, ok)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1109)), bumped, "fees are rounded up")
+
+	// capped at 0.012 * 100000 = 1200
+	r := StuckTxReplacement{MaxGasPrice: "0.012uatom"}
+	bumped, ok = r.bumpFees(fees, 100000)
+	require.True(t, ok)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1200)), bumped)
+
+	_, ok = r.bumpFees(bumped, 100000)
+	require.False(t, ok, "fees are already at the max gas price")
+
+	// the cap only applies to its denom.
+	bumped, ok = StuckTxReplacement{MaxGasPrice: "0.001uosmo"}.bumpFees(fees, 100000)
+	require.True(t, ok)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1500)), bumped)
+}
+
+// stuckTxRPCClient is the RPC client of a chain whose mempool accepts replacement transactions. It answers the
+// queries made while waiting for and replacing a stuck transaction: the latest height, the account of the key,
+// the broadcast of the replacement and the inclusion of the transactions in a block.
+type stuckTxRPCClient struct {
+	rpcclient.Client
+
+	mu            sync.Mutex
+	height        int64
+	account       *authtypes.BaseAccount
+	broadcasts    [][]byte
+	included      map[string][]byte
+	statusQueries int
+}
+
+func (c *stuckTxRPCClient) setHeight(height int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.height = height
+}
+
+func (c *stuckTxRPCClient) Status(context.Context) (*coretypes.ResultStatus, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.statusQueries++
+	return &coretypes.ResultStatus{SyncInfo: coretypes.SyncInfo{LatestBlockHeight: c.height}}, nil
+}
+
+func (c *stuckTxRPCClient) ABCIQueryWithOptions(_ context.Context, path string, _ bytes.HexBytes, _ rpcclient.ABCIQueryOptions) (*coretypes.ResultABCIQuery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if path != "/cosmos.auth.v1beta1.Query/Account" {
+		return nil, fmt.Errorf("unexpected query %s", path)
+	}
+	account, err := codectypes.NewAnyWithValue(c.account)
+	if err != nil {
+		return nil, err
+	}
+	bz, err := (&authtypes.QueryAccountResponse{Account: account}).Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return &coretypes.ResultABCIQuery{Response: abci.ResponseQuery{Value: bz, Height: c.height}}, nil
+}
+
+func (c *stuckTxRPCClient) BroadcastTxSync(_ context.Context, tx comettypes.Tx) (*coretypes.ResultBroadcastTx, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.broadcasts = append(c.broadcasts, tx)
+	return &coretypes.ResultBroadcastTx{Hash: tmhash.Sum(tx)}, nil
+}
+
+func (c *stuckTxRPCClient) Tx(_ context.Context, hash []byte, _ bool) (*coretypes.ResultTx, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tx, ok := c.included[string(hash)]
+	if !ok {
+		return nil, errors.New("tx not found")
+	}
+	return &coretypes.ResultTx{Hash: hash, Height: c.height, Tx: tx}, nil
+}
+
+// includeBroadcasts includes the broadcast transactions in a block.
+func (c *stuckTxRPCClient) includeBroadcasts() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, tx := range c.broadcasts {
+		c.included[string(tmhash.Sum(tx))] = tx
+	}
+}
+
+// newStuckTxProvider returns a provider with the key "default", whose account has the sequence,
+// connected to a stuckTxRPCClient at height 100.
+func newStuckTxProvider(t *testing.T, cfg StuckTxReplacement, budgets []FeeBudget, sequence uint64) (*CosmosProvider, *stuckTxRPCClient, *stuckTx) {
+	pcfg := CosmosProviderConfig{
+		Key:                "default",
+		ChainID:            "cosmoshub-4",
+		AccountPrefix:      "cosmos",
+		GasAdjustment:      1.2,
+		GasPrices:          "0.01uatom",
+		Timeout:            "10s",
+		StuckTxReplacement: &cfg,
+		FeeBudgets:         budgets,
+	}
+	p, err := pcfg.NewProvider(zap.NewNop(), t.TempDir(), false, "cosmoshub")
+	require.NoError(t, err)
+	cc := p.(*CosmosProvider)
+
+	cc.Keybase = keyring.NewInMemory(cc.Cdc.Marshaler, KeyringAlgoOptions())
+	_, err = cc.AddKey("default", 118)
+	require.NoError(t, err)
+	addr, err := cc.keyAddress("default")
+	require.NoError(t, err)
+	from, err := cc.EncodeBech32AccAddr(addr)
+	require.NoError(t, err)
+
+	rpc := &stuckTxRPCClient{
+		height:   100,
+		account:  &authtypes.BaseAccount{Address: from, AccountNumber: 7, Sequence: sequence},
+		included: make(map[string][]byte),
+	}
+	cc.RPCClient = rpc
+
+	stuck := &stuckTx{
+		key:      "default",
+		msgs:     []provider.RelayerMessage{NewCosmosMessage(banktypes.NewMsgSend(addr, addr, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1))))},
+		sequence: 5,
+		gas:      100000,
+		fees:     sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000)),
+	}
+	return cc, rpc, stuck
+}
+
+// nextStuckTxCheck advances the chain to the height and lets the next check query it.
+func nextStuckTxCheck(cc *CosmosProvider, rpc *stuckTxRPCClient, height int64) {
+	rpc.setHeight(height)
+	cc.stuckTxHeightMu.Lock()
+	cc.stuckTxHeightUpdated = time.Time{}
+	cc.stuckTxHeightMu.Unlock()
+}
+
+func TestReplaceIfStuck(t *testing.T) {
+	ctx := context.Background()
+	budgets := []FeeBudget{{Window: "day", MaxFees: "10000uatom"}}
+	cc, rpc, stuck := newStuckTxProvider(t, StuckTxReplacement{AfterBlocks: 2, MaxReplacements: 2}, budgets, 5)
+
+	require.Nil(t, cc.replaceIfStuck(ctx, stuck))
+	require.Equal(t, int64(100), stuck.since, "the first check records the height")
+
+	nextStuckTxCheck(cc, rpc, 101)
+	require.Nil(t, cc.replaceIfStuck(ctx, stuck), "not stuck for after-blocks yet")
+	require.Empty(t, rpc.broadcasts)
+
+	nextStuckTxCheck(cc, rpc, 102)
+	hash := cc.replaceIfStuck(ctx, stuck)
+	require.NotNil(t, hash)
+	require.Len(t, rpc.broadcasts, 1)
+	require.Equal(t, tmhash.Sum(rpc.broadcasts[0]), hash)
+	require.Equal(t, 1, stuck.replacements)
+	require.Equal(t, int64(102), stuck.since, "after-blocks restarts from the replacement")
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1500)), stuck.fees)
+	// only the extra fees of the replacement, 500uatom, count against the budget.
+	now := time.Now()
+	require.NoError(t, cc.feeBudgets.check("default", sdk.NewCoins(sdk.NewInt64Coin("uatom", 9500)), now))
+	require.Error(t, cc.feeBudgets.check("default", sdk.NewCoins(sdk.NewInt64Coin("uatom", 9501)), now))
+
+	nextStuckTxCheck(cc, rpc, 104)
+	require.NotNil(t, cc.replaceIfStuck(ctx, stuck))
+	require.Equal(t, 2, stuck.replacements)
+
+	nextStuckTxCheck(cc, rpc, 110)
+	require.Nil(t, cc.replaceIfStuck(ctx, stuck), "stops after max-replacements")
+	require.Len(t, rpc.broadcasts, 2)
+}
+
+func TestReplaceIfStuckSequenceUsed(t *testing.T) {
+	ctx := context.Background()
+	// the account sequence is past the stuck transaction, so it, or a replacement, was included.
+	cc, rpc, stuck := newStuckTxProvider(t, StuckTxReplacement{AfterBlocks: 1}, nil, 6)
+
+	require.Nil(t, cc.replaceIfStuck(ctx, stuck))
+	nextStuckTxCheck(cc, rpc, 101)
+	require.Nil(t, cc.replaceIfStuck(ctx, stuck))
+	require.Empty(t, rpc.broadcasts)
+	require.Zero(t, stuck.replacements)
+}
+
+func TestReplaceIfStuckFeeBudget(t *testing.T) {
+	ctx := context.Background()
+	// the extra fees of the replacement, 500uatom, exceed the budget left after 600uatom spent.
+	cc, rpc, stuck := newStuckTxProvider(t, StuckTxReplacement{AfterBlocks: 1}, []FeeBudget{{Window: "day", MaxFees: "1000uatom"}}, 5)
+	cc.feeBudgets.record("default", sdk.NewCoins(sdk.NewInt64Coin("uatom", 600)), time.Now())
+
+	require.Nil(t, cc.replaceIfStuck(ctx, stuck))
+	nextStuckTxCheck(cc, rpc, 101)
+	require.Nil(t, cc.replaceIfStuck(ctx, stuck))
+	require.Empty(t, rpc.broadcasts)
+	require.Equal(t, stuck.replacements, defaultStuckTxMaxReplacements, "no more replacements once the budget is exceeded")
+}
+
+func TestStuckTxLatestHeightShared(t *testing.T) {
+	ctx := context.Background()
+	cc, rpc, _ := newStuckTxProvider(t, StuckTxReplacement{}, nil, 5)
+
+	for i := 0; i < 10; i++ {
+		height, err := cc.stuckTxLatestHeight(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(100), height)
+	}
+	require.Equal(t, 1, rpc.statusQueries, "the latest height should be queried once per check")
+}
+
+func TestWaitForBlockInclusionReplacement(t *testing.T) {
+	ctx := context.Background()
+	cc, rpc, stuck := newStuckTxProvider(t, StuckTxReplacement{AfterBlocks: 1}, nil, 5)
+
+	// the chain advances a block between the checks for stuck transactions.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for height := int64(101); ; height++ {
+			select {
+			case <-done:
+				return
+			case <-time.After(stuckTxCheckInterval):
+				rpc.setHeight(height)
+				rpc.includeBroadcasts()
+			}
+		}
+	}()
+
+	res, err := cc.waitForBlockInclusion(ctx, []byte("stuck"), stuck, 10*time.Second)
+	require.NoError(t, err)
+	require.Equal(t, 1, stuck.replacements)
+	require.Len(t, rpc.broadcasts, 1)
+	require.Equal(t, fmt.Sprintf("%X", tmhash.Sum(rpc.broadcasts[0])), res.TxHash, "the wait ends when the replacement is included")
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"math/big"
 	"regexp"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -27,6 +26,7 @@ import (
 	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
 	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
 	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	feetypes "github.com/cosmos/ibc-go/v7/modules/apps/29-fee/types"
 	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
 	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
 	conntypes "github.com/cosmos/ibc-go/v7/modules/core/03-connection/types"
@@ -81,6 +81,33 @@ func (cc *CosmosProvider) SendMessage(ctx context.Context, msg provider.RelayerM
 // of that transaction will be logged. A boolean indicating if a transaction was successfully
 // sent and executed successfully is returned.
 func (cc *CosmosProvider) SendMessages(ctx context.Context, msgs []provider.RelayerMessage, memo string) (*provider.RelayerTxResponse, bool, error) {
+	return cc.sendMessages(ctx, msgs, memo, cc.SendMessagesToMempool)
+}
+
+// SendMessagesWithKey is like SendMessages, but signs the transaction with the named key
+// instead of the keys configured for the chain. No fee granter is used if it is the fee granter's key.
+func (cc *CosmosProvider) SendMessagesWithKey(ctx context.Context, keyName string, msgs []provider.RelayerMessage, memo string) (*provider.RelayerTxResponse, bool, error) {
+	return cc.sendMessages(ctx, msgs, memo, func(
+		ctx context.Context,
+		msgs []provider.RelayerMessage,
+		memo string,
+		asyncCtx context.Context,
+		asyncCallback func(*provider.RelayerTxResponse, error),
+	) error {
+		key := cc.lockSigningKey(keyName)
+		defer key.mu.Unlock()
+		return cc.sendMessagesToMempool(ctx, key, msgs, memo, asyncCtx, asyncCallback)
+	})
+}
+
+// sendMessages sends the msgs to the mempool with send, retrying on errors, and waits for the transaction
+// to be included in a block.
+func (cc *CosmosProvider) sendMessages(
+	ctx context.Context,
+	msgs []provider.RelayerMessage,
+	memo string,
+	send func(context.Context, []provider.RelayerMessage, string, context.Context, func(*provider.RelayerTxResponse, error)) error,
+) (*provider.RelayerTxResponse, bool, error) {
 	var (
 		rlyResp     *provider.RelayerTxResponse
 		callbackErr error
@@ -96,7 +123,13 @@ func (cc *CosmosProvider) SendMessages(ctx context.Context, msgs []provider.Rela
 	wg.Add(1)
 
 	if err := retry.Do(func() error {
-		return cc.SendMessagesToMempool(ctx, msgs, memo, ctx, callback)
+		err := send(ctx, msgs, memo, ctx, callback)
+		var budgetErr *provider.FeeBudgetExceededError
+		if errors.As(err, &budgetErr) {
+			// retrying cannot succeed until the budget window rolls over.
+			return retry.Unrecoverable(err)
+		}
+		return err
 	}, retry.Context(ctx), rtyAtt, rtyDel, rtyErr, retry.OnRetry(func(n uint, err error) {
 		cc.log.Info(
 			"Error building or broadcasting transaction",
@@ -134,31 +167,62 @@ func (cc *CosmosProvider) SendMessagesToMempool(
 	asyncCtx context.Context,
 	asyncCallback func(*provider.RelayerTxResponse, error),
 ) error {
-	// Guard against account sequence number mismatch errors by locking for the specific wallet for
-	// the account sequence query all the way through the transaction broadcast success/fail.
-	cc.txMu.Lock()
-	defer cc.txMu.Unlock()
+	key := cc.acquireSigningKey(ctx, msgs)
+	defer key.mu.Unlock()
 
-	txBytes, sequence, fees, err := cc.buildMessages(ctx, msgs, memo)
+	msgs, err := cc.signMsgsWith(key, msgs)
+	if err != nil {
+		return err
+	}
+
+	return cc.sendMessagesToMempool(ctx, key, msgs, memo, asyncCtx, asyncCallback)
+}
+
+// sendMessagesToMempool simulates and broadcasts a transaction signed with the key, which must be locked.
+func (cc *CosmosProvider) sendMessagesToMempool(
+	ctx context.Context,
+	key *signingKey,
+	msgs []provider.RelayerMessage,
+	memo string,
+
+	asyncCtx context.Context,
+	asyncCallback func(*provider.RelayerTxResponse, error),
+) error {
+	txBytes, sequence, gas, fees, err := cc.buildMessages(ctx, key, msgs, memo)
 	if err != nil {
 		// Account sequence mismatch errors can happen on the simulated transaction also.
 		if strings.Contains(err.Error(), sdkerrors.ErrWrongSequence.Error()) {
-			cc.handleAccountSequenceMismatchError(err)
+			key.handleAccountSequenceMismatchError(err)
 		}
 
 		return err
 	}
 
-	if err := cc.broadcastTx(ctx, txBytes, msgs, fees, asyncCtx, defaultBroadcastWaitTimeout, asyncCallback); err != nil {
+	if cc.feeBudgets != nil {
+		if err := cc.feeBudgets.check(key.name, fees, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	var stuck *stuckTx
+	if cc.PCfg.StuckTxReplacement != nil {
+		stuck = &stuckTx{key: key.name, msgs: msgs, memo: memo, sequence: sequence, gas: gas, fees: fees}
+	}
+
+	if err := cc.broadcastTx(ctx, txBytes, msgs, key.name, fees, stuck, asyncCtx, defaultBroadcastWaitTimeout, asyncCallback); err != nil {
 		if strings.Contains(err.Error(), sdkerrors.ErrWrongSequence.Error()) {
-			cc.handleAccountSequenceMismatchError(err)
+			key.handleAccountSequenceMismatchError(err)
 		}
 
 		return err
 	}
 
 	// we had a successful tx broadcast with this sequence, so update it to the next
-	cc.updateNextAccountSequence(sequence + 1)
+	key.updateNextAccountSequence(sequence + 1)
+
+	if cc.feeBudgets != nil {
+		cc.feeBudgets.record(key.name, fees, time.Now())
+	}
 
 	return nil
 }
@@ -181,7 +245,9 @@ func (cc *CosmosProvider) broadcastTx(
 	ctx context.Context, // context for tx broadcast
 	tx []byte, // raw tx to be broadcasted
 	msgs []provider.RelayerMessage, // used for logging only
+	key string, // used for metrics
 	fees sdk.Coins, // used for metrics
+	stuck *stuckTx, // used to replace the tx if it is stuck in the mempool, nil to disable
 
 	asyncCtx context.Context, // context for async wait for block inclusion after successful tx broadcast
 	asyncTimeout time.Duration, // timeout for waiting for block inclusion
@@ -207,17 +273,18 @@ func (cc *CosmosProvider) broadcastTx(
 			if err == nil {
 				err = fmt.Errorf("transaction failed to execute")
 			}
+			cc.handleOutOfGas(msgs, err)
 		}
 		cc.LogFailedTx(rlyResp, err, msgs)
 		return err
 	}
 
-	cc.UpdateFeesSpent(cc.ChainId(), cc.Key(), fees)
+	cc.UpdateFeesSpent(cc.ChainId(), key, fees)
 
 	// TODO: maybe we need to check if the node has tx indexing enabled?
 	// if not, we need to find a new way to block until inclusion in a block
 
-	go cc.waitForTx(asyncCtx, res.Hash, msgs, asyncTimeout, asyncCallback)
+	go cc.waitForTx(asyncCtx, res.Hash, msgs, stuck, asyncTimeout, asyncCallback)
 
 	return nil
 }
@@ -228,10 +295,11 @@ func (cc *CosmosProvider) waitForTx(
 	ctx context.Context,
 	txHash []byte,
 	msgs []provider.RelayerMessage, // used for logging only
+	stuck *stuckTx,
 	waitTimeout time.Duration,
 	callback func(*provider.RelayerTxResponse, error),
 ) {
-	res, err := cc.waitForBlockInclusion(ctx, txHash, waitTimeout)
+	res, err := cc.waitForBlockInclusion(ctx, txHash, stuck, waitTimeout)
 	if err != nil {
 		cc.log.Error("Failed to wait for block inclusion", zap.Error(err))
 		if callback != nil {
@@ -259,6 +327,7 @@ func (cc *CosmosProvider) waitForTx(
 		if err == nil {
 			err = fmt.Errorf("transaction failed to execute")
 		}
+		cc.handleOutOfGas(msgs, err)
 		if callback != nil {
 			callback(nil, err)
 		}
@@ -266,6 +335,10 @@ func (cc *CosmosProvider) waitForTx(
 		return
 	}
 
+	if cc.gasModel != nil && res.GasUsed > 0 {
+		cc.gasModel.record(CosmosMsgs(msgs...), uint64(res.GasUsed))
+	}
+
 	if callback != nil {
 		callback(rlyResp, nil)
 	}
@@ -273,24 +346,42 @@ func (cc *CosmosProvider) waitForTx(
 }
 
 // waitForBlockInclusion will wait for a transaction to be included in a block, up to waitTimeout or context cancellation.
+// If stuck is not nil, the transaction is replaced with a higher fee while it is stuck in the mempool,
+// and the wait ends when either the transaction or one of its replacements is included.
 func (cc *CosmosProvider) waitForBlockInclusion(
 	ctx context.Context,
 	txHash []byte,
+	stuck *stuckTx,
 	waitTimeout time.Duration,
 ) (*sdk.TxResponse, error) {
 	exitAfter := time.After(waitTimeout)
+	txHashes := [][]byte{txHash}
+
+	var checkStuck <-chan time.Time
+	if stuck != nil {
+		ticker := time.NewTicker(stuckTxCheckInterval)
+		defer ticker.Stop()
+		checkStuck = ticker.C
+	}
+
 	for {
 		select {
 		case <-exitAfter:
 			return nil, fmt.Errorf("timed out after: %d; %w", waitTimeout, ErrTimeoutAfterWaitingForTxBroadcast)
 		// This fixed poll is fine because it's only for logging and updating prometheus metrics currently.
 		case <-time.After(time.Millisecond * 100):
-			res, err := cc.RPCClient.Tx(ctx, txHash, false)
-			if err == nil {
-				return cc.mkTxResult(res)
+			for _, hash := range txHashes {
+				res, err := cc.RPCClient.Tx(ctx, hash, false)
+				if err == nil {
+					return cc.mkTxResult(res)
+				}
+				if strings.Contains(err.Error(), "transaction indexing is disabled") {
+					return nil, fmt.Errorf("cannot determine success/failure of tx because transaction indexing is disabled on rpc url")
+				}
 			}
-			if strings.Contains(err.Error(), "transaction indexing is disabled") {
-				return nil, fmt.Errorf("cannot determine success/failure of tx because transaction indexing is disabled on rpc url")
+		case <-checkStuck:
+			if hash := cc.replaceIfStuck(ctx, stuck); hash != nil {
+				txHashes = append(txHashes, hash)
 			}
 		case <-ctx.Done():
 			return nil, ctx.Err()
@@ -334,21 +425,17 @@ func parseEventsFromTxResponse(resp *sdk.TxResponse) []provider.RelayerEvent {
 	return events
 }
 
-func (cc *CosmosProvider) buildMessages(ctx context.Context, msgs []provider.RelayerMessage, memo string) ([]byte, uint64, sdk.Coins, error) {
+func (cc *CosmosProvider) buildMessages(ctx context.Context, key *signingKey, msgs []provider.RelayerMessage, memo string) ([]byte, uint64, uint64, sdk.Coins, error) {
 	// Query account details
-	txf, err := cc.PrepareFactory(cc.TxFactory())
+	txf, err := cc.keyTxFactory(key.name, memo)
 	if err != nil {
-		return nil, 0, sdk.Coins{}, err
-	}
-
-	if memo != "" {
-		txf = txf.WithMemo(memo)
+		return nil, 0, 0, sdk.Coins{}, err
 	}
 
 	sequence := txf.Sequence()
-	cc.updateNextAccountSequence(sequence)
-	if sequence < cc.nextAccountSeq {
-		sequence = cc.nextAccountSeq
+	key.updateNextAccountSequence(sequence)
+	if sequence < key.nextAccountSeq {
+		sequence = key.nextAccountSeq
 		txf = txf.WithSequence(sequence)
 	}
 
@@ -356,15 +443,62 @@ func (cc *CosmosProvider) buildMessages(ctx context.Context, msgs []provider.Rel
 	// TODO: This is related to GRPC client stuff?
 	// https://github.com/cosmos/cosmos-sdk/blob/5725659684fc93790a63981c653feee33ecf3225/client/tx/tx.go#L297
 	// If users pass gas adjustment, then calculate gas
-	_, adjusted, err := cc.CalculateGas(ctx, txf, CosmosMsgs(msgs...)...)
+	adjusted, err := cc.estimateGas(ctx, txf, key.name, CosmosMsgs(msgs...))
 	if err != nil {
-		return nil, 0, sdk.Coins{}, err
+		return nil, 0, 0, sdk.Coins{}, err
+	}
+
+	if err := cc.checkBlockGasLimit(ctx, adjusted); err != nil {
+		return nil, 0, 0, sdk.Coins{}, err
 	}
 
 	// Set the gas amount on the transaction factory
 	txf = txf.WithGas(adjusted)
 
-	var txb client.TxBuilder
+	txBytes, fees, err := cc.signTx(ctx, txf, key.name, msgs)
+	if err != nil {
+		return nil, 0, 0, sdk.Coins{}, err
+	}
+
+	return txBytes, sequence, adjusted, fees, nil
+}
+
+// keyTxFactory returns the tx factory for a transaction signed with the key, with the account details
+// of the key, the memo and the fee granter.
+func (cc *CosmosProvider) keyTxFactory(keyName, memo string) (tx.Factory, error) {
+	txf, err := cc.prepareFactory(cc.TxFactory(), keyName)
+	if err != nil {
+		return tx.Factory{}, err
+	}
+
+	if memo != "" {
+		txf = txf.WithMemo(memo)
+	}
+
+	if cc.PCfg.FeeGranter != "" {
+		granter, err := cc.DecodeBech32AccAddr(cc.PCfg.FeeGranter)
+		if err != nil {
+			return tx.Factory{}, fmt.Errorf("invalid fee granter: %w", err)
+		}
+		signer, err := cc.keyAddress(keyName)
+		if err != nil {
+			return tx.Factory{}, err
+		}
+		// the fee granter pays its own fees.
+		if !granter.Equals(signer) {
+			txf = txf.WithFeeGranter(granter)
+		}
+	}
+
+	return txf, nil
+}
+
+// signTx builds, signs and encodes a transaction with the msgs, returning the transaction bytes and its fees.
+func (cc *CosmosProvider) signTx(ctx context.Context, txf tx.Factory, keyName string, msgs []provider.RelayerMessage) ([]byte, sdk.Coins, error) {
+	var (
+		txb client.TxBuilder
+		err error
+	)
 	// Build the transaction builder & retry on failures
 	if err := retry.Do(func() error {
 		txb, err = txf.BuildUnsignedTx(CosmosMsgs(msgs...)...)
@@ -373,18 +507,18 @@ func (cc *CosmosProvider) buildMessages(ctx context.Context, msgs []provider.Rel
 		}
 		return nil
 	}, retry.Context(ctx), rtyAtt, rtyDel, rtyErr); err != nil {
-		return nil, 0, sdk.Coins{}, err
+		return nil, sdk.Coins{}, err
 	}
 
 	done := cc.SetSDKContext()
 
 	if err := retry.Do(func() error {
-		if err := tx.Sign(txf, cc.PCfg.Key, txb, false); err != nil {
+		if err := tx.Sign(txf, keyName, txb, false); err != nil {
 			return err
 		}
 		return nil
 	}, retry.Context(ctx), rtyAtt, rtyDel, rtyErr); err != nil {
-		return nil, 0, sdk.Coins{}, err
+		return nil, sdk.Coins{}, err
 	}
 
 	done()
@@ -402,25 +536,10 @@ func (cc *CosmosProvider) buildMessages(ctx context.Context, msgs []provider.Rel
 		}
 		return nil
 	}, retry.Context(ctx), rtyAtt, rtyDel, rtyErr); err != nil {
-		return nil, 0, sdk.Coins{}, err
+		return nil, sdk.Coins{}, err
 	}
 
-	return txBytes, sequence, fees, nil
-}
-
-// handleAccountSequenceMismatchError will parse the error string, e.g.:
-// "account sequence mismatch, expected 10, got 9: incorrect account sequence"
-// and update the next account sequence with the expected value.
-func (cc *CosmosProvider) handleAccountSequenceMismatchError(err error) {
-	sequences := numRegex.FindAllString(err.Error(), -1)
-	if len(sequences) != 2 {
-		return
-	}
-	nextSeq, err := strconv.ParseUint(sequences[0], 10, 64)
-	if err != nil {
-		return
-	}
-	cc.nextAccountSeq = nextSeq
+	return txBytes, fees, nil
 }
 
 // MsgCreateClient creates an sdk.Msg to update the client on src with consensus state from dst
@@ -1042,6 +1161,16 @@ func (cc *CosmosProvider) MsgSubmitMisbehaviour(clientID string, misbehaviour ib
 	return NewCosmosMessage(msg), nil
 }
 
+func (cc *CosmosProvider) MsgRegisterPayee(portID, channelID, relayerAddr, payeeAddr string) (provider.RelayerMessage, error) {
+	msg := feetypes.NewMsgRegisterPayee(portID, channelID, relayerAddr, payeeAddr)
+	return NewCosmosMessage(msg), nil
+}
+
+func (cc *CosmosProvider) MsgRegisterCounterpartyPayee(portID, channelID, relayerAddr, counterpartyPayeeAddr string) (provider.RelayerMessage, error) {
+	msg := feetypes.NewMsgRegisterCounterpartyPayee(portID, channelID, relayerAddr, counterpartyPayeeAddr)
+	return NewCosmosMessage(msg), nil
+}
+
 // RelayPacketFromSequence relays a packet with a given seq on src and returns recvPacket msgs, timeoutPacketmsgs and error
 func (cc *CosmosProvider) RelayPacketFromSequence(
 	ctx context.Context,
@@ -1129,12 +1258,21 @@ func (cc *CosmosProvider) AcknowledgementFromSequence(ctx context.Context, dst p
 }
 
 // QueryIBCHeader returns the IBC compatible block header (TendermintIBCHeader) at a specific height.
+// With light client verification enabled, the header is verified by the light client.
 func (cc *CosmosProvider) QueryIBCHeader(ctx context.Context, h int64) (provider.IBCHeader, error) {
 	if h == 0 {
 		return nil, fmt.Errorf("height cannot be 0")
 	}
 
-	lightBlock, err := cc.LightProvider.LightBlock(ctx, h)
+	var (
+		lightBlock *tmtypes.LightBlock
+		err        error
+	)
+	if cc.PCfg.LightClientVerification != nil {
+		lightBlock, err = cc.verifiedLightBlock(ctx, h)
+	} else {
+		lightBlock, err = cc.LightProvider.LightBlock(ctx, h)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -1263,9 +1401,14 @@ func (cc *CosmosProvider) UpdateFeesSpent(chain, key string, fees sdk.Coins) {
 
 	cc.totalFeesMu.Lock()
 	cc.TotalFees = cc.TotalFees.Add(fees...)
+	if cc.keyFees == nil {
+		cc.keyFees = make(map[string]sdk.Coins)
+	}
+	keyFees := cc.keyFees[key].Add(fees...)
+	cc.keyFees[key] = keyFees
 	cc.totalFeesMu.Unlock()
 
-	for _, fee := range cc.TotalFees {
+	for _, fee := range keyFees {
 		// Convert to a big float to get a float64 for metrics
 		f, _ := big.NewFloat(0.0).SetInt(fee.Amount.BigInt()).Float64()
 		cc.metrics.SetFeesSpent(chain, key, fee.GetDenom(), f)
@@ -1274,6 +1417,12 @@ func (cc *CosmosProvider) UpdateFeesSpent(chain, key string, fees sdk.Coins) {
 
 // PrepareFactory mutates the tx factory with the appropriate account number, sequence number, and min gas settings.
 func (cc *CosmosProvider) PrepareFactory(txf tx.Factory) (tx.Factory, error) {
+	return cc.prepareFactory(txf, cc.PCfg.Key)
+}
+
+// prepareFactory mutates the tx factory with the appropriate account number, sequence number, and min gas settings
+// for signing with the named key.
+func (cc *CosmosProvider) prepareFactory(txf tx.Factory, keyName string) (tx.Factory, error) {
 	var (
 		err      error
 		from     sdk.AccAddress
@@ -1282,7 +1431,7 @@ func (cc *CosmosProvider) PrepareFactory(txf tx.Factory) (tx.Factory, error) {
 
 	// Get key address and retry if fail
 	if err = retry.Do(func() error {
-		from, err = cc.GetKeyAddress()
+		from, err = cc.keyAddress(keyName)
 		if err != nil {
 			return err
 		}
@@ -1337,7 +1486,12 @@ func (cc *CosmosProvider) PrepareFactory(txf tx.Factory) (tx.Factory, error) {
 
 // CalculateGas simulates a tx to generate the appropriate gas settings before broadcasting a tx.
 func (cc *CosmosProvider) CalculateGas(ctx context.Context, txf tx.Factory, msgs ...sdk.Msg) (txtypes.SimulateResponse, uint64, error) {
-	keyInfo, err := cc.Keybase.Key(cc.PCfg.Key)
+	return cc.calculateGas(ctx, txf, cc.PCfg.Key, msgs...)
+}
+
+// calculateGas simulates a tx signed with the named key to generate the appropriate gas settings.
+func (cc *CosmosProvider) calculateGas(ctx context.Context, txf tx.Factory, keyName string, msgs ...sdk.Msg) (txtypes.SimulateResponse, uint64, error) {
+	keyInfo, err := cc.Keybase.Key(keyName)
 	if err != nil {
 		return txtypes.SimulateResponse{}, 0, err
 	}
@@ -1386,7 +1540,7 @@ func (cc *CosmosProvider) TxFactory() tx.Factory {
 		WithChainID(cc.PCfg.ChainID).
 		WithTxConfig(cc.Cdc.TxConfig).
 		WithGasAdjustment(cc.PCfg.GasAdjustment).
-		WithGasPrices(cc.PCfg.GasPrices).
+		WithGasPrices(cc.gasPrices()).
 		WithKeybase(cc.Keybase).
 		WithSignMode(cc.PCfg.SignMode())
 }
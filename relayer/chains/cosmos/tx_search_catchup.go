@@ -0,0 +1,188 @@
+package cosmos
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+	conntypes "github.com/cosmos/ibc-go/v7/modules/core/03-connection/types"
+	chantypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/cosmos/relayer/v2/relayer/processor"
+	"go.uber.org/zap"
+)
+
+const (
+	// CatchUpStrategyBlocks catches up by querying every block, the default.
+	CatchUpStrategyBlocks = "blocks"
+
+	// CatchUpStrategyTxSearch catches up by searching for the transactions with IBC events
+	// of the relevant clients, connections and channels.
+	CatchUpStrategyTxSearch = "tx-search"
+
+	// Minimum number of blocks behind the latest height to catch up with tx_search instead of querying every block.
+	txSearchCatchUpMinBlocks = 20
+
+	txSearchPerPage      = 100
+	txSearchQueryTimeout = time.Minute
+)
+
+// clientEventTypes are the client and connection handshake events which are searched for each relevant client.
+var clientEventTypes = []string{
+	clienttypes.EventTypeUpdateClient,
+	conntypes.EventTypeConnectionOpenInit,
+	conntypes.EventTypeConnectionOpenTry,
+	conntypes.EventTypeConnectionOpenAck,
+	conntypes.EventTypeConnectionOpenConfirm,
+}
+
+// channelEventTypes are the channel handshake events which are searched for each relevant connection.
+var channelEventTypes = []string{
+	chantypes.EventTypeChannelOpenInit,
+	chantypes.EventTypeChannelOpenTry,
+	chantypes.EventTypeChannelOpenAck,
+	chantypes.EventTypeChannelOpenConfirm,
+	chantypes.EventTypeChannelCloseInit,
+	chantypes.EventTypeChannelCloseConfirm,
+	chantypes.EventTypeChannelClosed,
+}
+
+// packetEventTypes are the packet events which are searched for each relevant connection.
+var packetEventTypes = []string{
+	chantypes.EventTypeSendPacket,
+	chantypes.EventTypeRecvPacket,
+	chantypes.EventTypeWriteAck,
+	chantypes.EventTypeAcknowledgePacket,
+	chantypes.EventTypeTimeoutPacket,
+	chantypes.EventTypeTimeoutPacketOnClose,
+}
+
+// useTxSearch returns true if the blocks from the latest queried block up to the latest height
+// should be caught up with tx_search instead of querying every block.
+func (ccp *CosmosChainProcessor) useTxSearch(persistence *queryCyclePersistence) bool {
+	return ccp.chainProvider.PCfg.CatchUpStrategy == CatchUpStrategyTxSearch &&
+		!ccp.txSearchUnavailable &&
+		persistence.latestHeight-persistence.latestQueriedBlock > txSearchCatchUpMinBlocks
+}
+
+// txSearchQueries returns the tx_search queries for the IBC events of the clients of the path processors,
+// and of their connections and channels, from the height from up to the height to.
+// It returns nil if there are no relevant clients yet.
+func (ccp *CosmosChainProcessor) txSearchQueries(from, to int64) []string {
+	chainID := ccp.chainProvider.ChainId()
+	clients := make(map[string]bool)
+	for _, pp := range ccp.pathProcessors {
+		if clientID := pp.RelevantClientID(chainID); clientID != "" {
+			clients[clientID] = true
+		}
+	}
+
+	heights := fmt.Sprintf("tx.height>=%d AND tx.height<=%d", from, to)
+	var queries []string
+	for _, clientID := range sortedKeys(clients) {
+		for _, eventType := range clientEventTypes {
+			queries = append(queries, fmt.Sprintf("%s.%s='%s' AND %s", eventType, conntypes.AttributeKeyClientID, clientID, heights))
+		}
+	}
+
+	connections := make(map[string]bool)
+	for connectionID, clientID := range ccp.connectionClients {
+		if clients[clientID] {
+			connections[connectionID] = true
+		}
+	}
+	for _, connectionID := range sortedKeys(connections) {
+		for _, eventType := range channelEventTypes {
+			queries = append(queries, fmt.Sprintf("%s.%s='%s' AND %s", eventType, chantypes.AttributeKeyConnectionID, connectionID, heights))
+		}
+		for _, eventType := range packetEventTypes {
+			queries = append(queries, fmt.Sprintf("%s.%s='%s' AND %s", eventType, chantypes.AttributeKeyConnection, connectionID, heights))
+		}
+	}
+	return queries
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// catchUpWithTxSearch handles the IBC messages of the relevant transactions from the height from up to the height to,
+// found with tx_search, in the order they were executed. Events emitted outside of transactions,
+// in begin and end block, are not found. If the node has tx indexing disabled, tx_search is not tried again.
+func (ccp *CosmosChainProcessor) catchUpWithTxSearch(ctx context.Context, from, to int64, ibcMessagesCache processor.IBCMessagesCache) error {
+	queries := ccp.txSearchQueries(from, to)
+	if len(queries) == 0 {
+		return fmt.Errorf("no relevant clients to search for")
+	}
+
+	txs := make(map[string]*ctypes.ResultTx)
+	for _, query := range queries {
+		if err := ccp.txSearch(ctx, query, txs); err != nil {
+			if strings.Contains(err.Error(), "indexing is disabled") {
+				ccp.txSearchUnavailable = true
+				ccp.log.Warn("Tx indexing is disabled on the node, catching up by querying every block instead")
+			}
+			return err
+		}
+	}
+
+	sorted := make([]*ctypes.ResultTx, 0, len(txs))
+	for _, tx := range txs {
+		sorted = append(sorted, tx)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Height != sorted[j].Height {
+			return sorted[i].Height < sorted[j].Height
+		}
+		return sorted[i].Index < sorted[j].Index
+	})
+
+	chainID := ccp.chainProvider.ChainId()
+	base64Encoded := ccp.chainProvider.cometLegacyEncoding
+	for _, tx := range sorted {
+		if tx.TxResult.Code != 0 {
+			// tx was not successful
+			continue
+		}
+		messages := ibcMessagesFromEvents(ccp.log, tx.TxResult.Events, chainID, uint64(tx.Height), base64Encoded)
+		for _, m := range messages {
+			ccp.handleMessage(ctx, m, ibcMessagesCache)
+		}
+	}
+
+	ccp.log.Info("Caught up with tx_search",
+		zap.Int64("from_height", from),
+		zap.Int64("to_height", to),
+		zap.Int("queries", len(queries)),
+		zap.Int("txs", len(sorted)),
+	)
+	return nil
+}
+
+// txSearch adds the transactions matching the query to txs, by hash, querying every page of results.
+func (ccp *CosmosChainProcessor) txSearch(ctx context.Context, query string, txs map[string]*ctypes.ResultTx) error {
+	perPage := txSearchPerPage
+	for page, found := 1, 0; ; page++ {
+		queryCtx, cancelQueryCtx := context.WithTimeout(ctx, txSearchQueryTimeout)
+		res, err := ccp.chainProvider.RPCClient.TxSearch(queryCtx, query, false, &page, &perPage, "asc")
+		cancelQueryCtx()
+		if err != nil {
+			return err
+		}
+		for _, tx := range res.Txs {
+			txs[tx.Hash.String()] = tx
+		}
+		found += len(res.Txs)
+		if len(res.Txs) == 0 || found >= res.TotalCount {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package cosmos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cosmos/relayer/v2/relayer/processor"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func testTxSearchChainProcessor(t *testing.T, rpcErr string) *CosmosChainProcessor {
+	var requests int32
+	rpc := jsonRPCServer(t, `{"txs":[],"total_count":"0"}`, rpcErr, &requests)
+	t.Cleanup(rpc.Close)
+
+	pool, err := newRPCPool(zap.NewNop(), "test-1", []string{rpc.URL}, time.Second)
+	require.NoError(t, err)
+	pool.lastHealthCheck = time.Now()
+
+	cc := &CosmosProvider{
+		log:       zap.NewNop(),
+		PCfg:      CosmosProviderConfig{ChainID: "test-1", CatchUpStrategy: CatchUpStrategyTxSearch},
+		RPCClient: pool,
+	}
+	ccp := NewCosmosChainProcessor(zap.NewNop(), cc, nil)
+	ccp.pathProcessors = processor.PathProcessors{processor.NewPathProcessor(
+		zap.NewNop(),
+		processor.NewPathEnd("path", "test-1", "07-tendermint-0", "", nil),
+		processor.NewPathEnd("path", "test-2", "07-tendermint-1", "", nil),
+		nil, "", 0, 0,
+	)}
+	ccp.connectionClients["connection-0"] = "07-tendermint-0"
+	ccp.connectionClients["connection-1"] = "07-tendermint-5"
+	return ccp
+}
+
+func TestTxSearchQueries(t *testing.T) {
+	ccp := testTxSearchChainProcessor(t, "")
+
+	queries := ccp.txSearchQueries(10, 20)
+	require.Len(t, queries, len(clientEventTypes)+len(channelEventTypes)+len(packetEventTypes))
+	require.Contains(t, queries, "update_client.client_id='07-tendermint-0' AND tx.height>=10 AND tx.height<=20")
+	require.Contains(t, queries, "channel_open_init.connection_id='connection-0' AND tx.height>=10 AND tx.height<=20")
+	require.Contains(t, queries, "send_packet.packet_connection='connection-0' AND tx.height>=10 AND tx.height<=20")
+	for _, q := range queries {
+		require.NotContains(t, q, "connection-1")
+	}
+}
+
+func TestCatchUpWithTxSearch(t *testing.T) {
+	ccp := testTxSearchChainProcessor(t, "")
+	persistence := &queryCyclePersistence{latestQueriedBlock: 10, latestHeight: 100}
+
+	require.True(t, ccp.useTxSearch(persistence))
+	require.NoError(t, ccp.catchUpWithTxSearch(context.Background(), 11, 99, processor.NewIBCMessagesCache()))
+	require.True(t, ccp.useTxSearch(persistence))
+
+	persistence.latestQueriedBlock = 90
+	require.False(t, ccp.useTxSearch(persistence), "scan the blocks when only a few are behind")
+}
+
+func TestCatchUpWithTxSearchIndexingDisabled(t *testing.T) {
+	ccp := testTxSearchChainProcessor(t, "transaction indexing is disabled")
+	persistence := &queryCyclePersistence{latestQueriedBlock: 10, latestHeight: 100}
+
+	require.Error(t, ccp.catchUpWithTxSearch(context.Background(), 11, 99, processor.NewIBCMessagesCache()))
+	require.False(t, ccp.useTxSearch(persistence), "fall back to scanning blocks")
+}
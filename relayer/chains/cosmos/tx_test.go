@@ -17,7 +17,7 @@ func (err mockAccountSequenceMismatchError) Error() string {
 }
 
 func TestHandleAccountSequenceMismatchError(t *testing.T) {
-	p := &CosmosProvider{}
-	p.handleAccountSequenceMismatchError(mockAccountSequenceMismatchError{Actual: 9, Expected: 10})
-	require.Equal(t, p.nextAccountSeq, uint64(10))
+	k := &signingKey{}
+	k.handleAccountSequenceMismatchError(mockAccountSequenceMismatchError{Actual: 9, Expected: 10})
+	require.Equal(t, k.nextAccountSeq, uint64(10))
 }
@@ -0,0 +1,226 @@
+package relayer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"go.uber.org/zap"
+)
+
+// How often the clients are checked in clients-only mode.
+const clientKeepaliveInterval = time.Minute
+
+// keepaliveClient is a client on the host chain which tracks the counterparty chain.
+// The PathEnd of the host is the path end of the client.
+type keepaliveClient struct {
+	host, counterparty *Chain
+}
+
+// keepaliveClients returns the clients on both ends of the paths, without duplicates.
+func keepaliveClients(chains map[string]*Chain, paths []NamedPath) []keepaliveClient {
+	var clients []keepaliveClient
+	seen := make(map[string]bool)
+	add := func(host, counterparty *PathEnd) {
+		key := host.ChainID + "/" + host.ClientID
+		if host.ClientID == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+
+		// copies of the chains, so that each client has its own path end.
+		h, c := *chains[host.ChainID], *chains[counterparty.ChainID]
+		h.PathEnd, c.PathEnd = host, counterparty
+		clients = append(clients, keepaliveClient{host: &h, counterparty: &c})
+	}
+	for _, np := range paths {
+		add(np.Path.Src, np.Path.Dst)
+		add(np.Path.Dst, np.Path.Src)
+	}
+	return clients
+}
+
+// clientUpdateDue returns true if a client whose latest consensus state is from the elapsed time ago should be updated:
+// after two thirds of its trusting period, or after the threshold if it is not zero, the same as when relaying.
+func clientUpdateDue(elapsed, trustingPeriod, threshold time.Duration) bool {
+	if trustingPeriod > 0 && elapsed > trustingPeriod*2/3 {
+		return true
+	}
+	return threshold > 0 && elapsed > threshold
+}
+
+// StartClientKeepalive keeps the clients of the paths from expiring, without relaying packets.
+// Every minute, each client which is due for an update, see clientUpdateDue, is updated,
+// with the updates for clients on the same chain sent in one transaction of up to maxMsgs messages.
+func StartClientKeepalive(
+	ctx context.Context,
+	log *zap.Logger,
+	chains map[string]*Chain,
+	paths []NamedPath,
+	maxMsgs uint64,
+	memo string,
+	clientUpdateThresholdTime time.Duration,
+) chan error {
+	errorChan := make(chan error, 1)
+	clients := keepaliveClients(chains, paths)
+
+	go func() {
+		defer close(errorChan)
+
+		log.Info("Keeping clients alive", zap.Int("clients", len(clients)))
+
+		ticker := time.NewTicker(clientKeepaliveInterval)
+		defer ticker.Stop()
+		for {
+			updateDueClients(ctx, log, clients, maxMsgs, memo, clientUpdateThresholdTime)
+
+			select {
+			case <-ctx.Done():
+				errorChan <- ctx.Err()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return errorChan
+}
+
+// updateDueClients updates the clients which are due for an update, batched by the chain they are on.
+func updateDueClients(
+	ctx context.Context,
+	log *zap.Logger,
+	clients []keepaliveClient,
+	maxMsgs uint64,
+	memo string,
+	clientUpdateThresholdTime time.Duration,
+) {
+	due := make(map[string][]keepaliveClient)
+	for _, c := range clients {
+		ok, err := keepaliveClientDue(ctx, log, c, clientUpdateThresholdTime)
+		if err != nil {
+			log.Warn("Failed to check whether client needs an update",
+				zap.String("chain_id", c.host.ChainID()),
+				zap.String("client_id", c.host.ClientID()),
+				zap.Error(err),
+			)
+			continue
+		}
+		if ok {
+			due[c.host.ChainID()] = append(due[c.host.ChainID()], c)
+		}
+	}
+
+	chainIDs := make([]string, 0, len(due))
+	for chainID := range due {
+		chainIDs = append(chainIDs, chainID)
+	}
+	sort.Strings(chainIDs)
+
+	for _, chainID := range chainIDs {
+		updateKeepaliveClients(ctx, log, due[chainID], maxMsgs, memo)
+	}
+}
+
+// keepaliveClientDue returns true if the client should be updated. Expired clients can not be updated,
+// and are only logged.
+func keepaliveClientDue(ctx context.Context, log *zap.Logger, c keepaliveClient, clientUpdateThresholdTime time.Duration) (bool, error) {
+	height, err := c.host.ChainProvider.QueryLatestHeight(ctx)
+	if err != nil {
+		return false, err
+	}
+	clientStateRes, err := c.host.ChainProvider.QueryClientStateResponse(ctx, height, c.host.ClientID())
+	if err != nil {
+		return false, err
+	}
+	clientInfo, err := ClientInfoFromClientState(clientStateRes.ClientState)
+	if err != nil {
+		return false, err
+	}
+	consensusTime, err := c.counterparty.ChainProvider.BlockTime(ctx, int64(clientInfo.LatestHeight.GetRevisionHeight()))
+	if err != nil {
+		return false, err
+	}
+
+	elapsed := time.Since(consensusTime)
+	if clientInfo.TrustingPeriod > 0 && elapsed >= clientInfo.TrustingPeriod {
+		log.Error("Client is expired and can not be updated",
+			zap.String("chain_id", c.host.ChainID()),
+			zap.String("client_id", c.host.ClientID()),
+			zap.Time("expired_at", consensusTime.Add(clientInfo.TrustingPeriod)),
+		)
+		return false, nil
+	}
+	return clientUpdateDue(elapsed, clientInfo.TrustingPeriod, clientUpdateThresholdTime), nil
+}
+
+// updateKeepaliveClients updates the clients, which must all be on the same chain,
+// in transactions of up to maxMsgs messages, or a single transaction if maxMsgs is zero.
+// A client whose update can not be built is skipped, and a failed transaction does not stop
+// the remaining transactions, so that one failing client does not keep the others from being updated.
+func updateKeepaliveClients(ctx context.Context, log *zap.Logger, clients []keepaliveClient, maxMsgs uint64, memo string) {
+	host := clients[0].host
+
+	var (
+		msgs      []provider.RelayerMessage
+		clientIDs []string
+	)
+	for _, c := range clients {
+		msg, err := keepaliveClientUpdate(ctx, c)
+		if err != nil {
+			log.Warn("Failed to build client update, skipping client",
+				zap.String("chain_id", c.host.ChainID()),
+				zap.String("client_id", c.host.ClientID()),
+				zap.Error(err),
+			)
+			continue
+		}
+		msgs = append(msgs, msg)
+		clientIDs = append(clientIDs, c.host.ClientID())
+	}
+
+	batchSize := len(msgs)
+	if maxMsgs > 0 && int(maxMsgs) < batchSize {
+		batchSize = int(maxMsgs)
+	}
+	for start := 0; start < len(msgs); start += batchSize {
+		end := start + batchSize
+		if end > len(msgs) {
+			end = len(msgs)
+		}
+		batch := msgs[start:end]
+
+		res, success, err := host.ChainProvider.SendMessages(ctx, batch, memo)
+		if err != nil || !success {
+			if res != nil {
+				host.LogFailedTx(res, err, batch)
+			}
+			if err == nil {
+				err = fmt.Errorf("tx failed on chain{%s}: %s", host.ChainID(), res.Data)
+			}
+			log.Warn("Failed to update clients",
+				zap.String("chain_id", host.ChainID()),
+				zap.Strings("client_ids", clientIDs[start:end]),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		log.Info("Clients updated",
+			zap.String("chain_id", host.ChainID()),
+			zap.Strings("client_ids", clientIDs[start:end]),
+			zap.String("tx_hash", res.TxHash),
+		)
+	}
+}
+
+// keepaliveClientUpdate builds the MsgUpdateClient for the client at the latest height of its counterparty.
+func keepaliveClientUpdate(ctx context.Context, c keepaliveClient) (provider.RelayerMessage, error) {
+	srch, dsth, err := QueryLatestHeights(ctx, c.counterparty, c.host)
+	if err != nil {
+		return nil, err
+	}
+	return MsgUpdateClient(ctx, c.counterparty, c.host, srch, dsth)
+}
@@ -0,0 +1,58 @@
+package relayer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientUpdateDue(t *testing.T) {
+	const day = 24 * time.Hour
+
+	require.False(t, clientUpdateDue(day, 14*day, 0))
+	require.True(t, clientUpdateDue(10*day, 14*day, 0), "past two thirds of the trusting period")
+	require.False(t, clientUpdateDue(day, 14*day, 2*day))
+	require.True(t, clientUpdateDue(3*day, 14*day, 2*day), "past the threshold")
+	require.False(t, clientUpdateDue(day, 0, 0))
+}
+
+func TestKeepaliveClients(t *testing.T) {
+	chains := map[string]*Chain{
+		"chain-a": {Chainid: "chain-a"},
+		"chain-b": {Chainid: "chain-b"},
+		"chain-c": {Chainid: "chain-c"},
+	}
+	paths := []NamedPath{
+		{Name: "a-b", Path: &Path{
+			Src: &PathEnd{ChainID: "chain-a", ClientID: "07-tendermint-0"},
+			Dst: &PathEnd{ChainID: "chain-b", ClientID: "07-tendermint-0"},
+		}},
+		// another path using the same clients.
+		{Name: "a-b-2", Path: &Path{
+			Src: &PathEnd{ChainID: "chain-b", ClientID: "07-tendermint-0"},
+			Dst: &PathEnd{ChainID: "chain-a", ClientID: "07-tendermint-0"},
+		}},
+		// the client on chain-c has not been created yet.
+		{Name: "a-c", Path: &Path{
+			Src: &PathEnd{ChainID: "chain-a", ClientID: "07-tendermint-1"},
+			Dst: &PathEnd{ChainID: "chain-c"},
+		}},
+	}
+
+	clients := keepaliveClients(chains, paths)
+	require.Len(t, clients, 3)
+
+	var hosts []string
+	for _, c := range clients {
+		hosts = append(hosts, c.host.PathEnd.ChainID+"/"+c.host.ClientID()+"->"+c.counterparty.PathEnd.ChainID)
+	}
+	require.Equal(t, []string{
+		"chain-a/07-tendermint-0->chain-b",
+		"chain-b/07-tendermint-0->chain-a",
+		"chain-a/07-tendermint-1->chain-c",
+	}, hosts)
+
+	// the chains are not modified.
+	require.Nil(t, chains["chain-a"].PathEnd)
+}
@@ -0,0 +1,153 @@
+package relayer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+	ibcexported "github.com/cosmos/ibc-go/v7/modules/core/exported"
+	tmclient "github.com/cosmos/ibc-go/v7/modules/light-clients/07-tendermint"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"go.uber.org/zap"
+)
+
+// CreateSubstituteClient creates a client on src tracking dst, to replace the expired or frozen client of the path end of src
+// through governance. The substitute client has the same parameters as the client it replaces, as required for the
+// client to be recovered, with the latest header of dst as its consensus state. The path end of src is not changed.
+func CreateSubstituteClient(ctx context.Context, src, dst *Chain, memo string) (string, error) {
+	status, err := src.ChainProvider.QueryClientStatus(ctx, src.ClientID())
+	if err != nil {
+		return "", fmt.Errorf("failed to query status of client %s on chain{%s}: %w", src.ClientID(), src.ChainID(), err)
+	}
+	if status != ibcexported.Expired && status != ibcexported.Frozen {
+		return "", fmt.Errorf("client %s on chain{%s} is %s, only expired or frozen clients need to be recovered",
+			src.ClientID(), src.ChainID(), status)
+	}
+
+	srch, dsth, err := QueryLatestHeights(ctx, src, dst)
+	if err != nil {
+		return "", err
+	}
+
+	clientStateRes, err := src.ChainProvider.QueryClientStateResponse(ctx, srch, src.ClientID())
+	if err != nil {
+		return "", err
+	}
+	clientState, err := clienttypes.UnpackClientState(clientStateRes.ClientState)
+	if err != nil {
+		return "", err
+	}
+	subject, ok := clientState.(*tmclient.ClientState)
+	if !ok {
+		return "", fmt.Errorf("unhandled client state type: (%T)", clientState)
+	}
+
+	header, err := dst.ChainProvider.QueryIBCHeader(ctx, dsth)
+	if err != nil {
+		return "", err
+	}
+
+	substitute := *subject
+	substitute.ChainId = dst.ChainID()
+	substitute.LatestHeight = clienttypes.NewHeight(clienttypes.ParseChainID(dst.ChainID()), header.Height())
+	substitute.FrozenHeight = clienttypes.ZeroHeight()
+
+	createMsg, err := src.ChainProvider.MsgCreateClient(&substitute, header.ConsensusState())
+	if err != nil {
+		return "", fmt.Errorf("failed to compose CreateClient msg for chain{%s} tracking the state of chain{%s}: %w",
+			src.ChainID(), dst.ChainID(), err)
+	}
+
+	msgs := []provider.RelayerMessage{createMsg}
+	res, success, err := src.ChainProvider.SendMessages(ctx, msgs, memo)
+	if err != nil {
+		src.LogFailedTx(res, err, msgs)
+		return "", fmt.Errorf("failed to send messages on chain{%s}: %w", src.ChainID(), err)
+	}
+	if !success {
+		src.LogFailedTx(res, nil, msgs)
+		return "", fmt.Errorf("tx failed on chain{%s}: %s", src.ChainID(), res.Data)
+	}
+
+	clientID, err := parseClientIDFromEvents(res.Events)
+	if err != nil {
+		return "", err
+	}
+
+	src.log.Info(
+		"Substitute client created",
+		zap.String("src_chain_id", src.ChainID()),
+		zap.String("subject_client_id", src.ClientID()),
+		zap.String("substitute_client_id", clientID),
+		zap.String("dst_chain_id", dst.ChainID()),
+	)
+
+	return clientID, nil
+}
+
+// clientRecoveryProposal is a governance proposal in the format of the proposal file of `tx gov submit-proposal`.
+type clientRecoveryProposal struct {
+	Messages []any  `json:"messages"`
+	Metadata string `json:"metadata"`
+	Deposit  string `json:"deposit"`
+	Title    string `json:"title"`
+	Summary  string `json:"summary"`
+}
+
+type msgRecoverClient struct {
+	Type               string `json:"@type"`
+	SubjectClientID    string `json:"subject_client_id"`
+	SubstituteClientID string `json:"substitute_client_id"`
+	Signer             string `json:"signer"`
+}
+
+type msgExecLegacyContent struct {
+	Type      string               `json:"@type"`
+	Content   clientUpdateProposal `json:"content"`
+	Authority string               `json:"authority"`
+}
+
+type clientUpdateProposal struct {
+	Type               string `json:"@type"`
+	Title              string `json:"title"`
+	Description        string `json:"description"`
+	SubjectClientID    string `json:"subject_client_id"`
+	SubstituteClientID string `json:"substitute_client_id"`
+}
+
+// ClientRecoveryProposal returns the JSON of a governance proposal to replace the subject client with the substitute client,
+// signed by the authority, the address of the gov module. The proposal contains a ClientUpdateProposal, supported by
+// the ibc-go v7 chains the relayer targets, or with recoverClientMsg set, a MsgRecoverClient for ibc-go v8 and later.
+func ClientRecoveryProposal(subjectClientID, substituteClientID, authority, deposit string, recoverClientMsg bool) ([]byte, error) {
+	title := fmt.Sprintf("Recover IBC client %s", subjectClientID)
+	summary := fmt.Sprintf("Replace the state of the expired or frozen IBC client %s with the state of the active client %s.",
+		subjectClientID, substituteClientID)
+
+	var msg any = msgExecLegacyContent{
+		Type: "/cosmos.gov.v1.MsgExecLegacyContent",
+		Content: clientUpdateProposal{
+			Type:               "/ibc.core.client.v1.ClientUpdateProposal",
+			Title:              title,
+			Description:        summary,
+			SubjectClientID:    subjectClientID,
+			SubstituteClientID: substituteClientID,
+		},
+		Authority: authority,
+	}
+	if recoverClientMsg {
+		msg = msgRecoverClient{
+			Type:               "/ibc.core.client.v1.MsgRecoverClient",
+			SubjectClientID:    subjectClientID,
+			SubstituteClientID: substituteClientID,
+			Signer:             authority,
+		}
+	}
+
+	return json.MarshalIndent(clientRecoveryProposal{
+		Messages: []any{msg},
+		Deposit:  deposit,
+		Title:    title,
+		Summary:  summary,
+	}, "", "  ")
+}
@@ -0,0 +1,46 @@
+package relayer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientRecoveryProposal(t *testing.T) {
+	const authority = "cosmos10d07y265gmmuvt4z0w9aw880jnsr700j6zn9kn"
+
+	proposal, err := ClientRecoveryProposal("07-tendermint-0", "07-tendermint-1", authority, "10000000stake", true)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"messages": [{
+			"@type": "/ibc.core.client.v1.MsgRecoverClient",
+			"subject_client_id": "07-tendermint-0",
+			"substitute_client_id": "07-tendermint-1",
+			"signer": "cosmos10d07y265gmmuvt4z0w9aw880jnsr700j6zn9kn"
+		}],
+		"metadata": "",
+		"deposit": "10000000stake",
+		"title": "Recover IBC client 07-tendermint-0",
+		"summary": "Replace the state of the expired or frozen IBC client 07-tendermint-0 with the state of the active client 07-tendermint-1."
+	}`, string(proposal))
+
+	proposal, err = ClientRecoveryProposal("07-tendermint-0", "07-tendermint-1", authority, "", false)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"messages": [{
+			"@type": "/cosmos.gov.v1.MsgExecLegacyContent",
+			"content": {
+				"@type": "/ibc.core.client.v1.ClientUpdateProposal",
+				"title": "Recover IBC client 07-tendermint-0",
+				"description": "Replace the state of the expired or frozen IBC client 07-tendermint-0 with the state of the active client 07-tendermint-1.",
+				"subject_client_id": "07-tendermint-0",
+				"substitute_client_id": "07-tendermint-1"
+			},
+			"authority": "cosmos10d07y265gmmuvt4z0w9aw880jnsr700j6zn9kn"
+		}],
+		"metadata": "",
+		"deposit": "",
+		"title": "Recover IBC client 07-tendermint-0",
+		"summary": "Replace the state of the expired or frozen IBC client 07-tendermint-0 with the state of the active client 07-tendermint-1."
+	}`, string(proposal))
+}
@@ -0,0 +1,75 @@
+package relayer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cosmos/relayer/v2/relayer/chains/cosmos"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"go.uber.org/zap"
+)
+
+// RegisterPayee registers payeeAddr with the ICS-29 fee middleware on this chain, for the given channel.
+// By default, payeeAddr will receive the ack and timeout fees earned by this chain's relayer keys.
+// When counterparty is true, payeeAddr is instead registered as the counterparty payee, i.e. the address
+// on the counterparty chain which will receive the recv fees for packets relayed to this chain.
+// The fee middleware pays the fees to the address which signed the relayed message, so the payee is
+// registered for the configured key and every key of the key pool.
+func (c *Chain) RegisterPayee(ctx context.Context, portID, channelID, payeeAddr string, counterparty bool, memo string) error {
+	keys := []string{c.ChainProvider.Key()}
+	ccp, isCosmos := c.ChainProvider.(*cosmos.CosmosProvider)
+	if isCosmos {
+		keys = ccp.SigningKeys()
+	}
+
+	for _, key := range keys {
+		relayerAddr, err := c.ChainProvider.ShowAddress(key)
+		if err != nil {
+			return fmt.Errorf("failed to get address of key %s on chain{%s}: %w", key, c.ChainID(), err)
+		}
+
+		var msg provider.RelayerMessage
+		if counterparty {
+			msg, err = c.ChainProvider.MsgRegisterCounterpartyPayee(portID, channelID, relayerAddr, payeeAddr)
+		} else {
+			msg, err = c.ChainProvider.MsgRegisterPayee(portID, channelID, relayerAddr, payeeAddr)
+		}
+		if err != nil {
+			return err
+		}
+
+		msgs := []provider.RelayerMessage{msg}
+
+		var (
+			res     *provider.RelayerTxResponse
+			success bool
+		)
+		if isCosmos {
+			res, success, err = ccp.SendMessagesWithKey(ctx, key, msgs, memo)
+		} else {
+			res, success, err = c.ChainProvider.SendMessages(ctx, msgs, memo)
+		}
+		if err != nil {
+			c.LogFailedTx(res, err, msgs)
+			return fmt.Errorf("failed to send messages on chain{%s}: %w", c.ChainID(), err)
+		}
+		if !success {
+			c.LogFailedTx(res, nil, msgs)
+			return fmt.Errorf("tx failed on chain{%s}: %s", c.ChainID(), res.Data)
+		}
+
+		c.log.Info(
+			"Registered payee",
+			zap.String("chain_id", c.ChainID()),
+			zap.String("port_id", portID),
+			zap.String("channel_id", channelID),
+			zap.String("key", key),
+			zap.String("relayer", relayerAddr),
+			zap.String("payee", payeeAddr),
+			zap.Bool("counterparty", counterparty),
+			zap.String("tx_hash", res.TxHash),
+		)
+	}
+
+	return nil
+}
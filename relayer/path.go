@@ -88,10 +88,18 @@ type PathAction struct {
 
 // Path represents a pair of chains and the identifiers needed to relay over them along with a channel filter list.
 // A Memo can optionally be provided for identification in relayed messages.
+// FeePolicy determines how packets incentivized with the ICS-29 fee middleware are relayed.
+// PacketFilter optionally restricts which ICS-20 packets are relayed based on their packet data.
+// RetryPolicy overrides the global retry policy for the path.
+// ChannelLimits sets the priority and rate limits for the packets of each channel.
 type Path struct {
-	Src    *PathEnd      `yaml:"src" json:"src"`
-	Dst    *PathEnd      `yaml:"dst" json:"dst"`
-	Filter ChannelFilter `yaml:"src-channel-filter" json:"src-channel-filter"`
+	Src           *PathEnd      `yaml:"src" json:"src"`
+	Dst           *PathEnd      `yaml:"dst" json:"dst"`
+	Filter        ChannelFilter `yaml:"src-channel-filter" json:"src-channel-filter"`
+	FeePolicy     string        `yaml:"fee-policy,omitempty" json:"fee-policy,omitempty"`
+	PacketFilter  PacketFilter  `yaml:"packet-filter,omitempty" json:"packet-filter,omitempty"`
+	RetryPolicy   RetryPolicy   `yaml:"retry-policy,omitempty" json:"retry-policy,omitempty"`
+	ChannelLimits ChannelLimits `yaml:"channel-limits,omitempty" json:"channel-limits,omitempty"`
 }
 
 // Named path wraps a Path with its name.
@@ -107,6 +115,48 @@ type ChannelFilter struct {
 	ChannelList []string `yaml:"channel-list" json:"channel-list"`
 }
 
+// PacketFilter provides expressions which are evaluated against the data of ICS-20 packets
+// to determine whether they should be relayed. See processor.PacketFilter for the expression syntax.
+type PacketFilter struct {
+	Allow []string `yaml:"allow,omitempty" json:"allow,omitempty"`
+	Deny  []string `yaml:"deny,omitempty" json:"deny,omitempty"`
+}
+
+// ChannelLimits determines how the packet messages of the channels share each batch sent to a chain.
+// Channels are identified by their channel ID on the src chain, and limits apply in both directions.
+type ChannelLimits struct {
+	MaxMsgsPerBatch uint64                  `yaml:"max-msgs-per-batch,omitempty" json:"max-msgs-per-batch,omitempty"`
+	Channels        map[string]ChannelLimit `yaml:"channels,omitempty" json:"channels,omitempty"`
+}
+
+// ChannelLimit is the priority class and the rate limits of a channel. Zero limits are unlimited.
+type ChannelLimit struct {
+	Priority        string `yaml:"priority,omitempty" json:"priority,omitempty"`
+	MaxMsgsPerBlock uint64 `yaml:"max-msgs-per-block,omitempty" json:"max-msgs-per-block,omitempty"`
+	MaxMsgsPerTx    uint64 `yaml:"max-msgs-per-tx,omitempty" json:"max-msgs-per-tx,omitempty"`
+}
+
+// Resolve converts the configuration to processor.ChannelLimits.
+func (c ChannelLimits) Resolve() (processor.ChannelLimits, error) {
+	limits := processor.ChannelLimits{MaxMsgsPerBatch: c.MaxMsgsPerBatch}
+	if len(c.Channels) == 0 {
+		return limits, nil
+	}
+	limits.Channels = make(map[string]processor.ChannelLimit, len(c.Channels))
+	for channelID, l := range c.Channels {
+		priority, err := processor.ParsePacketPriority(l.Priority)
+		if err != nil {
+			return processor.ChannelLimits{}, fmt.Errorf("channel %s: %w", channelID, err)
+		}
+		limits.Channels[channelID] = processor.ChannelLimit{
+			Priority:        priority,
+			MaxMsgsPerBlock: l.MaxMsgsPerBlock,
+			MaxMsgsPerTx:    l.MaxMsgsPerTx,
+		}
+	}
+	return limits, nil
+}
+
 type IBCdata struct {
 	Schema string `json:"$schema"`
 	Chain1 struct {
@@ -148,6 +198,24 @@ func (p *Path) ValidateChannelFilterRule() error {
 	return nil
 }
 
+// ValidateFeePolicy verifies that the configured FeePolicy is set to an appropriate value.
+func (p *Path) ValidateFeePolicy() error {
+	_, err := processor.ParseFeePolicy(p.FeePolicy)
+	return err
+}
+
+// ValidatePacketFilter verifies that the configured PacketFilter expressions can be parsed.
+func (p *Path) ValidatePacketFilter() error {
+	_, err := processor.NewPacketFilter(p.PacketFilter.Allow, p.PacketFilter.Deny)
+	return err
+}
+
+// ValidateChannelLimits verifies that the configured ChannelLimits priorities are valid.
+func (p *Path) ValidateChannelLimits() error {
+	_, err := p.ChannelLimits.Resolve()
+	return err
+}
+
 // InChannelList returns true if the channelID argument is in the ChannelFilter's ChannelList or false otherwise.
 func (cf *ChannelFilter) InChannelList(channelID string) bool {
 	for _, channel := range cf.ChannelList {
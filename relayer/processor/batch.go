@@ -0,0 +1,79 @@
+package processor
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// Bytes of a transaction other than its messages and memo, e.g. the signature, public key and fee.
+	txOverheadBytes = 1024
+
+	// Bytes of a block reserved for the header, last commit and evidence, which count towards the block max bytes.
+	blockOverheadBytes = 64 * 1024
+)
+
+// splitBatch splits the batch into batches of at most maxMsgs messages, where the encoded messages
+// of each batch add up to at most maxBytes, keeping the order of the messages. A message larger than
+// maxBytes is sent in a batch of its own. Zero limits are unlimited.
+func splitBatch(batch []messageToTrack, maxMsgs, maxBytes int) [][]messageToTrack {
+	var (
+		batches [][]messageToTrack
+		current []messageToTrack
+		size    int
+	)
+	for _, t := range batch {
+		msgSize := 0
+		if bz, err := t.assembledMsg().MsgBytes(); err == nil {
+			msgSize = len(bz)
+		}
+		full := maxMsgs > 0 && len(current) >= maxMsgs
+		tooLarge := maxBytes > 0 && size+msgSize > maxBytes
+		if len(current) > 0 && (full || tooLarge) {
+			batches = append(batches, current)
+			current, size = nil, 0
+		}
+		current = append(current, t)
+		size += msgSize
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// batchMaxBytes returns the maximum bytes of the messages in each transaction sent to dst,
+// which is the smaller of the configured max tx size and the block max bytes of dst,
+// less the bytes of the MsgUpdateClient and the rest of the transaction. Zero means unlimited.
+func (mp *messageProcessor) batchMaxBytes(ctx context.Context, dst *pathEndRuntime) int {
+	maxBytes := int64(mp.maxTxSize)
+	// the provider caches the block limits, which rarely change.
+	limits, err := dst.chainProvider.QueryBlockLimits(ctx)
+	if err != nil {
+		dst.log.Warn("Failed to query block limits, keeping the previous limits", zap.Error(err))
+	}
+	if blockMaxBytes := limits.MaxBytes; blockMaxBytes > 0 {
+		if blockMaxBytes > blockOverheadBytes {
+			blockMaxBytes -= blockOverheadBytes
+		}
+		if maxBytes == 0 || blockMaxBytes < maxBytes {
+			maxBytes = blockMaxBytes
+		}
+	}
+	if maxBytes == 0 {
+		return 0
+	}
+
+	maxBytes -= int64(txOverheadBytes + len(mp.memo))
+	if mp.msgUpdateClient != nil {
+		if bz, err := mp.msgUpdateClient.MsgBytes(); err == nil {
+			maxBytes -= int64(len(bz))
+		}
+	}
+	if maxBytes <= 0 {
+		// too small for any message, so each is sent on its own.
+		return 1
+	}
+	return int(maxBytes)
+}
@@ -0,0 +1,84 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/stretchr/testify/require"
+)
+
+type sizedRelayerMessage int
+
+func (sizedRelayerMessage) Type() string                { return "sized" }
+func (m sizedRelayerMessage) MsgBytes() ([]byte, error) { return make([]byte, m), nil }
+
+func sizedTrackers(sizes ...int) []messageToTrack {
+	trackers := make([]messageToTrack, len(sizes))
+	for i, size := range sizes {
+		trackers[i] = packetMessageToTrack{
+			msg:       packetIBCMessage{info: provider.PacketInfo{Sequence: uint64(i + 1)}},
+			assembled: sizedRelayerMessage(size),
+		}
+	}
+	return trackers
+}
+
+func batchSequences(batches [][]messageToTrack) [][]uint64 {
+	var sequences [][]uint64
+	for _, b := range batches {
+		var s []uint64
+		for _, t := range b {
+			s = append(s, t.(packetMessageToTrack).msg.info.Sequence)
+		}
+		sequences = append(sequences, s)
+	}
+	return sequences
+}
+
+func TestSplitBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		sizes    []int
+		maxMsgs  int
+		maxBytes int
+		expected [][]uint64
+	}{
+		{
+			name:     "unlimited",
+			sizes:    []int{100, 100, 100},
+			expected: [][]uint64{{1, 2, 3}},
+		},
+		{
+			name:     "max msgs",
+			sizes:    []int{100, 100, 100, 100, 100},
+			maxMsgs:  2,
+			expected: [][]uint64{{1, 2}, {3, 4}, {5}},
+		},
+		{
+			name:     "max bytes",
+			sizes:    []int{100, 100, 150, 50, 100},
+			maxBytes: 250,
+			expected: [][]uint64{{1, 2}, {3, 4}, {5}},
+		},
+		{
+			name:     "max msgs and bytes",
+			sizes:    []int{100, 100, 100, 100, 10, 10},
+			maxMsgs:  3,
+			maxBytes: 250,
+			expected: [][]uint64{{1, 2}, {3, 4, 5}, {6}},
+		},
+		{
+			name:     "message larger than max bytes",
+			sizes:    []int{100, 500, 100},
+			maxBytes: 250,
+			expected: [][]uint64{{1}, {2}, {3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batches := splitBatch(sizedTrackers(tt.sizes...), tt.maxMsgs, tt.maxBytes)
+			require.Equal(t, tt.expected, batchSequences(batches))
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package processor
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PacketPriority is the priority class of the packet messages of a channel.
+// Channels in a higher class fill each batch first.
+type PacketPriority int
+
+const (
+	PacketPriorityLow PacketPriority = iota
+	PacketPriorityNormal
+	PacketPriorityHigh
+)
+
+// ParsePacketPriority validates a priority class from the path configuration.
+// An empty string is treated as PacketPriorityNormal.
+func ParsePacketPriority(priority string) (PacketPriority, error) {
+	switch priority {
+	case "low":
+		return PacketPriorityLow, nil
+	case "", "normal":
+		return PacketPriorityNormal, nil
+	case "high":
+		return PacketPriorityHigh, nil
+	}
+	return 0, fmt.Errorf("%s is not a valid priority, please ensure your priority is one of: [low, normal, high]", priority)
+}
+
+// ChannelLimit restricts how many packet messages of a channel are sent. A zero limit is unlimited.
+type ChannelLimit struct {
+	Priority PacketPriority
+
+	// Maximum number of packet messages sent to each chain per block.
+	MaxMsgsPerBlock uint64
+
+	// Maximum number of packet messages included in each batch sent to a chain.
+	MaxMsgsPerTx uint64
+}
+
+// ChannelLimits determines how the packet messages of the channels of a path share each batch.
+type ChannelLimits struct {
+	// Maximum number of packet messages in each batch sent to a chain. 0 is unlimited.
+	MaxMsgsPerBatch uint64
+
+	// Limits keyed by the channel ID on the first path end (src chain of the path).
+	// Channels without an entry have normal priority and no limits.
+	Channels map[string]ChannelLimit
+}
+
+// enabled returns true if any limits or priorities are configured.
+func (l ChannelLimits) enabled() bool {
+	return l.MaxMsgsPerBatch > 0 || len(l.Channels) > 0
+}
+
+func (l ChannelLimits) forChannel(channelID string) ChannelLimit {
+	if limit, ok := l.Channels[channelID]; ok {
+		return limit
+	}
+	return ChannelLimit{Priority: PacketPriorityNormal}
+}
+
+// channelPacketMessages are the packet messages to send to a chain for one channel of the path.
+type channelPacketMessages struct {
+	// channel ID on the first path end.
+	channelID string
+	msgs      []packetIBCMessage
+}
+
+// schedulePacketMessages selects the packet messages to send to dst in this batch from the messages of each channel.
+// Each channel is capped at its limits, then channels in higher priority classes fill the batch first,
+// with the remaining capacity shared round-robin across the channels of the same class.
+func (pp *PathProcessor) schedulePacketMessages(channels []channelPacketMessages, src, dst *pathEndRuntime) []packetIBCMessage {
+	if !pp.channelLimits.enabled() {
+		var msgs []packetIBCMessage
+		for _, c := range channels {
+			msgs = append(msgs, c.msgs...)
+		}
+		pp.sortPacketMessagesByFee(msgs, src, dst)
+		return msgs
+	}
+
+	if dst.scheduledMsgsHeight != dst.latestBlock.Height {
+		dst.scheduledMsgs = make(map[string]uint64)
+		dst.scheduledMsgsHeight = dst.latestBlock.Height
+	}
+
+	capped := make([]channelPacketMessages, 0, len(channels))
+	for _, c := range channels {
+		limit := pp.channelLimits.forChannel(c.channelID)
+		msgs := c.msgs
+		pp.sortPacketMessagesByFee(msgs, src, dst)
+		n := uint64(len(msgs))
+		if limit.MaxMsgsPerTx > 0 && n > limit.MaxMsgsPerTx {
+			n = limit.MaxMsgsPerTx
+		}
+		if limit.MaxMsgsPerBlock > 0 {
+			sent := dst.scheduledMsgs[c.channelID]
+			if sent >= limit.MaxMsgsPerBlock {
+				n = 0
+			} else if n > limit.MaxMsgsPerBlock-sent {
+				n = limit.MaxMsgsPerBlock - sent
+			}
+		}
+		if n == 0 {
+			continue
+		}
+		capped = append(capped, channelPacketMessages{channelID: c.channelID, msgs: msgs[:n]})
+	}
+
+	sort.SliceStable(capped, func(i, j int) bool {
+		pi := pp.channelLimits.forChannel(capped[i].channelID).Priority
+		pj := pp.channelLimits.forChannel(capped[j].channelID).Priority
+		if pi != pj {
+			return pi > pj
+		}
+		return capped[i].channelID < capped[j].channelID
+	})
+
+	capacity := pp.channelLimits.MaxMsgsPerBatch
+	var scheduled []packetIBCMessage
+	full := func() bool {
+		return capacity > 0 && uint64(len(scheduled)) >= capacity
+	}
+
+	for start := 0; start < len(capped) && !full(); {
+		// channels in the same priority class as capped[start]
+		priority := pp.channelLimits.forChannel(capped[start].channelID).Priority
+		end := start
+		for end < len(capped) && pp.channelLimits.forChannel(capped[end].channelID).Priority == priority {
+			end++
+		}
+		class := capped[start:end]
+
+		// rotate the starting channel each block, so that no channel is favored when the batch fills up mid-round.
+		offset := int(dst.latestBlock.Height % uint64(len(class)))
+		for round := 0; !full(); round++ {
+			added := false
+			for i := 0; i < len(class) && !full(); i++ {
+				c := class[(i+offset)%len(class)]
+				if round >= len(c.msgs) {
+					continue
+				}
+				scheduled = append(scheduled, c.msgs[round])
+				dst.scheduledMsgs[c.channelID]++
+				added = true
+			}
+			if !added {
+				break
+			}
+		}
+		start = end
+	}
+
+	return scheduled
+}
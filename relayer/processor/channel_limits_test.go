@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"testing"
+
+	chantypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func recvPacketMessages(channelID string, sequences ...uint64) channelPacketMessages {
+	c := channelPacketMessages{channelID: channelID}
+	for _, seq := range sequences {
+		c.msgs = append(c.msgs, packetIBCMessage{
+			eventType: chantypes.EventTypeRecvPacket,
+			info: provider.PacketInfo{
+				Sequence:      seq,
+				SourcePort:    "transfer",
+				SourceChannel: channelID,
+				ChannelOrder:  chantypes.UNORDERED.String(),
+			},
+		})
+	}
+	return c
+}
+
+func scheduledChannels(msgs []packetIBCMessage) []string {
+	var channels []string
+	for _, msg := range msgs {
+		channels = append(channels, msg.info.SourceChannel)
+	}
+	return channels
+}
+
+func TestSchedulePacketMessagesNoLimits(t *testing.T) {
+	pp := NewPathProcessor(zap.NewNop(), PathEnd{}, PathEnd{}, nil, "", 0, 0)
+
+	msgs := pp.schedulePacketMessages([]channelPacketMessages{
+		recvPacketMessages("channel-0", 1, 2, 3),
+		recvPacketMessages("channel-1", 1),
+	}, pp.pathEnd1, pp.pathEnd2)
+	require.Len(t, msgs, 4)
+}
+
+func TestSchedulePacketMessagesPriorityAndFairness(t *testing.T) {
+	pp := NewPathProcessor(zap.NewNop(), PathEnd{}, PathEnd{}, nil, "", 0, 0)
+	pp.SetChannelLimits(ChannelLimits{
+		MaxMsgsPerBatch: 6,
+		Channels: map[string]ChannelLimit{
+			"channel-0": {Priority: PacketPriorityHigh, MaxMsgsPerTx: 2},
+			"channel-3": {Priority: PacketPriorityLow},
+		},
+	})
+	dst := pp.pathEnd2
+	dst.latestBlock.Height = 10
+
+	msgs := pp.schedulePacketMessages([]channelPacketMessages{
+		recvPacketMessages("channel-3", 1, 2),
+		recvPacketMessages("channel-1", 1, 2, 3, 4, 5),
+		recvPacketMessages("channel-0", 1, 2, 3),
+		recvPacketMessages("channel-2", 1, 2, 3),
+	}, pp.pathEnd1, dst)
+
+	// high priority channel-0 first, capped at 2 per tx, then the remaining 4 shared by the normal priority channels.
+	require.Equal(t, []string{"channel-0", "channel-0", "channel-1", "channel-2", "channel-1", "channel-2"}, scheduledChannels(msgs))
+}
+
+func TestSchedulePacketMessagesPerBlockLimit(t *testing.T) {
+	pp := NewPathProcessor(zap.NewNop(), PathEnd{}, PathEnd{}, nil, "", 0, 0)
+	pp.SetChannelLimits(ChannelLimits{
+		Channels: map[string]ChannelLimit{
+			"channel-0": {Priority: PacketPriorityNormal, MaxMsgsPerBlock: 3},
+		},
+	})
+	dst := pp.pathEnd2
+	dst.latestBlock.Height = 10
+
+	msgs := pp.schedulePacketMessages([]channelPacketMessages{recvPacketMessages("channel-0", 1, 2)}, pp.pathEnd1, dst)
+	require.Len(t, msgs, 2)
+
+	msgs = pp.schedulePacketMessages([]channelPacketMessages{recvPacketMessages("channel-0", 3, 4)}, pp.pathEnd1, dst)
+	require.Len(t, msgs, 1, "only 3 messages should be sent per block")
+
+	dst.latestBlock.Height = 11
+	msgs = pp.schedulePacketMessages([]channelPacketMessages{recvPacketMessages("channel-0", 4, 5, 6, 7)}, pp.pathEnd1, dst)
+	require.Len(t, msgs, 3, "limit should reset on the next block")
+}
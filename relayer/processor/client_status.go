@@ -0,0 +1,137 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ibcexported "github.com/cosmos/ibc-go/v7/modules/core/exported"
+	"go.uber.org/zap"
+)
+
+const (
+	// How often the status of the clients of a path is checked, in addition to after errors.
+	clientStatusCheckInterval = 5 * time.Minute
+
+	// Minimum time between checks of the status of the clients requested after errors, see checkClientStatus.
+	clientStatusErrorCheckInterval = 30 * time.Second
+
+	// How often the status of the clients of a stopped path is checked, to resume once they are active again.
+	clientStatusHaltedCheckInterval = time.Minute
+
+	clientStatusQueryTimeout = 10 * time.Second
+)
+
+// ClientInactiveError is returned when a client of a path is expired or frozen,
+// so that it can no longer be updated and the path can not be relayed until the client is recovered.
+type ClientInactiveError struct {
+	PathName string
+	ChainID  string
+	ClientID string
+	Status   ibcexported.Status
+}
+
+func (e ClientInactiveError) Error() string {
+	return fmt.Sprintf("client %s on chain %s is %s, relaying on path %s is stopped until the client is recovered, "+
+		"e.g. with the proposal from 'rly tx client-recovery %s %s'",
+		e.ClientID, e.ChainID, e.Status, e.PathName, e.PathName, e.ChainID)
+}
+
+// inactiveClient returns an error if a client of the path is expired or frozen.
+// The status of the clients is only queried once every clientStatusCheckInterval, or clientStatusErrorCheckInterval
+// if a check is requested with checkClientStatus. If the status can not be queried, the clients are assumed to be active.
+func (pp *PathProcessor) inactiveClient(ctx context.Context) error {
+	interval := clientStatusCheckInterval
+	if pp.clientStatusRequested {
+		interval = clientStatusErrorCheckInterval
+	}
+	if time.Since(pp.clientStatusChecked) < interval {
+		return nil
+	}
+	pp.clientStatusChecked = time.Now()
+	pp.clientStatusRequested = false
+
+	_, err := pp.queryClientStatus(ctx)
+	return err
+}
+
+// queryClientStatus returns a ClientInactiveError if a client of the path is expired or frozen.
+// The returned bool is false if the status of a client could not be queried.
+func (pp *PathProcessor) queryClientStatus(ctx context.Context) (bool, error) {
+	queried := true
+	for _, pathEnd := range []*pathEndRuntime{pp.pathEnd1, pp.pathEnd2} {
+		if pathEnd.chainProvider == nil {
+			continue
+		}
+
+		queryCtx, cancel := context.WithTimeout(ctx, clientStatusQueryTimeout)
+		status, err := pathEnd.chainProvider.QueryClientStatus(queryCtx, pathEnd.info.ClientID)
+		cancel()
+		if err != nil {
+			pp.log.Debug("Failed to query client status",
+				zap.String("chain_id", pathEnd.info.ChainID),
+				zap.String("client_id", pathEnd.info.ClientID),
+				zap.Error(err),
+			)
+			queried = false
+			continue
+		}
+
+		inactive := status == ibcexported.Expired || status == ibcexported.Frozen
+		if pp.metrics != nil {
+			pp.metrics.SetClientInactive(pathEnd.info.PathName, pathEnd.info.ChainID, pathEnd.info.ClientID, inactive)
+		}
+		if inactive {
+			return true, ClientInactiveError{
+				PathName: pathEnd.info.PathName,
+				ChainID:  pathEnd.info.ChainID,
+				ClientID: pathEnd.info.ClientID,
+				Status:   status,
+			}
+		}
+	}
+	return queried, nil
+}
+
+// checkClientStatus requests the status of the clients to be checked on the next call to inactiveClient,
+// e.g. after failing to send messages, which fails for expired or frozen clients. The checks requested
+// are limited to one every clientStatusErrorCheckInterval, so that repeated errors do not flood the node with queries.
+func (pp *PathProcessor) checkClientStatus() {
+	pp.clientStatusRequested = true
+}
+
+// halt stops relaying on the path while a client is expired or frozen. New data from the chain processors
+// is discarded, so that they are not blocked by the path, and the status of the clients is checked every
+// clientStatusHaltedCheckInterval. Once all clients are active again, e.g. after a client recovery proposal
+// has passed, true is returned to resume relaying, and the path is flushed to relay the packets sent while stopped.
+// False is returned when the context is done. If the path is run for a message lifecycle, e.g. to flush or link,
+// which can not complete, the run is canceled instead.
+func (pp *PathProcessor) halt(ctx context.Context, cancel func(), err error) bool {
+	pp.log.Error("Stopping path", zap.Error(err))
+	if pp.messageLifecycle != nil {
+		cancel()
+		return false
+	}
+
+	ticker := time.NewTicker(clientStatusHaltedCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-pp.pathEnd1.incomingCacheData:
+		case <-pp.pathEnd2.incomingCacheData:
+		case <-pp.retryProcess:
+		case <-ticker.C:
+			if queried, err := pp.queryClientStatus(ctx); err != nil || !queried {
+				continue
+			}
+			pp.log.Info("Clients are active again, resuming path")
+			pp.clientStatusChecked = time.Now()
+			pp.clientStatusRequested = false
+			// relay the packets which were sent while the path was stopped.
+			pp.initialFlushComplete = false
+			return true
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	ibcexported "github.com/cosmos/ibc-go/v7/modules/core/exported"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// clientStatusProvider is a chain provider which only answers client status queries.
+type clientStatusProvider struct {
+	provider.ChainProvider
+	status  ibcexported.Status
+	err     error
+	queries int
+}
+
+func (p *clientStatusProvider) QueryClientStatus(context.Context, string) (ibcexported.Status, error) {
+	p.queries++
+	return p.status, p.err
+}
+
+func TestInactiveClient(t *testing.T) {
+	log := zap.NewNop()
+	provider1 := &clientStatusProvider{status: ibcexported.Active}
+	provider2 := &clientStatusProvider{err: errors.New("connection refused")}
+
+	pp := &PathProcessor{
+		log:      log,
+		pathEnd1: newPathEndRuntime(log, PathEnd{PathName: "demo-path", ChainID: "chain-a", ClientID: "07-tendermint-0"}, nil),
+		pathEnd2: newPathEndRuntime(log, PathEnd{PathName: "demo-path", ChainID: "chain-b", ClientID: "07-tendermint-1"}, nil),
+	}
+	pp.pathEnd1.chainProvider = provider1
+	pp.pathEnd2.chainProvider = provider2
+
+	ctx := context.Background()
+
+	require.NoError(t, pp.inactiveClient(ctx), "active client and failing query")
+	require.Equal(t, 1, provider1.queries)
+	require.Equal(t, 1, provider2.queries)
+
+	// not queried again until the interval has passed
+	provider2.status, provider2.err = ibcexported.Expired, nil
+	require.NoError(t, pp.inactiveClient(ctx))
+	require.Equal(t, 1, provider1.queries)
+
+	// checks requested after errors are limited to one every clientStatusErrorCheckInterval.
+	pp.checkClientStatus()
+	require.NoError(t, pp.inactiveClient(ctx))
+	require.Equal(t, 1, provider1.queries)
+
+	pp.clientStatusChecked = time.Now().Add(-clientStatusErrorCheckInterval)
+	err := pp.inactiveClient(ctx)
+	require.Equal(t, ClientInactiveError{
+		PathName: "demo-path",
+		ChainID:  "chain-b",
+		ClientID: "07-tendermint-1",
+		Status:   ibcexported.Expired,
+	}, err)
+	require.Contains(t, err.Error(), "rly tx client-recovery demo-path chain-b")
+}
+
+func TestQueryClientStatusResume(t *testing.T) {
+	log := zap.NewNop()
+	provider1 := &clientStatusProvider{status: ibcexported.Frozen}
+	provider2 := &clientStatusProvider{status: ibcexported.Active}
+
+	pp := &PathProcessor{
+		log:      log,
+		pathEnd1: newPathEndRuntime(log, PathEnd{PathName: "demo-path", ChainID: "chain-a", ClientID: "07-tendermint-0"}, nil),
+		pathEnd2: newPathEndRuntime(log, PathEnd{PathName: "demo-path", ChainID: "chain-b", ClientID: "07-tendermint-1"}, nil),
+	}
+	pp.pathEnd1.chainProvider = provider1
+	pp.pathEnd2.chainProvider = provider2
+
+	ctx := context.Background()
+
+	queried, err := pp.queryClientStatus(ctx)
+	require.True(t, queried)
+	require.Error(t, err)
+
+	// a halted path is not resumed if the status of a client is unknown.
+	provider1.status, provider1.err = "", errors.New("connection refused")
+	queried, err = pp.queryClientStatus(ctx)
+	require.False(t, queried)
+	require.NoError(t, err)
+
+	// the client has been recovered.
+	provider1.status, provider1.err = ibcexported.Active, nil
+	queried, err = pp.queryClientStatus(ctx)
+	require.True(t, queried)
+	require.NoError(t, err)
+}
@@ -0,0 +1,293 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	chantypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/gofrs/flock"
+	"go.uber.org/zap"
+)
+
+const (
+	deadLetterDir = "dead-letters"
+
+	// How often a running PathProcessor checks for dead letters which were requested to be replayed.
+	deadLetterReplayInterval = 10 * time.Second
+)
+
+// DeadLetter is a packet message which was given up on after the maximum number of retries.
+type DeadLetter struct {
+	PathName string `json:"path_name"`
+
+	// ChainID is the chain which the message was to be delivered to.
+	ChainID             string `json:"chain_id"`
+	CounterpartyChainID string `json:"counterparty_chain_id"`
+
+	EventType string              `json:"event_type"`
+	Packet    provider.PacketInfo `json:"packet"`
+
+	Retries   uint64    `json:"retries"`
+	LastError string    `json:"last_error,omitempty"`
+	Time      time.Time `json:"time"`
+
+	// ReplayRequested is set by RequestReplay until a PathProcessor picks up the message.
+	ReplayRequested bool `json:"replay_requested,omitempty"`
+}
+
+// sameMessage returns true if both dead letters are for the same packet message.
+func (l DeadLetter) sameMessage(o DeadLetter) bool {
+	return l.ChainID == o.ChainID &&
+		l.EventType == o.EventType &&
+		l.Packet.SourcePort == o.Packet.SourcePort &&
+		l.Packet.SourceChannel == o.Packet.SourceChannel &&
+		l.Packet.Sequence == o.Packet.Sequence
+}
+
+// DeadLetterStore persists the packet messages which PathProcessors gave up on,
+// so that they can be inspected and replayed. A nil *DeadLetterStore disables it.
+// The store is shared between processes, e.g. rly start and rly tx replay-dead-letters,
+// so modifications are guarded by a lock file for each path.
+type DeadLetterStore struct {
+	dir string
+}
+
+// NewDeadLetterStore returns a DeadLetterStore which keeps its files in the dead-letters directory under homePath.
+func NewDeadLetterStore(homePath string) (*DeadLetterStore, error) {
+	dir := filepath.Join(homePath, deadLetterDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create dead letter directory: %w", err)
+	}
+	return &DeadLetterStore{dir: dir}, nil
+}
+
+func (s *DeadLetterStore) filePath(pathName string) string {
+	return filepath.Join(s.dir, pathName+stateFileExtension)
+}
+
+// List returns the dead letters of the path, oldest first.
+func (s *DeadLetterStore) List(pathName string) ([]DeadLetter, error) {
+	var letters []DeadLetter
+	if _, err := readJSONFile(s.filePath(pathName), &letters); err != nil {
+		return nil, err
+	}
+	return letters, nil
+}
+
+// Add records a dead letter, replacing any previous one for the same packet message.
+func (s *DeadLetterStore) Add(letter DeadLetter) error {
+	return s.update(letter.PathName, func(letters []DeadLetter) []DeadLetter {
+		kept := letters[:0]
+		for _, l := range letters {
+			if !l.sameMessage(letter) {
+				kept = append(kept, l)
+			}
+		}
+		return append(kept, letter)
+	})
+}
+
+// RequestReplay marks the dead letters of the path with the given packet sequences, or all of them
+// if none are given, to be re-injected by the next PathProcessor running for the path.
+// It returns the number of dead letters which were marked.
+func (s *DeadLetterStore) RequestReplay(pathName string, sequences []uint64) (int, error) {
+	marked := 0
+	err := s.update(pathName, func(letters []DeadLetter) []DeadLetter {
+		for i, l := range letters {
+			if len(sequences) > 0 && !containsSequence(sequences, l.Packet.Sequence) {
+				continue
+			}
+			letters[i].ReplayRequested = true
+			marked++
+		}
+		return letters
+	})
+	return marked, err
+}
+
+// takeReplayRequested removes and returns the dead letters of the path which were requested to be replayed.
+func (s *DeadLetterStore) takeReplayRequested(pathName string) ([]DeadLetter, error) {
+	var taken []DeadLetter
+	err := s.update(pathName, func(letters []DeadLetter) []DeadLetter {
+		kept := letters[:0]
+		for _, l := range letters {
+			if l.ReplayRequested {
+				taken = append(taken, l)
+				continue
+			}
+			kept = append(kept, l)
+		}
+		return kept
+	})
+	return taken, err
+}
+
+// update replaces the dead letters of the path with the result of fn while holding the lock for the path.
+func (s *DeadLetterStore) update(pathName string, fn func([]DeadLetter) []DeadLetter) error {
+	lock := flock.New(filepath.Join(s.dir, pathName+".lock"))
+	if err := lock.Lock(); err != nil {
+		return fmt.Errorf("failed to acquire dead letter lock: %w", err)
+	}
+	defer lock.Unlock()
+
+	letters, err := s.List(pathName)
+	if err != nil {
+		return err
+	}
+	return writeJSONFile(s.filePath(pathName), fn(letters))
+}
+
+func containsSequence(sequences []uint64, sequence uint64) bool {
+	for _, seq := range sequences {
+		if seq == sequence {
+			return true
+		}
+	}
+	return false
+}
+
+// packetMessageKey identifies a packet message for a path end.
+type packetMessageKey struct {
+	channelKey ChannelKey
+	eventType  string
+	sequence   uint64
+}
+
+// setLastPacketError records the error from assembling or sending the packet message,
+// to be included if the message ends up as a dead letter.
+func (pathEnd *pathEndRuntime) setLastPacketError(msg packetIBCMessage, err error) {
+	k, kErr := msg.channelKey()
+	if kErr != nil {
+		return
+	}
+	pathEnd.lastPacketErrorsMu.Lock()
+	defer pathEnd.lastPacketErrorsMu.Unlock()
+	pathEnd.lastPacketErrors[packetMessageKey{channelKey: k, eventType: msg.eventType, sequence: msg.info.Sequence}] = err.Error()
+}
+
+// setLastPacketErrors records the error for all packet messages among the trackers.
+func (pathEnd *pathEndRuntime) setLastPacketErrors(trackers []messageToTrack, err error) {
+	for _, tracker := range trackers {
+		if t, ok := tracker.(packetMessageToTrack); ok {
+			pathEnd.setLastPacketError(t.msg, err)
+		}
+	}
+}
+
+// takeLastPacketError removes and returns the last error recorded for the packet message.
+func (pathEnd *pathEndRuntime) takeLastPacketError(k ChannelKey, eventType string, sequence uint64) string {
+	pathEnd.lastPacketErrorsMu.Lock()
+	defer pathEnd.lastPacketErrorsMu.Unlock()
+	key := packetMessageKey{channelKey: k, eventType: eventType, sequence: sequence}
+	lastErr := pathEnd.lastPacketErrors[key]
+	delete(pathEnd.lastPacketErrors, key)
+	return lastErr
+}
+
+// pruneLastPacketErrors removes the errors of packet messages which are no longer in progress.
+func (pathEnd *pathEndRuntime) pruneLastPacketErrors() {
+	pathEnd.lastPacketErrorsMu.Lock()
+	defer pathEnd.lastPacketErrorsMu.Unlock()
+	for key := range pathEnd.lastPacketErrors {
+		if _, ok := pathEnd.packetProcessing[key.channelKey][key.eventType][key.sequence]; !ok {
+			delete(pathEnd.lastPacketErrors, key)
+		}
+	}
+}
+
+// recordDeadLetter persists the packet message which is being given up on,
+// along with the last error seen for it.
+func (pathEnd *pathEndRuntime) recordDeadLetter(
+	message packetIBCMessage,
+	k ChannelKey,
+	retries uint64,
+	counterparty *pathEndRuntime,
+) {
+	lastErr := pathEnd.takeLastPacketError(k, message.eventType, message.info.Sequence)
+	if pathEnd.deadLetterStore == nil {
+		return
+	}
+	err := pathEnd.deadLetterStore.Add(DeadLetter{
+		PathName:            pathEnd.info.PathName,
+		ChainID:             pathEnd.info.ChainID,
+		CounterpartyChainID: counterparty.info.ChainID,
+		EventType:           message.eventType,
+		Packet:              message.info,
+		Retries:             retries,
+		LastError:           lastErr,
+		Time:                time.Now(),
+	})
+	if err != nil {
+		pathEnd.log.Error("Failed to record dead letter",
+			zap.String("event_type", message.eventType),
+			zap.Uint64("sequence", message.info.Sequence),
+			zap.Inline(k),
+			zap.Error(err),
+		)
+	}
+}
+
+// retainDeadLetter re-injects the packet flow messages from which the dead letter,
+// to be delivered to this path end, will be assembled again.
+func (pathEnd *pathEndRuntime) retainDeadLetter(letter DeadLetter, counterparty *pathEndRuntime) error {
+	info := letter.Packet
+	sendKey, _ := PacketInfoChannelKey(chantypes.EventTypeSendPacket, info)
+	switch letter.EventType {
+	case chantypes.EventTypeRecvPacket:
+		counterparty.messageCache.PacketFlow.Retain(sendKey, chantypes.EventTypeSendPacket, info)
+	case chantypes.EventTypeAcknowledgePacket:
+		ackKey, _ := PacketInfoChannelKey(chantypes.EventTypeWriteAck, info)
+		pathEnd.messageCache.PacketFlow.Retain(sendKey, chantypes.EventTypeSendPacket, info)
+		counterparty.messageCache.PacketFlow.Retain(ackKey, chantypes.EventTypeWriteAck, info)
+	case chantypes.EventTypeTimeoutPacket, chantypes.EventTypeTimeoutPacketOnClose:
+		// the timeout will be determined again from the pending packet.
+		pathEnd.messageCache.PacketFlow.Retain(sendKey, chantypes.EventTypeSendPacket, info)
+	default:
+		return fmt.Errorf("unexpected event type for dead letter: %s", letter.EventType)
+	}
+	return nil
+}
+
+// replayDeadLetters re-injects the dead letters of the path which were requested to be replayed.
+// They are relayed again with a fresh retry count.
+func (pp *PathProcessor) replayDeadLetters() {
+	if pp.deadLetterStore == nil {
+		return
+	}
+	pathName := pp.pathEnd1.info.PathName
+	letters, err := pp.deadLetterStore.takeReplayRequested(pathName)
+	if err != nil {
+		pp.log.Error("Failed to load dead letters to replay", zap.String("path_name", pathName), zap.Error(err))
+		return
+	}
+	for _, l := range letters {
+		var err error
+		switch l.ChainID {
+		case pp.pathEnd1.info.ChainID:
+			err = pp.pathEnd1.retainDeadLetter(l, pp.pathEnd2)
+		case pp.pathEnd2.info.ChainID:
+			err = pp.pathEnd2.retainDeadLetter(l, pp.pathEnd1)
+		default:
+			err = fmt.Errorf("chain %s is not part of the path", l.ChainID)
+		}
+		if err != nil {
+			pp.log.Error("Failed to replay dead letter",
+				zap.String("path_name", pathName),
+				zap.String("chain_id", l.ChainID),
+				zap.String("event_type", l.EventType),
+				zap.Uint64("sequence", l.Packet.Sequence),
+				zap.Error(err),
+			)
+			continue
+		}
+		pp.log.Info("Replaying dead letter",
+			zap.String("path_name", pathName),
+			zap.String("chain_id", l.ChainID),
+			zap.String("event_type", l.EventType),
+			zap.Uint64("sequence", l.Packet.Sequence),
+		)
+	}
+}
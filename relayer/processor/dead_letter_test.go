@@ -0,0 +1,59 @@
+package processor_test
+
+import (
+	"testing"
+
+	chantypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/cosmos/relayer/v2/relayer/processor"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func testDeadLetter(sequence uint64, lastErr string) processor.DeadLetter {
+	return processor.DeadLetter{
+		PathName:  "test-path",
+		ChainID:   testChain0,
+		EventType: chantypes.EventTypeRecvPacket,
+		Packet: provider.PacketInfo{
+			Sequence:      sequence,
+			SourcePort:    testPort0,
+			SourceChannel: testChannel0,
+			DestPort:      testPort1,
+			DestChannel:   testChannel1,
+		},
+		Retries:   5,
+		LastError: lastErr,
+	}
+}
+
+func TestDeadLetterStore(t *testing.T) {
+	store, err := processor.NewDeadLetterStore(t.TempDir())
+	require.NoError(t, err)
+
+	letters, err := store.List("test-path")
+	require.NoError(t, err)
+	require.Empty(t, letters)
+
+	require.NoError(t, store.Add(testDeadLetter(1, "out of gas")))
+	require.NoError(t, store.Add(testDeadLetter(2, "timeout")))
+	require.NoError(t, store.Add(testDeadLetter(1, "account sequence mismatch")))
+
+	letters, err = store.List("test-path")
+	require.NoError(t, err)
+	require.Len(t, letters, 2, "dead letter for the same message should be replaced")
+	require.Equal(t, uint64(2), letters[0].Packet.Sequence)
+	require.Equal(t, "account sequence mismatch", letters[1].LastError)
+
+	marked, err := store.RequestReplay("test-path", []uint64{2, 3})
+	require.NoError(t, err)
+	require.Equal(t, 1, marked)
+
+	letters, err = store.List("test-path")
+	require.NoError(t, err)
+	require.True(t, letters[0].ReplayRequested)
+	require.False(t, letters[1].ReplayRequested)
+
+	marked, err = store.RequestReplay("test-path", nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, marked, "all dead letters should be marked without sequences")
+}
@@ -0,0 +1,199 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	feetypes "github.com/cosmos/ibc-go/v7/modules/apps/29-fee/types"
+	chantypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// FeePolicy determines how a PathProcessor treats packets
+// depending on the fees escrowed for them by the ICS-29 fee middleware.
+type FeePolicy string
+
+const (
+	// FeePolicyRelayAll relays all packets, regardless of incentivization.
+	FeePolicyRelayAll FeePolicy = "all"
+
+	// FeePolicyIncentivizedOnly relays only packets which have fees escrowed
+	// for the message being relayed.
+	FeePolicyIncentivizedOnly FeePolicy = "incentivized-only"
+
+	// FeePolicyPrioritizeByFee relays all packets, but sends the messages
+	// with the highest escrowed fees first.
+	FeePolicyPrioritizeByFee FeePolicy = "prioritize-by-fee"
+)
+
+// ParseFeePolicy validates a fee policy from the path configuration.
+// An empty string is treated as FeePolicyRelayAll.
+func ParseFeePolicy(policy string) (FeePolicy, error) {
+	switch FeePolicy(policy) {
+	case "", FeePolicyRelayAll:
+		return FeePolicyRelayAll, nil
+	case FeePolicyIncentivizedOnly, FeePolicyPrioritizeByFee:
+		return FeePolicy(policy), nil
+	}
+	return "", fmt.Errorf("%s is not a valid fee policy, please ensure your fee policy is one of: [%s, %s, %s]",
+		policy, FeePolicyRelayAll, FeePolicyIncentivizedOnly, FeePolicyPrioritizeByFee)
+}
+
+// packetFeeCache holds the total fees escrowed on a chain for packets sent from that chain,
+// keyed by the source channel and the packet sequence.
+type packetFeeCache map[ChannelKey]map[uint64]feetypes.Fee
+
+// fee returns the fee escrowed for relaying the given packet flow message,
+// i.e. the recv fee for MsgRecvPacket, the ack fee for MsgAcknowledgement
+// and the timeout fee for MsgTimeout/MsgTimeoutOnClose.
+func (c packetFeeCache) fee(msg packetIBCMessage) sdk.Coins {
+	fee, ok := c[packetInfoChannelKey(msg.info)][msg.info.Sequence]
+	if !ok {
+		return nil
+	}
+	switch msg.eventType {
+	case chantypes.EventTypeRecvPacket:
+		return fee.RecvFee
+	case chantypes.EventTypeAcknowledgePacket:
+		return fee.AckFee
+	case chantypes.EventTypeTimeoutPacket, chantypes.EventTypeTimeoutPacketOnClose:
+		return fee.TimeoutFee
+	}
+	return nil
+}
+
+// totalPacketFee sums all fees which have been escrowed for a single packet.
+func totalPacketFee(packetFees []feetypes.PacketFee) feetypes.Fee {
+	var total feetypes.Fee
+	for _, pf := range packetFees {
+		total.RecvFee = total.RecvFee.Add(pf.Fee.RecvFee...)
+		total.AckFee = total.AckFee.Add(pf.Fee.AckFee...)
+		total.TimeoutFee = total.TimeoutFee.Add(pf.Fee.TimeoutFee...)
+	}
+	return total
+}
+
+// feeWeight reduces a fee to a single value for comparison.
+// Amounts of different denoms are summed without conversion.
+func feeWeight(fee sdk.Coins) sdk.Int {
+	weight := sdk.ZeroInt()
+	for _, c := range fee {
+		weight = weight.Add(c.Amount)
+	}
+	return weight
+}
+
+// packetSource returns the pathEndRuntime where the packet for the message was sent,
+// given the messages to be sent to dst from src.
+func packetSource(msg packetIBCMessage, src, dst *pathEndRuntime) *pathEndRuntime {
+	if msg.eventType == chantypes.EventTypeRecvPacket {
+		return src
+	}
+	return dst
+}
+
+// shouldRelayForFee applies the fee policy to a packet message.
+// packetSrc is the chain where the packet was sent, which holds the escrowed fees.
+func (pp *PathProcessor) shouldRelayForFee(msg packetIBCMessage, packetSrc *pathEndRuntime) bool {
+	if pp.feePolicy != FeePolicyIncentivizedOnly {
+		return true
+	}
+	if !packetSrc.packetFees.fee(msg).IsZero() {
+		return true
+	}
+	pp.log.Debug("Skipping packet message without escrowed fee",
+		zap.String("event_type", msg.eventType),
+		zap.String("src_chain_id", packetSrc.info.ChainID),
+		zap.String("src_channel", msg.info.SourceChannel),
+		zap.String("src_port", msg.info.SourcePort),
+		zap.Uint64("sequence", msg.info.Sequence),
+	)
+	return false
+}
+
+// sortPacketMessagesByFee orders the messages to be sent to dst by descending escrowed fee.
+// Messages of ordered channels must be sent in sequence order, so they keep their positions
+// and only the messages of unordered channels are reordered among the remaining positions.
+func (pp *PathProcessor) sortPacketMessagesByFee(msgs []packetIBCMessage, src, dst *pathEndRuntime) {
+	if pp.feePolicy != FeePolicyPrioritizeByFee {
+		return
+	}
+	var (
+		positions []int
+		unordered []packetIBCMessage
+	)
+	for i, msg := range msgs {
+		if msg.isOrdered() {
+			continue
+		}
+		positions = append(positions, i)
+		unordered = append(unordered, msg)
+	}
+	sort.SliceStable(unordered, func(i, j int) bool {
+		feeI := feeWeight(packetSource(unordered[i], src, dst).packetFees.fee(unordered[i]))
+		feeJ := feeWeight(packetSource(unordered[j], src, dst).packetFees.fee(unordered[j]))
+		return feeI.GT(feeJ)
+	})
+	for i, pos := range positions {
+		msgs[pos] = unordered[i]
+	}
+}
+
+// updatePacketFees queries the escrowed fees for the channels which have pending packets
+// on either path end. It is a no-op when the fee policy does not make use of fees.
+func (pp *PathProcessor) updatePacketFees(ctx context.Context, channelPairs []channelPair) {
+	if pp.feePolicy == FeePolicyRelayAll {
+		return
+	}
+
+	var eg errgroup.Group
+	for _, pair := range channelPairs {
+		if pp.pathEnd1.shouldUpdatePacketFees(pair.pathEnd1ChannelKey) {
+			eg.Go(pp.pathEnd1.queryPacketFees(ctx, pair.pathEnd1ChannelKey))
+		}
+		if pp.pathEnd2.shouldUpdatePacketFees(pair.pathEnd2ChannelKey) {
+			eg.Go(pp.pathEnd2.queryPacketFees(ctx, pair.pathEnd2ChannelKey))
+		}
+	}
+	if err := eg.Wait(); err != nil {
+		pp.log.Warn("Failed to query incentivized packets, using last known fees", zap.Error(err))
+	}
+}
+
+// shouldUpdatePacketFees returns true if packets are pending on the channel
+// and its fees have not yet been queried at the latest height.
+func (pathEnd *pathEndRuntime) shouldUpdatePacketFees(k ChannelKey) bool {
+	if len(pathEnd.messageCache.PacketFlow[k][chantypes.EventTypeSendPacket]) == 0 {
+		return false
+	}
+	pathEnd.packetFeesMu.Lock()
+	defer pathEnd.packetFeesMu.Unlock()
+	return pathEnd.packetFeesHeight[k] < pathEnd.latestBlock.Height
+}
+
+func (pathEnd *pathEndRuntime) queryPacketFees(ctx context.Context, k ChannelKey) func() error {
+	height := pathEnd.latestBlock.Height
+	return func() error {
+		queryCtx, cancel := context.WithTimeout(ctx, packetFeeQueryTimeout)
+		defer cancel()
+
+		packets, err := pathEnd.chainProvider.QueryIncentivizedPacketsForChannel(queryCtx, height, k.ChannelID, k.PortID)
+		if err != nil {
+			return fmt.Errorf("channel: %s, port: %s: %w", k.ChannelID, k.PortID, err)
+		}
+
+		fees := make(map[uint64]feetypes.Fee, len(packets))
+		for _, p := range packets {
+			fees[p.PacketId.Sequence] = totalPacketFee(p.PacketFees)
+		}
+
+		pathEnd.packetFeesMu.Lock()
+		defer pathEnd.packetFeesMu.Unlock()
+		pathEnd.packetFees[k] = fees
+		pathEnd.packetFeesHeight[k] = height
+		return nil
+	}
+}
@@ -0,0 +1,52 @@
+package processor
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"go.uber.org/zap"
+)
+
+// pauseIfFeeBudgetExceeded pauses sending messages to this path end until the budget window rolls over
+// if err is a provider.FeeBudgetExceededError. It returns true if sending is paused.
+func (pathEnd *pathEndRuntime) pauseIfFeeBudgetExceeded(err error) bool {
+	var budgetErr *provider.FeeBudgetExceededError
+	if !errors.As(err, &budgetErr) {
+		return false
+	}
+
+	pathEnd.feeBudgetMu.Lock()
+	defer pathEnd.feeBudgetMu.Unlock()
+
+	if !budgetErr.ResetAt.After(pathEnd.feeBudgetPausedUntil) {
+		// already paused until then.
+		return true
+	}
+	pathEnd.feeBudgetPausedUntil = budgetErr.ResetAt
+
+	pathEnd.log.Error("Fee budget exceeded, pausing relaying to chain until the budget window rolls over",
+		zap.String("key", budgetErr.Key),
+		zap.String("denom", budgetErr.Denom),
+		zap.String("limit", budgetErr.Limit),
+		zap.String("spent", budgetErr.Spent),
+		zap.Time("resume_at", budgetErr.ResetAt),
+	)
+	return true
+}
+
+// feeBudgetPaused returns true while sending messages to this path end is paused due to an exceeded fee budget.
+func (pathEnd *pathEndRuntime) feeBudgetPaused() bool {
+	pathEnd.feeBudgetMu.Lock()
+	defer pathEnd.feeBudgetMu.Unlock()
+
+	if pathEnd.feeBudgetPausedUntil.IsZero() {
+		return false
+	}
+	if time.Now().Before(pathEnd.feeBudgetPausedUntil) {
+		return true
+	}
+	pathEnd.log.Info("Fee budget window rolled over, resuming relaying to chain")
+	pathEnd.feeBudgetPausedUntil = time.Time{}
+	return false
+}
@@ -0,0 +1,116 @@
+package processor
+
+import (
+	"fmt"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	feetypes "github.com/cosmos/ibc-go/v7/modules/apps/29-fee/types"
+	chantypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func feePacketMessage(eventType, channelID string, order chantypes.Order, seq uint64) packetIBCMessage {
+	return packetIBCMessage{
+		eventType: eventType,
+		info: provider.PacketInfo{
+			Sequence:      seq,
+			SourcePort:    "transfer",
+			SourceChannel: channelID,
+			DestPort:      "transfer",
+			DestChannel:   "channel-100",
+			ChannelOrder:  order.String(),
+		},
+	}
+}
+
+func feeChannelKey(channelID string) ChannelKey {
+	return ChannelKey{
+		ChannelID:             channelID,
+		PortID:                "transfer",
+		CounterpartyChannelID: "channel-100",
+		CounterpartyPortID:    "transfer",
+	}
+}
+
+func testFee(recv, ack, timeout int64) feetypes.Fee {
+	return feetypes.Fee{
+		RecvFee:    sdk.NewCoins(sdk.NewInt64Coin("stake", recv)),
+		AckFee:     sdk.NewCoins(sdk.NewInt64Coin("stake", ack)),
+		TimeoutFee: sdk.NewCoins(sdk.NewInt64Coin("stake", timeout)),
+	}
+}
+
+func TestPacketFeeCacheFee(t *testing.T) {
+	cache := packetFeeCache{
+		feeChannelKey("channel-0"): {1: testFee(10, 20, 30)},
+	}
+
+	fee := func(eventType string, seq uint64) sdk.Coins {
+		return cache.fee(feePacketMessage(eventType, "channel-0", chantypes.UNORDERED, seq))
+	}
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("stake", 10)), fee(chantypes.EventTypeRecvPacket, 1))
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("stake", 20)), fee(chantypes.EventTypeAcknowledgePacket, 1))
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("stake", 30)), fee(chantypes.EventTypeTimeoutPacket, 1))
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("stake", 30)), fee(chantypes.EventTypeTimeoutPacketOnClose, 1))
+	require.Nil(t, fee(chantypes.EventTypeRecvPacket, 2), "sequence without escrowed fee")
+	require.Nil(t, cache.fee(feePacketMessage(chantypes.EventTypeRecvPacket, "channel-1", chantypes.UNORDERED, 1)), "other channel")
+}
+
+func TestShouldRelayForFee(t *testing.T) {
+	src := &pathEndRuntime{packetFees: packetFeeCache{
+		feeChannelKey("channel-0"): {1: testFee(10, 0, 0)},
+	}}
+	paid := feePacketMessage(chantypes.EventTypeRecvPacket, "channel-0", chantypes.UNORDERED, 1)
+	unpaid := feePacketMessage(chantypes.EventTypeRecvPacket, "channel-0", chantypes.UNORDERED, 2)
+	noAckFee := feePacketMessage(chantypes.EventTypeAcknowledgePacket, "channel-0", chantypes.UNORDERED, 1)
+
+	for _, policy := range []FeePolicy{FeePolicyRelayAll, FeePolicyPrioritizeByFee} {
+		pp := &PathProcessor{log: zap.NewNop(), feePolicy: policy}
+		require.True(t, pp.shouldRelayForFee(paid, src), policy)
+		require.True(t, pp.shouldRelayForFee(unpaid, src), policy)
+	}
+
+	pp := &PathProcessor{log: zap.NewNop(), feePolicy: FeePolicyIncentivizedOnly}
+	require.True(t, pp.shouldRelayForFee(paid, src))
+	require.False(t, pp.shouldRelayForFee(unpaid, src))
+	require.False(t, pp.shouldRelayForFee(noAckFee, src), "only the fee for the message being relayed counts")
+}
+
+func TestSortPacketMessagesByFee(t *testing.T) {
+	src := &pathEndRuntime{packetFees: packetFeeCache{
+		feeChannelKey("channel-0"): {1: testFee(1, 0, 0), 2: testFee(5, 0, 0), 3: testFee(3, 0, 0)},
+		feeChannelKey("channel-1"): {1: testFee(9, 0, 0), 2: testFee(1, 0, 0)},
+	}}
+	dst := &pathEndRuntime{packetFees: packetFeeCache{}}
+
+	msgs := func() []packetIBCMessage {
+		return []packetIBCMessage{
+			feePacketMessage(chantypes.EventTypeRecvPacket, "channel-0", chantypes.UNORDERED, 1),
+			feePacketMessage(chantypes.EventTypeRecvPacket, "channel-1", chantypes.ORDERED, 1),
+			feePacketMessage(chantypes.EventTypeRecvPacket, "channel-0", chantypes.UNORDERED, 2),
+			feePacketMessage(chantypes.EventTypeRecvPacket, "channel-1", chantypes.ORDERED, 2),
+			feePacketMessage(chantypes.EventTypeRecvPacket, "channel-0", chantypes.UNORDERED, 3),
+		}
+	}
+	order := func(msgs []packetIBCMessage) []string {
+		var order []string
+		for _, msg := range msgs {
+			order = append(order, fmt.Sprintf("%s/%d", msg.info.SourceChannel, msg.info.Sequence))
+		}
+		return order
+	}
+
+	unsorted := msgs()
+	pp := &PathProcessor{log: zap.NewNop(), feePolicy: FeePolicyRelayAll}
+	pp.sortPacketMessagesByFee(unsorted, src, dst)
+	require.Equal(t, order(msgs()), order(unsorted), "not sorted without prioritize-by-fee")
+
+	sorted := msgs()
+	pp.feePolicy = FeePolicyPrioritizeByFee
+	pp.sortPacketMessagesByFee(sorted, src, dst)
+	// the ordered channel keeps its positions and sequence order, even though sequence 1 has the highest fee.
+	require.Equal(t, []string{"channel-0/2", "channel-1/1", "channel-0/3", "channel-1/2", "channel-0/1"}, order(sorted))
+}
@@ -21,6 +21,10 @@ type messageProcessor struct {
 
 	memo string
 
+	// Limits of each transaction sent in batch mode, zero if unlimited.
+	maxTxSize uint64
+	maxMsgs   uint64
+
 	msgUpdateClient           provider.RelayerMessage
 	clientUpdateThresholdTime time.Duration
 
@@ -66,12 +70,15 @@ func newMessageProcessor(
 	metrics *PrometheusMetrics,
 	memo string,
 	clientUpdateThresholdTime time.Duration,
+	maxTxSize, maxMsgs uint64,
 ) *messageProcessor {
 	return &messageProcessor{
 		log:                       log,
 		metrics:                   metrics,
 		memo:                      memo,
 		clientUpdateThresholdTime: clientUpdateThresholdTime,
+		maxTxSize:                 maxTxSize,
+		maxMsgs:                   maxMsgs,
 	}
 }
 
@@ -82,6 +89,10 @@ func (mp *messageProcessor) processMessages(
 	messages pathEndMessages,
 	src, dst *pathEndRuntime,
 ) error {
+	if dst.feeBudgetPaused() {
+		return nil
+	}
+
 	needsClientUpdate, err := mp.shouldUpdateClientNow(ctx, src, dst)
 	if err != nil {
 		return err
@@ -115,8 +126,10 @@ func (mp *messageProcessor) shouldUpdateClientNow(ctx context.Context, src, dst
 
 	clientUpdateThresholdMs := mp.clientUpdateThresholdTime.Milliseconds()
 
+	retrySendAfterBlocks := dst.retryPolicy.forMessageType(MessageTypeClientUpdate).RetrySendAfterBlocks
+
 	dst.lastClientUpdateHeightMu.Lock()
-	enoughBlocksPassed := (dst.latestBlock.Height - blocksToRetrySendAfter) > dst.lastClientUpdateHeight
+	enoughBlocksPassed := dst.latestBlock.Height > dst.lastClientUpdateHeight+retrySendAfterBlocks
 	dst.lastClientUpdateHeightMu.Unlock()
 
 	twoThirdsTrustingPeriodMs := float64(dst.clientState.TrustingPeriod.Milliseconds()) * 2 / 3
@@ -199,6 +212,9 @@ func (mp *messageProcessor) assembleMessage(
 	mp.trackMessage(msg.tracker(assembled), i)
 	wg.Done()
 	if err != nil {
+		if m, ok := msg.(packetIBCMessage); ok {
+			dst.setLastPacketError(m, err)
+		}
 		dst.log.Error(fmt.Sprintf("Error assembling %s message", msg.msgType()), zap.Object("msg", msg))
 		return
 	}
@@ -283,6 +299,10 @@ func (mp *messageProcessor) trackAndSendMessages(
 	var batch []messageToTrack
 
 	for _, t := range mp.trackers() {
+		if pt, ok := t.(packetMessageToTrack); ok && pt.msg.isOrdered() {
+			// tracked and sent in order below.
+			continue
+		}
 		retries := dst.trackProcessingMessage(t)
 		if t.assembledMsg() == nil {
 			continue
@@ -295,7 +315,17 @@ func (mp *messageProcessor) trackAndSendMessages(
 	}
 
 	if len(batch) > 0 {
-		go mp.sendBatchMessages(ctx, src, dst, batch)
+		go mp.sendBatchMessages(ctx, src, dst, batch, false)
+	}
+
+	// Packets on ordered channels are sent in a single transaction in sequence order,
+	// regardless of the broadcast mode, since they are only accepted in that order.
+	orderedToTrack, orderedBatch := orderedPacketTrackers(mp.pktMsgs)
+	for _, t := range orderedToTrack {
+		dst.trackProcessingMessage(t)
+	}
+	if len(orderedBatch) > 0 {
+		go mp.sendBatchMessages(ctx, src, dst, orderedBatch, true)
 	}
 
 	if mp.assembledCount() > 0 {
@@ -328,6 +358,9 @@ func (mp *messageProcessor) sendClientUpdate(
 	msgs := []provider.RelayerMessage{mp.msgUpdateClient}
 
 	if err := dst.chainProvider.SendMessagesToMempool(broadcastCtx, msgs, mp.memo, ctx, nil); err != nil {
+		if dst.pauseIfFeeBudgetExceeded(err) {
+			return
+		}
 		mp.log.Error("Error sending client update message",
 			zap.String("src_chain_id", src.info.ChainID),
 			zap.String("dst_chain_id", dst.info.ChainID),
@@ -340,17 +373,44 @@ func (mp *messageProcessor) sendClientUpdate(
 	dst.log.Debug("Client update broadcast completed")
 }
 
-// sendBatchMessages will send a batch of messages,
-// then increment metrics counters for successful packet messages.
+// sendBatchMessages will send the batch of messages, split into transactions within the batch limits
+// and the block limits of dst, each with the MsgUpdateClient prepended. The transactions are sent one after another.
+// If ordered, the remaining transactions are not sent after one fails, since the packets must be received in order.
 func (mp *messageProcessor) sendBatchMessages(
 	ctx context.Context,
 	src, dst *pathEndRuntime,
 	batch []messageToTrack,
+	ordered bool,
 ) {
+	batches := splitBatch(batch, int(mp.maxMsgs), mp.batchMaxBytes(ctx, dst))
+	if len(batches) > 1 {
+		dst.log.Debug("Split messages into multiple transactions",
+			zap.Int("msgs", len(batch)),
+			zap.Int("txs", len(batches)),
+		)
+	}
+	for _, b := range batches {
+		if !mp.sendBatch(ctx, src, dst, b, ordered) && ordered {
+			return
+		}
+	}
+}
+
+// sendBatch will send a batch of messages in a single transaction,
+// then increment metrics counters for successful packet messages.
+// If the transaction would exceed the block max gas of dst, the batch is split in half and each half is sent.
+// It returns false if sending failed.
+func (mp *messageProcessor) sendBatch(
+	ctx context.Context,
+	src, dst *pathEndRuntime,
+	batch []messageToTrack,
+	ordered bool,
+) bool {
 	broadcastCtx, cancel := context.WithTimeout(ctx, messageSendTimeout)
 	defer cancel()
 
-	// messages are batch with appended MsgUpdateClient
+	// MsgUpdateClient is prepended to each transaction, since they may be included in any order.
+	// Updating the client again with the same header is a no-op.
 	msgs := make([]provider.RelayerMessage, 1+len(batch))
 	msgs[0] = mp.msgUpdateClient
 	fields := []zapcore.Field{}
@@ -362,6 +422,9 @@ func (mp *messageProcessor) sendBatchMessages(
 	dst.log.Debug("Will relay messages", fields...)
 
 	callback := func(rtr *provider.RelayerTxResponse, err error) {
+		if err != nil {
+			dst.setLastPacketErrors(batch, err)
+		}
 		// only increment metrics counts for successful packets
 		if err != nil || mp.metrics == nil {
 			return
@@ -384,6 +447,23 @@ func (mp *messageProcessor) sendBatchMessages(
 	}
 
 	if err := dst.chainProvider.SendMessagesToMempool(broadcastCtx, msgs, mp.memo, ctx, callback); err != nil {
+		if dst.pauseIfFeeBudgetExceeded(err) {
+			return false
+		}
+		var gasErr *provider.TxGasExceedsBlockLimitError
+		if errors.As(err, &gasErr) && len(batch) > 1 {
+			dst.log.Debug("Transaction exceeds block max gas, splitting messages",
+				zap.Uint64("gas_wanted", gasErr.GasWanted),
+				zap.Int64("max_gas", gasErr.MaxGas),
+				zap.Int("msgs", len(batch)),
+			)
+			half := len(batch) / 2
+			sent := mp.sendBatch(ctx, src, dst, batch[:half], ordered)
+			if !sent && ordered {
+				return false
+			}
+			return mp.sendBatch(ctx, src, dst, batch[half:], ordered) && sent
+		}
 		errFields := []zapcore.Field{
 			zap.String("src_chain_id", src.info.ChainID),
 			zap.String("dst_chain_id", dst.info.ChainID),
@@ -393,12 +473,14 @@ func (mp *messageProcessor) sendBatchMessages(
 		}
 		if errors.Is(err, chantypes.ErrRedundantTx) {
 			mp.log.Debug("Redundant message(s)", errFields...)
-			return
+			return true
 		}
+		dst.setLastPacketErrors(batch, err)
 		mp.log.Error("Error sending messages", errFields...)
-		return
+		return false
 	}
 	dst.log.Debug("Message broadcast completed", fields...)
+	return true
 }
 
 // sendSingleMessage will send an isolated message.
@@ -420,6 +502,9 @@ func (mp *messageProcessor) sendSingleMessage(
 	var callback func(rtr *provider.RelayerTxResponse, err error)
 	if t, ok := tracker.(packetMessageToTrack); ok {
 		callback = func(rtr *provider.RelayerTxResponse, err error) {
+			if err != nil {
+				dst.setLastPacketError(t.msg, err)
+			}
 			// only increment metrics counts for successful packets
 			if err != nil || mp.metrics == nil {
 				return
@@ -438,6 +523,9 @@ func (mp *messageProcessor) sendSingleMessage(
 
 	err := dst.chainProvider.SendMessagesToMempool(broadcastCtx, msgs, mp.memo, ctx, callback)
 	if err != nil {
+		if dst.pauseIfFeeBudgetExceeded(err) {
+			return
+		}
 		errFields := []zapcore.Field{
 			zap.String("src_chain_id", src.info.ChainID),
 			zap.String("dst_chain_id", dst.info.ChainID),
@@ -450,6 +538,9 @@ func (mp *messageProcessor) sendSingleMessage(
 			mp.log.Debug(fmt.Sprintf("Redundant %s message", msgType), errFields...)
 			return
 		}
+		if t, ok := tracker.(packetMessageToTrack); ok {
+			dst.setLastPacketError(t.msg, err)
+		}
 		mp.log.Error(fmt.Sprintf("Error broadcasting %s message", msgType), errFields...)
 		return
 	}
@@ -1,6 +1,8 @@
 package processor
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -12,6 +14,14 @@ type PrometheusMetrics struct {
 	LatestHeightGauge     *prometheus.GaugeVec
 	WalletBalance         *prometheus.GaugeVec
 	FeesSpent             *prometheus.GaugeVec
+	FeeBudgetExceeded     *prometheus.GaugeVec
+	PacketFilteredCounter *prometheus.CounterVec
+	OrderedChannelBlocked *prometheus.GaugeVec
+	TxReplacedCounter     *prometheus.CounterVec
+	RPCEndpointHealthy    *prometheus.GaugeVec
+	RPCEndpointLatency    *prometheus.GaugeVec
+	RPCEndpointErrors     *prometheus.CounterVec
+	ClientInactive        *prometheus.GaugeVec
 }
 
 func (m *PrometheusMetrics) AddPacketsObserved(path, chain, channel, port, eventType string, count int) {
@@ -22,6 +32,47 @@ func (m *PrometheusMetrics) IncPacketsRelayed(path, chain, channel, port, eventT
 	m.PacketRelayedCounter.WithLabelValues(path, chain, channel, port, eventType).Inc()
 }
 
+func (m *PrometheusMetrics) IncPacketsFiltered(path, chain, channel, port string) {
+	m.PacketFilteredCounter.WithLabelValues(path, chain, channel, port).Inc()
+}
+
+func (m *PrometheusMetrics) SetOrderedChannelBlockedBlocks(path, chain, channel, port string, blocks uint64) {
+	m.OrderedChannelBlocked.WithLabelValues(path, chain, channel, port).Set(float64(blocks))
+}
+
+func (m *PrometheusMetrics) SetFeeBudgetExceeded(chain, key, denom string, exceeded bool) {
+	var v float64
+	if exceeded {
+		v = 1
+	}
+	m.FeeBudgetExceeded.WithLabelValues(chain, key, denom).Set(v)
+}
+
+func (m *PrometheusMetrics) IncTxReplacements(chain, key string) {
+	m.TxReplacedCounter.WithLabelValues(chain, key).Inc()
+}
+
+func (m *PrometheusMetrics) SetRPCEndpointHealth(chain, endpoint string, healthy bool, latency time.Duration) {
+	var v float64
+	if healthy {
+		v = 1
+	}
+	m.RPCEndpointHealthy.WithLabelValues(chain, endpoint).Set(v)
+	m.RPCEndpointLatency.WithLabelValues(chain, endpoint).Set(latency.Seconds())
+}
+
+func (m *PrometheusMetrics) IncRPCEndpointErrors(chain, endpoint string) {
+	m.RPCEndpointErrors.WithLabelValues(chain, endpoint).Inc()
+}
+
+func (m *PrometheusMetrics) SetClientInactive(path, chain, client string, inactive bool) {
+	var v float64
+	if inactive {
+		v = 1
+	}
+	m.ClientInactive.WithLabelValues(path, chain, client).Set(v)
+}
+
 func (m *PrometheusMetrics) SetLatestHeight(chain string, height int64) {
 	m.LatestHeightGauge.WithLabelValues(chain).Set(float64(height))
 }
@@ -36,8 +87,12 @@ func (m *PrometheusMetrics) SetFeesSpent(chain, key, denom string, amount float6
 
 func NewPrometheusMetrics() *PrometheusMetrics {
 	packetLabels := []string{"path", "chain", "channel", "port", "type"}
+	channelLabels := []string{"path", "chain", "channel", "port"}
 	heightLabels := []string{"chain"}
 	walletLabels := []string{"chain", "key", "denom"}
+	keyLabels := []string{"chain", "key"}
+	endpointLabels := []string{"chain", "endpoint"}
+	clientLabels := []string{"path", "chain", "client"}
 	registry := prometheus.NewRegistry()
 	registerer := promauto.With(registry)
 	return &PrometheusMetrics{
@@ -62,5 +117,37 @@ func NewPrometheusMetrics() *PrometheusMetrics {
 			Name: "cosmos_relayer_fees_spent",
 			Help: "The amount of fees spent from the relayer's wallet",
 		}, walletLabels),
+		FeeBudgetExceeded: registerer.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cosmos_relayer_fee_budget_exceeded",
+			Help: "Whether sending transactions with the relayer's wallet is paused because a fee budget is exceeded (1) or not (0)",
+		}, walletLabels),
+		PacketFilteredCounter: registerer.NewCounterVec(prometheus.CounterOpts{
+			Name: "cosmos_relayer_filtered_packets",
+			Help: "The total number of packets skipped due to the packet filter",
+		}, channelLabels),
+		OrderedChannelBlocked: registerer.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cosmos_relayer_ordered_channel_blocked_blocks",
+			Help: "The number of blocks for which relaying on an ordered channel has been stuck on the next expected sequence",
+		}, channelLabels),
+		TxReplacedCounter: registerer.NewCounterVec(prometheus.CounterOpts{
+			Name: "cosmos_relayer_tx_replacements",
+			Help: "The total number of transactions stuck in the mempool which were replaced with a higher fee",
+		}, keyLabels),
+		RPCEndpointHealthy: registerer.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cosmos_relayer_rpc_endpoint_healthy",
+			Help: "Whether the RPC endpoint is healthy (1) or not (0), i.e. it is catching up or failing requests",
+		}, endpointLabels),
+		RPCEndpointLatency: registerer.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cosmos_relayer_rpc_endpoint_latency_seconds",
+			Help: "The moving average of the latency of successful requests to the RPC endpoint",
+		}, endpointLabels),
+		RPCEndpointErrors: registerer.NewCounterVec(prometheus.CounterOpts{
+			Name: "cosmos_relayer_rpc_endpoint_errors",
+			Help: "The total number of requests to the RPC endpoint which failed to reach it or get a valid response",
+		}, endpointLabels),
+		ClientInactive: registerer.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cosmos_relayer_client_inactive",
+			Help: "Whether the client is expired or frozen (1) or not (0), in which case relaying on the path is stopped",
+		}, clientLabels),
 	}
 }
@@ -0,0 +1,235 @@
+package processor
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	chantypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"go.uber.org/zap"
+)
+
+const (
+	// Amount of time to wait for the next sequence to be received on an ordered channel to be queried.
+	nextSeqRecvQueryTimeout = 5 * time.Second
+
+	// How many blocks relaying on an ordered channel can be stuck on the same sequence
+	// before the head-of-line blocking is reported.
+	headOfLineBlockingBlocks = 10
+)
+
+// nextSeqRecvState is the next sequence expected to be received on an ordered channel, as of a height.
+type nextSeqRecvState struct {
+	height   uint64
+	sequence uint64
+}
+
+// headOfLineBlock is the sequence on which relaying on an ordered channel is stuck, since a height.
+type headOfLineBlock struct {
+	sequence    uint64
+	sinceHeight uint64
+	reported    bool
+}
+
+// isOrdered returns true if the packet was sent on an ORDERED channel.
+func (msg packetIBCMessage) isOrdered() bool {
+	return msg.info.ChannelOrder == chantypes.ORDERED.String()
+}
+
+// getOrderedMessagesToSend returns the packet messages of an ordered channel which can be sent now,
+// in sequence order. Ordered channels only accept packets in sequence, so MsgRecvPacket are only sent for
+// the contiguous run of sequences starting at the next sequence expected by the counterparty,
+// and MsgAcknowledgement and MsgTimeout for the contiguous run starting at the lowest pending sequence.
+func (pp *PathProcessor) getOrderedMessagesToSend(
+	ctx context.Context,
+	msgs []packetIBCMessage,
+	src, dst *pathEndRuntime,
+) (srcMsgs []packetIBCMessage, dstMsgs []packetIBCMessage) {
+	sort.SliceStable(msgs, func(i, j int) bool {
+		return msgs[i].info.Sequence < msgs[j].info.Sequence
+	})
+
+	var recvMsgs, srcBoundMsgs []packetIBCMessage
+	for _, msg := range msgs {
+		if msg.eventType == chantypes.EventTypeRecvPacket {
+			recvMsgs = append(recvMsgs, msg)
+		} else {
+			srcBoundMsgs = append(srcBoundMsgs, msg)
+		}
+	}
+
+	if len(recvMsgs) > 0 {
+		dstMsgs = pp.getOrderedRecvMessagesToSend(ctx, recvMsgs, src, dst)
+	}
+
+	for i, msg := range srcBoundMsgs {
+		if i > 0 && msg.info.Sequence != srcBoundMsgs[i-1].info.Sequence+1 {
+			break
+		}
+		if !pp.shouldRelayForFee(msg, src) || !src.shouldSendPacketMessage(msg, dst) {
+			break
+		}
+		srcMsgs = append(srcMsgs, msg)
+		if msg.eventType != chantypes.EventTypeAcknowledgePacket {
+			// a timeout closes the channel, nothing can follow it.
+			break
+		}
+	}
+
+	return srcMsgs, dstMsgs
+}
+
+// getOrderedRecvMessagesToSend returns the MsgRecvPacket, sorted by sequence, which form
+// the contiguous run starting at the next sequence expected to be received by dst.
+func (pp *PathProcessor) getOrderedRecvMessagesToSend(
+	ctx context.Context,
+	msgs []packetIBCMessage,
+	src, dst *pathEndRuntime,
+) (dstMsgs []packetIBCMessage) {
+	first := msgs[0]
+	k, err := first.channelKey()
+	if err != nil {
+		dst.log.Error("Unexpected error getting ordered channel key", zap.Error(err))
+		return nil
+	}
+
+	expected, err := dst.nextSeqRecv(ctx, k)
+	if err != nil {
+		// fall back to the lowest pending sequence.
+		dst.log.Warn("Failed to query next sequence receive for ordered channel",
+			zap.Inline(k),
+			zap.Error(err),
+		)
+		expected = first.info.Sequence
+	}
+
+	head := expected
+	pending := false
+	for _, msg := range msgs {
+		if msg.info.Sequence < expected {
+			// already received, the recv_packet event has not been observed yet.
+			continue
+		}
+		if msg.info.Sequence == head {
+			pending = true
+		}
+		if msg.info.Sequence != expected {
+			break
+		}
+		if !pp.shouldRelayForFee(msg, src) || !pp.shouldRelayPacketContent(msg, src) || !dst.shouldSendPacketMessage(msg, src) {
+			break
+		}
+		dstMsgs = append(dstMsgs, msg)
+		expected++
+	}
+
+	blocked := len(dstMsgs) == 0 && msgs[len(msgs)-1].info.Sequence >= head
+	dst.trackHeadOfLineBlocking(k, head, blocked, pending)
+
+	return dstMsgs
+}
+
+// nextSeqRecv returns the next sequence expected to be received on the ordered channel of this path end.
+// It is queried at most once per block for each channel.
+func (pathEnd *pathEndRuntime) nextSeqRecv(ctx context.Context, k ChannelKey) (uint64, error) {
+	height := pathEnd.latestBlock.Height
+	if state, ok := pathEnd.nextSeqRecvCache[k]; ok && state.height == height {
+		return state.sequence, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, nextSeqRecvQueryTimeout)
+	defer cancel()
+
+	res, err := pathEnd.chainProvider.QueryNextSeqRecv(ctx, int64(height), k.ChannelID, k.PortID)
+	if err != nil {
+		return 0, err
+	}
+	pathEnd.nextSeqRecvCache[k] = nextSeqRecvState{height: height, sequence: res.NextSequenceReceive}
+	return res.NextSequenceReceive, nil
+}
+
+// trackHeadOfLineBlocking reports when no MsgRecvPacket could be sent on the ordered channel
+// for the same next expected sequence for headOfLineBlockingBlocks.
+// pending is true if the packet with the next expected sequence is known to the relayer.
+func (pathEnd *pathEndRuntime) trackHeadOfLineBlocking(k ChannelKey, sequence uint64, blocked, pending bool) {
+	if !blocked {
+		if _, ok := pathEnd.headOfLineBlocks[k]; ok {
+			delete(pathEnd.headOfLineBlocks, k)
+			pathEnd.setHeadOfLineBlockedBlocks(k, 0)
+		}
+		return
+	}
+
+	height := pathEnd.latestBlock.Height
+	block, ok := pathEnd.headOfLineBlocks[k]
+	if !ok || block.sequence != sequence {
+		block = headOfLineBlock{sequence: sequence, sinceHeight: height}
+	}
+	blockedBlocks := height - block.sinceHeight
+	pathEnd.setHeadOfLineBlockedBlocks(k, blockedBlocks)
+
+	if blockedBlocks >= headOfLineBlockingBlocks && !block.reported {
+		reason := "packet message has not been sent successfully"
+		if !pending {
+			reason = "packet is not pending, it will be picked up by the next flush"
+		}
+		pathEnd.log.Warn("Relaying on ordered channel is blocked on the next expected sequence",
+			zap.Inline(k),
+			zap.Uint64("sequence", sequence),
+			zap.Uint64("blocked_blocks", blockedBlocks),
+			zap.String("reason", reason),
+		)
+		block.reported = true
+	}
+	pathEnd.headOfLineBlocks[k] = block
+}
+
+func (pathEnd *pathEndRuntime) setHeadOfLineBlockedBlocks(k ChannelKey, blocks uint64) {
+	if pathEnd.metrics == nil {
+		return
+	}
+	pathEnd.metrics.SetOrderedChannelBlockedBlocks(pathEnd.info.PathName, pathEnd.info.ChainID, k.ChannelID, k.PortID, blocks)
+}
+
+// orderedPacketTrackers returns the trackers of the packet messages on ordered channels,
+// sorted by channel and sequence. For each channel, the messages following one which failed to assemble
+// are left out, since the chain would reject them until that one is delivered. The message which failed
+// to assemble is included in toTrack, so that it is retried, but not in toSend.
+func orderedPacketTrackers(trackers []packetMessageToTrack) (toTrack []messageToTrack, toSend []messageToTrack) {
+	type orderedTracker struct {
+		k ChannelKey
+		t packetMessageToTrack
+	}
+	var ordered []orderedTracker
+	for _, t := range trackers {
+		if !t.msg.isOrdered() {
+			continue
+		}
+		k, err := t.msg.channelKey()
+		if err != nil {
+			continue
+		}
+		ordered = append(ordered, orderedTracker{k: k, t: t})
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].k != ordered[j].k {
+			return ordered[i].k.ChannelID < ordered[j].k.ChannelID ||
+				(ordered[i].k.ChannelID == ordered[j].k.ChannelID && ordered[i].k.PortID < ordered[j].k.PortID)
+		}
+		return ordered[i].t.msg.info.Sequence < ordered[j].t.msg.info.Sequence
+	})
+
+	stalled := make(map[ChannelKey]bool)
+	for _, o := range ordered {
+		if stalled[o.k] {
+			continue
+		}
+		toTrack = append(toTrack, o.t)
+		if o.t.assembled == nil {
+			stalled[o.k] = true
+			continue
+		}
+		toSend = append(toSend, o.t)
+	}
+	return toTrack, toSend
+}
@@ -0,0 +1,94 @@
+package processor
+
+import (
+	"testing"
+
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	chantypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type mockRelayerMessage struct{}
+
+func (mockRelayerMessage) Type() string              { return "mock" }
+func (mockRelayerMessage) MsgBytes() ([]byte, error) { return nil, nil }
+
+func orderedRecvTracker(channelID string, sequence uint64, assembled bool) packetMessageToTrack {
+	t := packetMessageToTrack{
+		msg: packetIBCMessage{
+			eventType: chantypes.EventTypeRecvPacket,
+			info: provider.PacketInfo{
+				Sequence:      sequence,
+				SourcePort:    "icacontroller",
+				SourceChannel: channelID,
+				DestPort:      "icahost",
+				DestChannel:   channelID,
+				ChannelOrder:  chantypes.ORDERED.String(),
+			},
+		},
+	}
+	if assembled {
+		t.assembled = mockRelayerMessage{}
+	}
+	return t
+}
+
+func trackerSequences(trackers []messageToTrack) []uint64 {
+	var sequences []uint64
+	for _, t := range trackers {
+		sequences = append(sequences, t.(packetMessageToTrack).msg.info.Sequence)
+	}
+	return sequences
+}
+
+func TestOrderedPacketTrackers(t *testing.T) {
+	unordered := orderedRecvTracker("channel-0", 1, true)
+	unordered.msg.info.ChannelOrder = chantypes.UNORDERED.String()
+
+	toTrack, toSend := orderedPacketTrackers([]packetMessageToTrack{
+		orderedRecvTracker("channel-0", 7, true),
+		orderedRecvTracker("channel-0", 5, true),
+		unordered,
+		orderedRecvTracker("channel-0", 6, true),
+	})
+	require.Equal(t, []uint64{5, 6, 7}, trackerSequences(toTrack))
+	require.Equal(t, []uint64{5, 6, 7}, trackerSequences(toSend), "messages should be sent in sequence order")
+
+	toTrack, toSend = orderedPacketTrackers([]packetMessageToTrack{
+		orderedRecvTracker("channel-0", 6, false),
+		orderedRecvTracker("channel-0", 5, true),
+		orderedRecvTracker("channel-0", 7, true),
+		orderedRecvTracker("channel-1", 3, true),
+	})
+	require.Equal(t, []uint64{5, 6, 3}, trackerSequences(toTrack), "messages after one which failed to assemble should not be tracked")
+	require.Equal(t, []uint64{5, 3}, trackerSequences(toSend), "messages after one which failed to assemble should not be sent")
+}
+
+func TestPacketFilterNotAppliedOnOrderedChannels(t *testing.T) {
+	filter, err := NewPacketFilter(nil, []string{`denom == "uatom"`})
+	require.NoError(t, err)
+	pp := &PathProcessor{log: zap.NewNop(), packetFilter: filter}
+	src := newPathEndRuntime(zap.NewNop(), PathEnd{ChainID: "chain-a"}, nil)
+
+	data := transfertypes.NewFungibleTokenPacketData("uatom", "1", "sender", "receiver", "").GetBytes()
+	msg := func(channelID string, order chantypes.Order) packetIBCMessage {
+		return packetIBCMessage{
+			eventType: chantypes.EventTypeRecvPacket,
+			info: provider.PacketInfo{
+				Sequence:      1,
+				SourcePort:    "transfer",
+				SourceChannel: channelID,
+				DestPort:      "transfer",
+				DestChannel:   channelID,
+				ChannelOrder:  order.String(),
+				Data:          data,
+			},
+		}
+	}
+
+	require.False(t, pp.shouldRelayPacketContent(msg("channel-0", chantypes.UNORDERED), src))
+	require.True(t, pp.shouldRelayPacketContent(msg("channel-1", chantypes.ORDERED), src),
+		"skipping a packet would block the ordered channel")
+}
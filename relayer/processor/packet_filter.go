@@ -0,0 +1,462 @@
+package processor
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	chantypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"go.uber.org/zap"
+)
+
+// PacketFilter decides whether packets should be relayed based on their ICS-20 packet data.
+//
+// Expressions compare the packet data fields denom, amount, sender, receiver and memo against literals, e.g.
+//
+//	denom == "uatom" && amount < 1000
+//	sender == "cosmos1..." || memo =~ "^spam"
+//	!(receiver != "osmo1...")
+//
+// Strings support ==, !=, =~ (regex match) and !~ (regex mismatch). Amounts support ==, !=, <, <=, > and >=.
+// Clauses can be combined with &&, || and !, and grouped with parentheses.
+//
+// A packet is relayed if it matches none of the deny expressions and, if any allow expressions are
+// configured, it matches at least one of them. Packets which are not ICS-20 transfers are always relayed.
+//
+// The filter is not applied on ORDERED channels: the counterparty only accepts packets in sequence,
+// so skipping one packet would block every later packet on the channel forever.
+type PacketFilter struct {
+	allow []packetFilterRule
+	deny  []packetFilterRule
+}
+
+type packetFilterRule struct {
+	source string
+	expr   packetFilterExpr
+}
+
+// NewPacketFilter parses the allow and deny expressions.
+// It returns nil if no expressions are provided.
+func NewPacketFilter(allow, deny []string) (*PacketFilter, error) {
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil, nil
+	}
+	f := new(PacketFilter)
+	for _, source := range allow {
+		expr, err := parsePacketFilterExpr(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allow expression %q: %w", source, err)
+		}
+		f.allow = append(f.allow, packetFilterRule{source: source, expr: expr})
+	}
+	for _, source := range deny {
+		expr, err := parsePacketFilterExpr(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deny expression %q: %w", source, err)
+		}
+		f.deny = append(f.deny, packetFilterRule{source: source, expr: expr})
+	}
+	return f, nil
+}
+
+// ShouldRelay evaluates the filter against the packet data.
+// If the packet should not be relayed, the reason is returned as well.
+func (f *PacketFilter) ShouldRelay(data []byte) (bool, string) {
+	if f == nil {
+		return true, ""
+	}
+	var ftpd transfertypes.FungibleTokenPacketData
+	if err := transfertypes.ModuleCdc.UnmarshalJSON(data, &ftpd); err != nil || ftpd.Denom == "" {
+		// not an ICS-20 packet
+		return true, ""
+	}
+	fields := newPacketFilterFields(ftpd)
+	for _, r := range f.deny {
+		if r.expr.eval(fields) {
+			return false, "matched deny expression: " + r.source
+		}
+	}
+	if len(f.allow) == 0 {
+		return true, ""
+	}
+	for _, r := range f.allow {
+		if r.expr.eval(fields) {
+			return true, ""
+		}
+	}
+	return false, "matched no allow expression"
+}
+
+// shouldRelayPacketContent applies the packet filter to MsgRecvPacket messages.
+// Packets on ordered channels are always relayed, with a warning if the filter would have rejected them.
+// Results are cached on the packet source, so that skipped packets are only logged and counted once.
+func (pp *PathProcessor) shouldRelayPacketContent(msg packetIBCMessage, packetSrc *pathEndRuntime) bool {
+	if pp.packetFilter == nil || msg.eventType != chantypes.EventTypeRecvPacket {
+		return true
+	}
+	k := packetInfoChannelKey(msg.info)
+	if shouldRelay, ok := packetSrc.packetFilterResults[k][msg.info.Sequence]; ok {
+		return shouldRelay
+	}
+
+	shouldRelay, reason := pp.packetFilter.ShouldRelay(msg.info.Data)
+	if _, ok := packetSrc.packetFilterResults[k]; !ok {
+		packetSrc.packetFilterResults[k] = make(map[uint64]bool)
+	}
+
+	if !shouldRelay && msg.isOrdered() {
+		pp.log.Warn("Relaying packet rejected by packet filter, as skipping it would block the ordered channel",
+			zap.String("src_chain_id", packetSrc.info.ChainID),
+			zap.String("src_channel", msg.info.SourceChannel),
+			zap.String("src_port", msg.info.SourcePort),
+			zap.Uint64("sequence", msg.info.Sequence),
+			zap.String("reason", reason),
+		)
+		shouldRelay = true
+	}
+	packetSrc.packetFilterResults[k][msg.info.Sequence] = shouldRelay
+
+	if !shouldRelay {
+		pp.log.Info("Skipping packet due to packet filter",
+			zap.String("src_chain_id", packetSrc.info.ChainID),
+			zap.String("src_channel", msg.info.SourceChannel),
+			zap.String("src_port", msg.info.SourcePort),
+			zap.Uint64("sequence", msg.info.Sequence),
+			zap.String("reason", reason),
+		)
+		if pp.metrics != nil {
+			pp.metrics.IncPacketsFiltered(packetSrc.info.PathName, packetSrc.info.ChainID, msg.info.SourceChannel, msg.info.SourcePort)
+		}
+	}
+	return shouldRelay
+}
+
+// prunePacketFilterResults removes the cached filter results for packets which are no longer pending.
+func (pathEnd *pathEndRuntime) prunePacketFilterResults() {
+	for k, results := range pathEnd.packetFilterResults {
+		sent := pathEnd.messageCache.PacketFlow[k][chantypes.EventTypeSendPacket]
+		for seq := range results {
+			if _, ok := sent[seq]; !ok {
+				delete(results, seq)
+			}
+		}
+		if len(results) == 0 {
+			delete(pathEnd.packetFilterResults, k)
+		}
+	}
+}
+
+// packetFilterFields are the values of the ICS-20 packet data which expressions can refer to.
+type packetFilterFields struct {
+	strings map[string]string
+	amount  *big.Int
+}
+
+func newPacketFilterFields(ftpd transfertypes.FungibleTokenPacketData) packetFilterFields {
+	amount, ok := new(big.Int).SetString(ftpd.Amount, 10)
+	if !ok {
+		amount = nil
+	}
+	return packetFilterFields{
+		strings: map[string]string{
+			"denom":    ftpd.Denom,
+			"sender":   ftpd.Sender,
+			"receiver": ftpd.Receiver,
+			"memo":     ftpd.Memo,
+		},
+		amount: amount,
+	}
+}
+
+type packetFilterExpr interface {
+	eval(fields packetFilterFields) bool
+}
+
+type andExpr struct{ left, right packetFilterExpr }
+
+func (e andExpr) eval(fields packetFilterFields) bool {
+	return e.left.eval(fields) && e.right.eval(fields)
+}
+
+type orExpr struct{ left, right packetFilterExpr }
+
+func (e orExpr) eval(fields packetFilterFields) bool {
+	return e.left.eval(fields) || e.right.eval(fields)
+}
+
+type notExpr struct{ expr packetFilterExpr }
+
+func (e notExpr) eval(fields packetFilterFields) bool {
+	return !e.expr.eval(fields)
+}
+
+type stringCompareExpr struct {
+	field string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+func (e stringCompareExpr) eval(fields packetFilterFields) bool {
+	v := fields.strings[e.field]
+	switch e.op {
+	case "==":
+		return v == e.value
+	case "!=":
+		return v != e.value
+	case "=~":
+		return e.re.MatchString(v)
+	case "!~":
+		return !e.re.MatchString(v)
+	}
+	return false
+}
+
+type amountCompareExpr struct {
+	op    string
+	value *big.Int
+}
+
+func (e amountCompareExpr) eval(fields packetFilterFields) bool {
+	if fields.amount == nil {
+		// invalid amounts never match
+		return false
+	}
+	c := fields.amount.Cmp(e.value)
+	switch e.op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// packetFilterOperators are ordered so that longer operators are matched first.
+var packetFilterOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!", "(", ")"}
+
+type packetFilterToken struct {
+	kind  packetFilterTokenKind
+	value string
+	pos   int
+}
+
+type packetFilterTokenKind int
+
+const (
+	tokenIdent packetFilterTokenKind = iota
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenEOF
+)
+
+func tokenizePacketFilter(s string) ([]packetFilterToken, error) {
+	var tokens []packetFilterToken
+	i := 0
+Loop:
+	for i < len(s) {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"':
+			end := i + 1
+			for ; end < len(s); end++ {
+				if s[end] == '\\' {
+					end++
+					continue
+				}
+				if s[end] == '"' {
+					break
+				}
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			value, err := strconv.Unquote(s[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d: %w", i, err)
+			}
+			tokens = append(tokens, packetFilterToken{kind: tokenString, value: value, pos: i})
+			i = end + 1
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(s) && unicode.IsDigit(rune(s[i])) {
+				i++
+			}
+			tokens = append(tokens, packetFilterToken{kind: tokenNumber, value: s[start:i], pos: start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(s) && (unicode.IsLetter(rune(s[i])) || unicode.IsDigit(rune(s[i])) || s[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, packetFilterToken{kind: tokenIdent, value: s[start:i], pos: start})
+		default:
+			for _, op := range packetFilterOperators {
+				if strings.HasPrefix(s[i:], op) {
+					tokens = append(tokens, packetFilterToken{kind: tokenOperator, value: op, pos: i})
+					i += len(op)
+					continue Loop
+				}
+			}
+			return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+		}
+	}
+	return append(tokens, packetFilterToken{kind: tokenEOF, pos: len(s)}), nil
+}
+
+// packetFilterParser is a recursive descent parser for packet filter expressions.
+//
+//	expr       = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" expr ")" | comparison
+//	comparison = field operator literal
+type packetFilterParser struct {
+	tokens []packetFilterToken
+	pos    int
+}
+
+func parsePacketFilterExpr(s string) (packetFilterExpr, error) {
+	tokens, err := tokenizePacketFilter(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &packetFilterParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", t.value, t.pos)
+	}
+	return expr, nil
+}
+
+func (p *packetFilterParser) peek() packetFilterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *packetFilterParser) next() packetFilterToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *packetFilterParser) acceptOperator(op string) bool {
+	if t := p.peek(); t.kind == tokenOperator && t.value == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *packetFilterParser) parseOr() (packetFilterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptOperator("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *packetFilterParser) parseAnd() (packetFilterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptOperator("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *packetFilterParser) parseUnary() (packetFilterExpr, error) {
+	if p.acceptOperator("!") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr: expr}, nil
+	}
+	if p.acceptOperator("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.acceptOperator(")") {
+			t := p.peek()
+			return nil, fmt.Errorf("expected ) at position %d", t.pos)
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *packetFilterParser) parseComparison() (packetFilterExpr, error) {
+	field := p.next()
+	if field.kind != tokenIdent {
+		return nil, fmt.Errorf("expected field name at position %d", field.pos)
+	}
+	op := p.next()
+	if op.kind != tokenOperator {
+		return nil, fmt.Errorf("expected operator at position %d", op.pos)
+	}
+	literal := p.next()
+
+	switch field.value {
+	case "amount":
+		switch op.value {
+		case "==", "!=", "<", "<=", ">", ">=":
+		default:
+			return nil, fmt.Errorf("operator %s is not supported for amount at position %d", op.value, op.pos)
+		}
+		if literal.kind != tokenNumber {
+			return nil, fmt.Errorf("expected number at position %d", literal.pos)
+		}
+		value, _ := new(big.Int).SetString(literal.value, 10)
+		return amountCompareExpr{op: op.value, value: value}, nil
+	case "denom", "sender", "receiver", "memo":
+		if literal.kind != tokenString {
+			return nil, fmt.Errorf("expected quoted string at position %d", literal.pos)
+		}
+		expr := stringCompareExpr{field: field.value, op: op.value, value: literal.value}
+		switch op.value {
+		case "==", "!=":
+		case "=~", "!~":
+			re, err := regexp.Compile(literal.value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex at position %d: %w", literal.pos, err)
+			}
+			expr.re = re
+		default:
+			return nil, fmt.Errorf("operator %s is not supported for %s at position %d", op.value, field.value, op.pos)
+		}
+		return expr, nil
+	}
+	return nil, fmt.Errorf("unknown field %s at position %d, expected one of: [denom, amount, sender, receiver, memo]", field.value, field.pos)
+}
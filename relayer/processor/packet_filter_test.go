@@ -0,0 +1,83 @@
+package processor_test
+
+import (
+	"testing"
+
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	"github.com/cosmos/relayer/v2/relayer/processor"
+	"github.com/stretchr/testify/require"
+)
+
+func transferPacketData(denom, amount, sender, receiver, memo string) []byte {
+	return transfertypes.NewFungibleTokenPacketData(denom, amount, sender, receiver, memo).GetBytes()
+}
+
+func TestPacketFilterNoExpressions(t *testing.T) {
+	f, err := processor.NewPacketFilter(nil, nil)
+	require.NoError(t, err)
+	require.Nil(t, f)
+
+	shouldRelay, _ := f.ShouldRelay(transferPacketData("uatom", "1", "sender", "receiver", ""))
+	require.True(t, shouldRelay, "nil filter should relay everything")
+}
+
+func TestPacketFilterDeny(t *testing.T) {
+	f, err := processor.NewPacketFilter(nil, []string{
+		`denom == "uatom" && amount < 1000`,
+		`memo =~ "(?i)airdrop"`,
+	})
+	require.NoError(t, err)
+
+	shouldRelay, reason := f.ShouldRelay(transferPacketData("uatom", "999", "sender", "receiver", ""))
+	require.False(t, shouldRelay, "dust transfer should be filtered")
+	require.Contains(t, reason, "amount < 1000")
+
+	shouldRelay, _ = f.ShouldRelay(transferPacketData("uatom", "1000", "sender", "receiver", ""))
+	require.True(t, shouldRelay, "transfer at minimum amount should be relayed")
+
+	shouldRelay, _ = f.ShouldRelay(transferPacketData("uosmo", "1", "sender", "receiver", ""))
+	require.True(t, shouldRelay, "minimum amount only applies to uatom")
+
+	shouldRelay, _ = f.ShouldRelay(transferPacketData("uosmo", "5000", "sender", "receiver", "Claim your AIRDROP"))
+	require.False(t, shouldRelay, "memo matching regex should be filtered")
+
+	shouldRelay, _ = f.ShouldRelay([]byte(`{"some":"ica packet"}`))
+	require.True(t, shouldRelay, "non ICS-20 packets should be relayed")
+}
+
+func TestPacketFilterAllow(t *testing.T) {
+	f, err := processor.NewPacketFilter([]string{
+		`sender == "cosmos1a" || sender == "cosmos1b"`,
+		`!(receiver != "osmo1c") && amount >= 10`,
+	}, nil)
+	require.NoError(t, err)
+
+	shouldRelay, _ := f.ShouldRelay(transferPacketData("uatom", "1", "cosmos1b", "osmo1x", ""))
+	require.True(t, shouldRelay)
+
+	shouldRelay, _ = f.ShouldRelay(transferPacketData("uatom", "10", "cosmos1x", "osmo1c", ""))
+	require.True(t, shouldRelay)
+
+	shouldRelay, reason := f.ShouldRelay(transferPacketData("uatom", "9", "cosmos1x", "osmo1c", ""))
+	require.False(t, shouldRelay)
+	require.NotEmpty(t, reason)
+}
+
+func TestPacketFilterInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{
+		`denom`,
+		`denom == uatom`,
+		`amount == "1000"`,
+		`amount =~ "1"`,
+		`denom < "uatom"`,
+		`fee == "1"`,
+		`(denom == "uatom"`,
+		`denom == "uatom" &&`,
+		`memo =~ "("`,
+		`denom == "uatom`,
+		`denom == "uatom" $`,
+	} {
+		_, err := processor.NewPacketFilter([]string{expr}, nil)
+		require.Error(t, err, expr)
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"sync"
 	"time"
 
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
 	conntypes "github.com/cosmos/ibc-go/v7/modules/core/03-connection/types"
 	chantypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	ibcexported "github.com/cosmos/ibc-go/v7/modules/core/exported"
 	"github.com/cosmos/relayer/v2/relayer/provider"
 	"go.uber.org/zap"
 )
@@ -50,6 +52,42 @@ type pathEndRuntime struct {
 	lastClientUpdateHeight   uint64
 	lastClientUpdateHeightMu sync.Mutex
 
+	retryPolicy RetryPolicy
+
+	// Records the packet messages which are given up on, nil if disabled.
+	deadLetterStore *DeadLetterStore
+
+	// Last error from assembling or sending each packet message in progress.
+	lastPacketErrors   map[packetMessageKey]string
+	lastPacketErrorsMu sync.Mutex
+
+	// Fees escrowed by the ICS-29 fee middleware for packets sent from this chain,
+	// along with the height at which they were last queried for each channel.
+	packetFees       packetFeeCache
+	packetFeesHeight map[ChannelKey]uint64
+	packetFeesMu     sync.Mutex
+
+	// Cached packet filter results for pending packets sent from this chain.
+	packetFilterResults map[ChannelKey]map[uint64]bool
+
+	// Next sequence expected to be received on ordered channels, and where relaying on them is stuck.
+	nextSeqRecvCache map[ChannelKey]nextSeqRecvState
+	headOfLineBlocks map[ChannelKey]headOfLineBlock
+
+	// Number of packet messages scheduled to be sent to this chain for each channel in the latest block.
+	scheduledMsgs       map[string]uint64
+	scheduledMsgsHeight uint64
+
+	// Checks of the client against the misbehaviour witnesses of the counterparty chain, run in the background,
+	// and the signal that misbehaviour was detected by them, see checkForWitnessMisbehaviour.
+	witnessChecks        chan witnessCheck
+	witnessChecksOnce    sync.Once
+	misbehaviourDetected chan struct{}
+
+	// Time until which sending messages to this chain is paused because a fee budget of the signing key is exceeded.
+	feeBudgetPausedUntil time.Time
+	feeBudgetMu          sync.Mutex
+
 	metrics *PrometheusMetrics
 }
 
@@ -62,6 +100,8 @@ func newPathEndRuntime(log *zap.Logger, pathEnd PathEnd, metrics *PrometheusMetr
 		),
 		info:                 pathEnd,
 		incomingCacheData:    make(chan ChainProcessorCacheData, 100),
+		witnessChecks:        make(chan witnessCheck, 100),
+		misbehaviourDetected: make(chan struct{}, 1),
 		connectionStateCache: make(ConnectionStateCache),
 		channelStateCache:    make(ChannelStateCache),
 		messageCache:         NewIBCMessagesCache(),
@@ -71,6 +111,14 @@ func newPathEndRuntime(log *zap.Logger, pathEnd PathEnd, metrics *PrometheusMetr
 		channelProcessing:    make(channelProcessingCache),
 		clientICQProcessing:  make(clientICQProcessingCache),
 		connSubscribers:      make(map[string][]func(provider.ConnectionInfo)),
+		packetFees:           make(packetFeeCache),
+		packetFeesHeight:     make(map[ChannelKey]uint64),
+		packetFilterResults:  make(map[ChannelKey]map[uint64]bool),
+		lastPacketErrors:     make(map[packetMessageKey]string),
+		nextSeqRecvCache:     make(map[ChannelKey]nextSeqRecvState),
+		headOfLineBlocks:     make(map[ChannelKey]headOfLineBlock),
+		scheduledMsgs:        make(map[string]uint64),
+		retryPolicy:          DefaultRetryPolicy(),
 		metrics:              metrics,
 	}
 }
@@ -311,17 +359,111 @@ func (pathEnd *pathEndRuntime) checkForMisbehaviour(
 		return false, nil
 	}
 
-	msgMisbehaviour, err := pathEnd.chainProvider.MsgSubmitMisbehaviour(pathEnd.info.ClientID, misbehaviour)
-	if err != nil {
-		return true, err
+	return true, pathEnd.submitMisbehaviour(ctx, misbehaviour)
+}
+
+// witnessCheck is a check of the light client on a path end against the misbehaviour witnesses of the counterparty chain,
+// for the advance of the consensus height of the client from the trusted height.
+type witnessCheck struct {
+	trustedHeight   clienttypes.Height
+	consensusHeight clienttypes.Height
+	trustingPeriod  time.Duration
+	counterparty    provider.ChainProvider
+}
+
+// checkForWitnessMisbehaviour is called when the consensus height of the light client on this path end advances from
+// the trusted height. The header of the counterparty chain at the new consensus height, as queried from the RPC node
+// of the counterparty chain, is compared with the headers of the independent witness nodes of the counterparty chain.
+// The header submitted in the MsgUpdateClient is compared with the header of the RPC node by checkForMisbehaviour.
+// The witnesses are queried in the background, see runWitnessChecks, so that merging new data is not blocked.
+// If no witnesses are configured for the counterparty chain, nothing is queried.
+func (pathEnd *pathEndRuntime) checkForWitnessMisbehaviour(
+	ctx context.Context,
+	trustedHeight clienttypes.Height,
+	counterparty *pathEndRuntime,
+) {
+	consensusHeight := pathEnd.clientState.ConsensusHeight
+	if counterparty.chainProvider == nil || trustedHeight.RevisionHeight == 0 ||
+		trustedHeight.RevisionNumber != consensusHeight.RevisionNumber || !consensusHeight.GT(trustedHeight) {
+		return
 	}
 
-	_, _, err = pathEnd.chainProvider.SendMessage(ctx, msgMisbehaviour, "")
+	pathEnd.witnessChecksOnce.Do(func() {
+		go pathEnd.runWitnessChecks(ctx)
+	})
+
+	select {
+	case pathEnd.witnessChecks <- witnessCheck{
+		trustedHeight:   trustedHeight,
+		consensusHeight: consensusHeight,
+		trustingPeriod:  pathEnd.clientState.TrustingPeriod,
+		counterparty:    counterparty.chainProvider,
+	}:
+	default:
+		pathEnd.log.Warn("Too many pending checks for misbehaviour with witnesses, skipping check",
+			zap.Uint64("trusted_height", trustedHeight.RevisionHeight),
+			zap.Uint64("consensus_height", consensusHeight.RevisionHeight),
+		)
+	}
+}
+
+// runWitnessChecks runs the checks against the misbehaviour witnesses one after another until the context is done.
+// If misbehaviour is detected, a MsgSubmitMisbehaviour will be composed and broadcasted to freeze the light client,
+// and misbehaviourDetected is signaled so that the path processor terminates.
+func (pathEnd *pathEndRuntime) runWitnessChecks(ctx context.Context) {
+	for {
+		var check witnessCheck
+		select {
+		case <-ctx.Done():
+			return
+		case check = <-pathEnd.witnessChecks:
+		}
+
+		queryCtx, cancel := context.WithTimeout(ctx, witnessMisbehaviourTimeout)
+		misbehaviour, err := check.counterparty.QueryWitnessMisbehaviour(
+			queryCtx,
+			pathEnd.info.ClientID,
+			check.trustedHeight,
+			int64(check.consensusHeight.RevisionHeight),
+			check.trustingPeriod,
+		)
+		cancel()
+		if err != nil {
+			pathEnd.log.Error(
+				"Failed to check for misbehaviour with witnesses",
+				zap.String("client_id", pathEnd.info.ClientID),
+				zap.Error(err),
+			)
+			continue
+		}
+		if misbehaviour == nil {
+			continue
+		}
+
+		if err := pathEnd.submitMisbehaviour(ctx, misbehaviour); err != nil {
+			pathEnd.log.Error(
+				"Failed to submit misbehaviour detected with witnesses",
+				zap.String("client_id", pathEnd.info.ClientID),
+				zap.Error(err),
+			)
+		}
+		select {
+		case pathEnd.misbehaviourDetected <- struct{}{}:
+		default:
+		}
+		return
+	}
+}
+
+// submitMisbehaviour broadcasts a MsgSubmitMisbehaviour with the misbehaviour to freeze the light client on this path end.
+func (pathEnd *pathEndRuntime) submitMisbehaviour(ctx context.Context, misbehaviour ibcexported.ClientMessage) error {
+	msgMisbehaviour, err := pathEnd.chainProvider.MsgSubmitMisbehaviour(pathEnd.info.ClientID, misbehaviour)
 	if err != nil {
-		return true, err
+		return err
 	}
 
-	return true, nil
+	_, _, err = pathEnd.chainProvider.SendMessage(ctx, msgMisbehaviour, "")
+	return err
 }
 
 func (pathEnd *pathEndRuntime) mergeCacheData(ctx context.Context, cancel func(), d ChainProcessorCacheData, counterpartyChainID string, counterpartyInSync bool, messageLifecycle MessageLifecycle, counterParty *pathEndRuntime) {
@@ -331,6 +473,7 @@ func (pathEnd *pathEndRuntime) mergeCacheData(ctx context.Context, cancel func()
 
 	pathEnd.inSync = d.InSync
 	pathEnd.latestHeader = d.LatestHeader
+	trustedHeight := pathEnd.clientState.ConsensusHeight
 	pathEnd.clientState = d.ClientState
 
 	terminate, err := pathEnd.checkForMisbehaviour(ctx, pathEnd.clientState, counterParty)
@@ -342,6 +485,10 @@ func (pathEnd *pathEndRuntime) mergeCacheData(ctx context.Context, cancel func()
 		)
 	}
 
+	if !terminate {
+		pathEnd.checkForWitnessMisbehaviour(ctx, trustedHeight, counterParty)
+	}
+
 	if d.ClientState.ConsensusHeight != pathEnd.clientState.ConsensusHeight {
 		pathEnd.clientState = d.ClientState
 		ibcHeader, ok := counterParty.ibcHeaderCache[d.ClientState.ConsensusHeight.RevisionHeight]
@@ -423,24 +570,19 @@ func (pathEnd *pathEndRuntime) shouldSendPacketMessage(message packetIBCMessage,
 		return true
 	}
 	blocksSinceLastProcessed := pathEnd.latestBlock.Height - inProgress.lastProcessedHeight
-	if inProgress.assembled {
-		if blocksSinceLastProcessed < blocksToRetrySendAfter {
-			// this message was sent less than blocksToRetrySendAfter ago, do not attempt to send again yet.
-			return false
-		}
-	} else {
-		if blocksSinceLastProcessed < blocksToRetryAssemblyAfter {
-			// this message was sent less than blocksToRetryAssemblyAfter ago, do not attempt assembly again yet.
-			return false
-		}
+	if blocksSinceLastProcessed < inProgress.retryAfterBlocks {
+		// this message was attempted less than retryAfterBlocks ago, do not attempt again yet.
+		return false
 	}
-	if inProgress.retryCount >= maxMessageSendRetries {
+	maxRetries := pathEnd.retryPolicy.forMessageType(MessageTypePacket).MaxRetries
+	if inProgress.retryCount >= maxRetries {
 		pathEnd.log.Error("Giving up on sending packet message after max retries",
 			zap.String("event_type", eventType),
 			zap.Uint64("sequence", sequence),
 			zap.Inline(k),
-			zap.Int("max_retries", maxMessageSendRetries),
+			zap.Uint64("max_retries", maxRetries),
 		)
+		pathEnd.recordDeadLetter(message, k, inProgress.retryCount, counterparty)
 		pathEnd.removePacketRetention(counterparty, eventType, k, sequence)
 		return false
 	}
@@ -499,20 +641,15 @@ func (pathEnd *pathEndRuntime) shouldSendConnectionMessage(message connectionIBC
 		return true
 	}
 	blocksSinceLastProcessed := pathEnd.latestBlock.Height - inProgress.lastProcessedHeight
-	if inProgress.assembled {
-		if blocksSinceLastProcessed < blocksToRetrySendAfter {
-			// this message was sent less than blocksToRetrySendAfter ago, do not attempt to send again yet.
-			return false
-		}
-	} else {
-		if blocksSinceLastProcessed < blocksToRetryAssemblyAfter {
-			// this message was sent less than blocksToRetryAssemblyAfter ago, do not attempt assembly again yet.
-			return false
-		}
+	if blocksSinceLastProcessed < inProgress.retryAfterBlocks {
+		// this message was attempted less than retryAfterBlocks ago, do not attempt again yet.
+		return false
 	}
-	if inProgress.retryCount >= maxMessageSendRetries {
+	maxRetries := pathEnd.retryPolicy.forMessageType(MessageTypeConnection).MaxRetries
+	if inProgress.retryCount >= maxRetries {
 		pathEnd.log.Error("Giving up on sending connection message after max retries",
 			zap.String("event_type", eventType),
+			zap.Uint64("max_retries", maxRetries),
 		)
 		// giving up on sending this connection handshake message
 		// remove all retention of this connection handshake in pathEnd.messagesCache.ConnectionHandshake and counterparty
@@ -566,21 +703,15 @@ func (pathEnd *pathEndRuntime) shouldSendChannelMessage(message channelIBCMessag
 		return true
 	}
 	blocksSinceLastProcessed := pathEnd.latestBlock.Height - inProgress.lastProcessedHeight
-	if inProgress.assembled {
-		if blocksSinceLastProcessed < blocksToRetrySendAfter {
-			// this message was sent less than blocksToRetrySendAfter ago, do not attempt to send again yet.
-			return false
-		}
-	} else {
-		if blocksSinceLastProcessed < blocksToRetryAssemblyAfter {
-			// this message was sent less than blocksToRetryAssemblyAfter ago, do not attempt assembly again yet.
-			return false
-		}
+	if blocksSinceLastProcessed < inProgress.retryAfterBlocks {
+		// this message was attempted less than retryAfterBlocks ago, do not attempt again yet.
+		return false
 	}
-	if inProgress.retryCount >= maxMessageSendRetries {
+	maxRetries := pathEnd.retryPolicy.forMessageType(MessageTypeChannel).MaxRetries
+	if inProgress.retryCount >= maxRetries {
 		pathEnd.log.Error("Giving up on sending channel message after max retries",
 			zap.String("event_type", eventType),
-			zap.Int("max_retries", maxMessageSendRetries),
+			zap.Uint64("max_retries", maxRetries),
 		)
 		// giving up on sending this channel handshake message
 		// remove all retention of this connection handshake in pathEnd.messagesCache.ConnectionHandshake and counterparty
@@ -654,20 +785,15 @@ func (pathEnd *pathEndRuntime) shouldSendClientICQMessage(message provider.Clien
 		return true
 	}
 	blocksSinceLastProcessed := pathEnd.latestBlock.Height - inProgress.lastProcessedHeight
-	if inProgress.assembled {
-		if blocksSinceLastProcessed < blocksToRetrySendAfter {
-			// this message was sent less than blocksToRetrySendAfter ago, do not attempt to send again yet.
-			return false
-		}
-	} else {
-		if blocksSinceLastProcessed < blocksToRetryAssemblyAfter {
-			// this message was sent less than blocksToRetryAssemblyAfter ago, do not attempt assembly again yet.
-			return false
-		}
+	if blocksSinceLastProcessed < inProgress.retryAfterBlocks {
+		// this message was attempted less than retryAfterBlocks ago, do not attempt again yet.
+		return false
 	}
-	if inProgress.retryCount >= maxMessageSendRetries {
+	maxRetries := pathEnd.retryPolicy.forMessageType(MessageTypeClientICQ).MaxRetries
+	if inProgress.retryCount >= maxRetries {
 		pathEnd.log.Error("Giving up on sending client ICQ message after max retries",
 			zap.String("query_id", string(queryID)),
+			zap.Uint64("max_retries", maxRetries),
 		)
 
 		// giving up on this query
@@ -685,6 +811,7 @@ func (pathEnd *pathEndRuntime) shouldSendClientICQMessage(message provider.Clien
 
 func (pathEnd *pathEndRuntime) trackProcessingMessage(tracker messageToTrack) uint64 {
 	retryCount := uint64(0)
+	retryPolicy := pathEnd.retryPolicy.forMessageType(trackerMessageType(tracker))
 
 	switch t := tracker.(type) {
 	case packetMessageToTrack:
@@ -718,6 +845,7 @@ func (pathEnd *pathEndRuntime) trackProcessingMessage(tracker messageToTrack) ui
 		channelProcessingCache[sequence] = processingMessage{
 			lastProcessedHeight: pathEnd.latestBlock.Height,
 			retryCount:          retryCount,
+			retryAfterBlocks:    retryPolicy.retryAfterBlocks(t.assembled != nil, retryCount),
 			assembled:           t.assembled != nil,
 		}
 	case channelMessageToTrack:
@@ -736,6 +864,7 @@ func (pathEnd *pathEndRuntime) trackProcessingMessage(tracker messageToTrack) ui
 		msgProcessCache[channelKey] = processingMessage{
 			lastProcessedHeight: pathEnd.latestBlock.Height,
 			retryCount:          retryCount,
+			retryAfterBlocks:    retryPolicy.retryAfterBlocks(t.assembled != nil, retryCount),
 			assembled:           t.assembled != nil,
 		}
 	case connectionMessageToTrack:
@@ -754,6 +883,7 @@ func (pathEnd *pathEndRuntime) trackProcessingMessage(tracker messageToTrack) ui
 		msgProcessCache[connectionKey] = processingMessage{
 			lastProcessedHeight: pathEnd.latestBlock.Height,
 			retryCount:          retryCount,
+			retryAfterBlocks:    retryPolicy.retryAfterBlocks(t.assembled != nil, retryCount),
 			assembled:           t.assembled != nil,
 		}
 	case clientICQMessageToTrack:
@@ -766,6 +896,7 @@ func (pathEnd *pathEndRuntime) trackProcessingMessage(tracker messageToTrack) ui
 		pathEnd.clientICQProcessing[queryID] = processingMessage{
 			lastProcessedHeight: pathEnd.latestBlock.Height,
 			retryCount:          retryCount,
+			retryAfterBlocks:    retryPolicy.retryAfterBlocks(t.assembled != nil, retryCount),
 			assembled:           t.assembled != nil,
 		}
 	}
@@ -10,10 +10,6 @@ import (
 )
 
 const (
-	// durationErrorRetry determines how long to wait before retrying
-	// in the case of failure to send transactions with IBC messages.
-	durationErrorRetry = 5 * time.Second
-
 	// Amount of time to wait when sending transactions before giving up
 	// and continuing on. Messages will be retried later if they are still
 	// relevant.
@@ -27,17 +23,13 @@ const (
 	// Amount of time to wait for interchain queries.
 	interchainQueryTimeout = 60 * time.Second
 
-	// If message assembly fails from either proof query failure on the source
-	// or assembling the message for the destination, how many blocks should pass
-	// before retrying.
-	blocksToRetryAssemblyAfter = 0
-
-	// If the message was assembled successfully, but sending the message failed,
-	// how many blocks should pass before retrying.
-	blocksToRetrySendAfter = 5
+	// Amount of time to wait for the escrowed packet fees to be queried.
+	// Fees from the previous query will be used if this is exceeded.
+	packetFeeQueryTimeout = 5 * time.Second
 
-	// How many times to retry sending a message before giving up on it.
-	maxMessageSendRetries = 5
+	// Amount of time to wait for the headers of the witnesses to be compared
+	// with the header of a client update to detect misbehaviour.
+	witnessMisbehaviourTimeout = 30 * time.Second
 
 	// How many blocks of history to retain ibc headers in the cache for.
 	ibcHeadersToCache = 10
@@ -58,6 +50,10 @@ type PathProcessor struct {
 
 	memo string
 
+	// Limits of each transaction sent in batch mode, zero if unlimited.
+	maxTxSize uint64
+	maxMsgs   uint64
+
 	clientUpdateThresholdTime time.Duration
 
 	messageLifecycle MessageLifecycle
@@ -71,7 +67,28 @@ type PathProcessor struct {
 
 	sentInitialMsg bool
 
+	feePolicy FeePolicy
+
+	packetFilter *PacketFilter
+
+	retryPolicy RetryPolicy
+
+	channelLimits ChannelLimits
+
+	// Persists the message caches across restarts, nil if disabled.
+	stateStore *StateStore
+	// When the message caches were last saved, see saveStateIfDue.
+	stateSaved time.Time
+
+	// Records the packet messages which are given up on, nil if disabled.
+	deadLetterStore  *DeadLetterStore
+	deadLetterTicker <-chan time.Time
+
 	metrics *PrometheusMetrics
+
+	// When the status of the clients was last checked, and whether a check was requested after an error, see inactiveClient.
+	clientStatusChecked   time.Time
+	clientStatusRequested bool
 }
 
 // PathProcessors is a slice of PathProcessor instances
@@ -107,6 +124,8 @@ func NewPathProcessor(
 		memo:                      memo,
 		clientUpdateThresholdTime: clientUpdateThresholdTime,
 		flushInterval:             flushInterval,
+		feePolicy:                 FeePolicyRelayAll,
+		retryPolicy:               DefaultRetryPolicy(),
 		metrics:                   metrics,
 	}
 }
@@ -115,6 +134,49 @@ func (pp *PathProcessor) SetMessageLifecycle(messageLifecycle MessageLifecycle)
 	pp.messageLifecycle = messageLifecycle
 }
 
+// SetFeePolicy sets how packets incentivized with the ICS-29 fee middleware are relayed.
+func (pp *PathProcessor) SetFeePolicy(feePolicy FeePolicy) {
+	pp.feePolicy = feePolicy
+}
+
+// SetPacketFilter sets the filter applied to the packet data before relaying MsgRecvPacket.
+func (pp *PathProcessor) SetPacketFilter(packetFilter *PacketFilter) {
+	pp.packetFilter = packetFilter
+}
+
+// SetRetryPolicy sets when messages which failed to be assembled or sent are retried.
+func (pp *PathProcessor) SetRetryPolicy(retryPolicy RetryPolicy) {
+	pp.retryPolicy = retryPolicy
+	pp.pathEnd1.retryPolicy = retryPolicy
+	pp.pathEnd2.retryPolicy = retryPolicy
+}
+
+// SetChannelLimits sets the priorities and limits for sending the packet messages of each channel.
+func (pp *PathProcessor) SetChannelLimits(channelLimits ChannelLimits) {
+	pp.channelLimits = channelLimits
+}
+
+// SetBatchLimits sets the maximum bytes and number of messages of each transaction sent
+// when the broadcast mode is batch, not counting the MsgUpdateClient. Zero means unlimited.
+func (pp *PathProcessor) SetBatchLimits(maxTxSize, maxMsgs uint64) {
+	pp.maxTxSize = maxTxSize
+	pp.maxMsgs = maxMsgs
+}
+
+// SetStateStore enables persisting the pending and in-flight message caches,
+// which will be restored when the PathProcessor is run.
+func (pp *PathProcessor) SetStateStore(stateStore *StateStore) {
+	pp.stateStore = stateStore
+}
+
+// SetDeadLetterStore enables recording the packet messages which are given up on after the maximum
+// number of retries, and replaying the ones which are requested to be replayed.
+func (pp *PathProcessor) SetDeadLetterStore(deadLetterStore *DeadLetterStore) {
+	pp.deadLetterStore = deadLetterStore
+	pp.pathEnd1.deadLetterStore = deadLetterStore
+	pp.pathEnd2.deadLetterStore = deadLetterStore
+}
+
 // TEST USE ONLY
 func (pp *PathProcessor) PathEnd1Messages(channelKey ChannelKey, message string) PacketSequenceCache {
 	return pp.pathEnd1.messageCache.PacketFlow[channelKey][message]
@@ -266,11 +328,23 @@ func (pp *PathProcessor) processAvailableSignals(ctx context.Context, cancel fun
 		// we have new data from ChainProcessor for pathEnd2
 		pp.pathEnd2.mergeCacheData(ctx, cancel, d, pp.pathEnd1.info.ChainID, pp.pathEnd1.inSync, pp.messageLifecycle, pp.pathEnd1)
 
+	case <-pp.pathEnd1.misbehaviourDetected:
+		// the misbehaviour witnesses detected misbehaviour for the client on pathEnd1, which has been frozen.
+		cancel()
+		return true
+	case <-pp.pathEnd2.misbehaviourDetected:
+		// the misbehaviour witnesses detected misbehaviour for the client on pathEnd2, which has been frozen.
+		cancel()
+		return true
+
 	case <-pp.retryProcess:
 		// No new data to merge in, just retry handling.
 	case <-pp.flushTicker.C:
 		// Periodic flush to clear out any old packets
 		pp.flush(ctx)
+	case <-pp.deadLetterTicker:
+		// Pick up dead letters requested to be replayed while running
+		pp.replayDeadLetters()
 	}
 	return false
 }
@@ -278,10 +352,27 @@ func (pp *PathProcessor) processAvailableSignals(ctx context.Context, cancel fun
 // Run executes the main path process.
 func (pp *PathProcessor) Run(ctx context.Context, cancel func()) {
 	var retryTimer *time.Timer
+	var consecutiveErrors uint64
 
 	pp.flushTicker = time.NewTicker(pp.flushInterval)
 	defer pp.flushTicker.Stop()
 
+	// save the message caches once more when stopping, as they are only saved every StateSaveInterval while running.
+	defer pp.saveState()
+
+	// The initial flush still runs after restoring the message caches. The chain checkpoints
+	// are saved independently of the path state, so a crash can leave the chains resuming past
+	// blocks whose packets never made it into the path state. The restored in-flight messages
+	// keep their retry state, so they are not broadcast again before they are due.
+	pp.restoreState()
+
+	if pp.deadLetterStore != nil {
+		deadLetterTicker := time.NewTicker(deadLetterReplayInterval)
+		defer deadLetterTicker.Stop()
+		pp.deadLetterTicker = deadLetterTicker.C
+		pp.replayDeadLetters()
+	}
+
 	for {
 		// block until we have any signals to process
 		if pp.processAvailableSignals(ctx, cancel) {
@@ -296,6 +387,7 @@ func (pp *PathProcessor) Run(ctx context.Context, cancel func()) {
 		}
 
 		if !pp.pathEnd1.inSync || !pp.pathEnd2.inSync {
+			pp.saveStateIfDue()
 			continue
 		}
 
@@ -307,15 +399,30 @@ func (pp *PathProcessor) Run(ctx context.Context, cancel func()) {
 			return
 		}
 
+		if err := pp.inactiveClient(ctx); err != nil {
+			if !pp.halt(ctx, cancel, err) {
+				return
+			}
+			continue
+		}
+
 		// process latest message cache state from both pathEnds
 		if err := pp.processLatestMessages(ctx); err != nil {
-			// in case of IBC message send errors, schedule retry after durationErrorRetry
+			pp.checkClientStatus()
+
+			// in case of IBC message send errors, schedule retry after the error retry delay,
+			// backing off for consecutive errors.
 			if retryTimer != nil {
 				retryTimer.Stop()
 			}
 			if ctx.Err() == nil {
-				retryTimer = time.AfterFunc(durationErrorRetry, pp.ProcessBacklogIfReady)
+				retryTimer = time.AfterFunc(pp.retryPolicy.errorRetryDelay(consecutiveErrors), pp.ProcessBacklogIfReady)
 			}
+			consecutiveErrors++
+		} else {
+			consecutiveErrors = 0
 		}
+
+		pp.saveStateIfDue()
 	}
 }
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"errors"
-	"sort"
 	"sync"
 
 	conntypes "github.com/cosmos/ibc-go/v7/modules/core/03-connection/types"
@@ -14,36 +13,24 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
-// getMessagesToSend returns only the lowest sequence message (if it should be sent) for ordered channels,
-// otherwise returns all which should be sent.
+// getMessagesToSend returns the messages which should be sent, in sequence order for ordered channels.
 func (pp *PathProcessor) getMessagesToSend(
+	ctx context.Context,
 	msgs []packetIBCMessage,
 	src, dst *pathEndRuntime,
 ) (srcMsgs []packetIBCMessage, dstMsgs []packetIBCMessage) {
 	if len(msgs) == 0 {
 		return
 	}
-	if msgs[0].info.ChannelOrder == chantypes.ORDERED.String() {
-		// for packet messages on ordered channels, only handle the lowest sequence number now.
-		sort.SliceStable(msgs, func(i, j int) bool {
-			return msgs[i].info.Sequence < msgs[j].info.Sequence
-		})
-		firstMsg := msgs[0]
-		switch firstMsg.eventType {
-		case chantypes.EventTypeRecvPacket:
-			if dst.shouldSendPacketMessage(firstMsg, src) {
-				dstMsgs = append(dstMsgs, firstMsg)
-			}
-		default:
-			if src.shouldSendPacketMessage(firstMsg, dst) {
-				srcMsgs = append(srcMsgs, firstMsg)
-			}
-		}
-		return srcMsgs, dstMsgs
+	if msgs[0].isOrdered() {
+		return pp.getOrderedMessagesToSend(ctx, msgs, src, dst)
 	}
 
 	// for unordered channels, can handle multiple simultaneous packets.
 	for _, msg := range msgs {
+		if !pp.shouldRelayForFee(msg, src) || !pp.shouldRelayPacketContent(msg, src) {
+			continue
+		}
 		switch msg.eventType {
 		case chantypes.EventTypeRecvPacket:
 			if dst.shouldSendPacketMessage(msg, src) {
@@ -177,7 +164,7 @@ MsgTransferLoop:
 		msgs = append(msgs, recvPacketMsg)
 	}
 
-	res.SrcMessages, res.DstMessages = pp.getMessagesToSend(msgs, pathEndPacketFlowMessages.Src, pathEndPacketFlowMessages.Dst)
+	res.SrcMessages, res.DstMessages = pp.getMessagesToSend(ctx, msgs, pathEndPacketFlowMessages.Src, pathEndPacketFlowMessages.Dst)
 
 	// now iterate through packet-flow-complete messages and remove any leftover messages if the MsgTransfer or MsgRecvPacket was in a previous block that we did not query
 	for ackSeq := range pathEndPacketFlowMessages.SrcMsgAcknowledgement {
@@ -514,6 +501,16 @@ func (pp *PathProcessor) processLatestMessages(ctx context.Context) error {
 
 	channelPairs := pp.channelPairs()
 
+	pp.updatePacketFees(ctx, channelPairs)
+
+	if pp.packetFilter != nil {
+		pp.pathEnd1.prunePacketFilterResults()
+		pp.pathEnd2.prunePacketFilterResults()
+	}
+
+	pp.pathEnd1.pruneLastPacketErrors()
+	pp.pathEnd2.pruneLastPacketErrors()
+
 	pathEnd1ConnectionHandshakeMessages := pathEndConnectionHandshakeMessages{
 		Src:                         pp.pathEnd1,
 		Dst:                         pp.pathEnd2,
@@ -654,11 +651,11 @@ func (pp *PathProcessor) processLatestMessages(ctx context.Context) error {
 	// if sending messages fails to one pathEnd, we don't need to halt sending to the other pathEnd.
 	var eg errgroup.Group
 	eg.Go(func() error {
-		mp := newMessageProcessor(pp.log, pp.metrics, pp.memo, pp.clientUpdateThresholdTime)
+		mp := newMessageProcessor(pp.log, pp.metrics, pp.memo, pp.clientUpdateThresholdTime, pp.maxTxSize, pp.maxMsgs)
 		return mp.processMessages(ctx, pathEnd1Messages, pp.pathEnd2, pp.pathEnd1)
 	})
 	eg.Go(func() error {
-		mp := newMessageProcessor(pp.log, pp.metrics, pp.memo, pp.clientUpdateThresholdTime)
+		mp := newMessageProcessor(pp.log, pp.metrics, pp.memo, pp.clientUpdateThresholdTime, pp.maxTxSize, pp.maxMsgs)
 		return mp.processMessages(ctx, pathEnd2Messages, pp.pathEnd1, pp.pathEnd2)
 	})
 	return eg.Wait()
@@ -717,30 +714,36 @@ func (pp *PathProcessor) packetMessagesToSend(
 	pathEnd1ProcessRes []pathEndPacketFlowResponse,
 	pathEnd2ProcessRes []pathEndPacketFlowResponse,
 ) ([]packetIBCMessage, []packetIBCMessage, []channelIBCMessage, []channelIBCMessage) {
-	pathEnd1PacketLen := 0
-	pathEnd2PacketLen := 0
 	pathEnd1ChannelLen := 0
 	pathEnd2ChannelLen := 0
 
 	for i := 0; i < len(channelPairs); i++ {
-		pathEnd1PacketLen += len(pathEnd2ProcessRes[i].DstMessages) + len(pathEnd1ProcessRes[i].SrcMessages)
-		pathEnd2PacketLen += len(pathEnd1ProcessRes[i].DstMessages) + len(pathEnd2ProcessRes[i].SrcMessages)
 		pathEnd1ChannelLen += len(pathEnd2ProcessRes[i].DstChannelMessage)
 		pathEnd2ChannelLen += len(pathEnd1ProcessRes[i].DstChannelMessage)
 	}
 
-	pathEnd1PacketMessages := make([]packetIBCMessage, 0, pathEnd1PacketLen)
-	pathEnd2PacketMessages := make([]packetIBCMessage, 0, pathEnd2PacketLen)
+	pathEnd1ChannelPacketMessages := make([]channelPacketMessages, 0, len(channelPairs))
+	pathEnd2ChannelPacketMessages := make([]channelPacketMessages, 0, len(channelPairs))
 
 	pathEnd1ChannelMessage := make([]channelIBCMessage, 0, pathEnd1ChannelLen)
 	pathEnd2ChannelMessage := make([]channelIBCMessage, 0, pathEnd2ChannelLen)
 
 	for i, channelPair := range channelPairs {
+		pathEnd1PacketMessages := make([]packetIBCMessage, 0, len(pathEnd2ProcessRes[i].DstMessages)+len(pathEnd1ProcessRes[i].SrcMessages))
 		pathEnd1PacketMessages = append(pathEnd1PacketMessages, pathEnd2ProcessRes[i].DstMessages...)
 		pathEnd1PacketMessages = append(pathEnd1PacketMessages, pathEnd1ProcessRes[i].SrcMessages...)
+		pathEnd1ChannelPacketMessages = append(pathEnd1ChannelPacketMessages, channelPacketMessages{
+			channelID: channelPair.pathEnd1ChannelKey.ChannelID,
+			msgs:      pathEnd1PacketMessages,
+		})
 
+		pathEnd2PacketMessages := make([]packetIBCMessage, 0, len(pathEnd1ProcessRes[i].DstMessages)+len(pathEnd2ProcessRes[i].SrcMessages))
 		pathEnd2PacketMessages = append(pathEnd2PacketMessages, pathEnd1ProcessRes[i].DstMessages...)
 		pathEnd2PacketMessages = append(pathEnd2PacketMessages, pathEnd2ProcessRes[i].SrcMessages...)
+		pathEnd2ChannelPacketMessages = append(pathEnd2ChannelPacketMessages, channelPacketMessages{
+			channelID: channelPair.pathEnd1ChannelKey.ChannelID,
+			msgs:      pathEnd2PacketMessages,
+		})
 
 		pathEnd1ChannelMessage = append(pathEnd1ChannelMessage, pathEnd2ProcessRes[i].DstChannelMessage...)
 		pathEnd2ChannelMessage = append(pathEnd2ChannelMessage, pathEnd1ProcessRes[i].DstChannelMessage...)
@@ -758,6 +761,9 @@ func (pp *PathProcessor) packetMessagesToSend(
 		pp.pathEnd2.packetProcessing[channelPair.pathEnd2ChannelKey].deleteMessages(pathEnd2ProcessRes[i].ToDeleteSrc, pathEnd1ProcessRes[i].ToDeleteDst)
 	}
 
+	pathEnd1PacketMessages := pp.schedulePacketMessages(pathEnd1ChannelPacketMessages, pp.pathEnd2, pp.pathEnd1)
+	pathEnd2PacketMessages := pp.schedulePacketMessages(pathEnd2ChannelPacketMessages, pp.pathEnd1, pp.pathEnd2)
+
 	return pathEnd1PacketMessages, pathEnd2PacketMessages, pathEnd1ChannelMessage, pathEnd2ChannelMessage
 }
 
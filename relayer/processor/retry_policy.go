@@ -0,0 +1,185 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// MessageType identifies a category of IBC messages for retry purposes.
+type MessageType string
+
+const (
+	MessageTypePacket     MessageType = "packet"
+	MessageTypeConnection MessageType = "connection"
+	MessageTypeChannel    MessageType = "channel"
+	MessageTypeClientICQ  MessageType = "client-icq"
+
+	// MessageTypeClientUpdate is the MsgUpdateClient sent on its own to keep a client from expiring.
+	// Only RetrySendAfterBlocks applies, as the number of blocks to wait between attempts.
+	MessageTypeClientUpdate MessageType = "client-update"
+)
+
+// maxRetryAfterBlocks bounds the backoff when no maximum is configured,
+// and the number of blocks to wait before the first retry.
+const maxRetryAfterBlocks = 1 << 20
+
+// MessageRetryPolicy determines when a message which failed to be assembled or sent
+// will be retried, and when it will be given up on.
+type MessageRetryPolicy struct {
+	// How many times to retry sending a message before giving up on it.
+	MaxRetries uint64
+
+	// If the message was assembled successfully, but sending the message failed,
+	// how many blocks should pass before the first retry.
+	RetrySendAfterBlocks uint64
+
+	// If message assembly failed, from either proof query failure on the source
+	// or assembling the message for the destination, how many blocks should pass
+	// before the first retry.
+	RetryAssemblyAfterBlocks uint64
+
+	// Factor by which the number of blocks to wait is multiplied for each subsequent retry.
+	// 1 retries at a constant interval.
+	BackoffMultiplier float64
+
+	// Upper bound of the number of blocks to wait between retries. 0 means unbounded.
+	MaxBackoffBlocks uint64
+
+	// Fraction of the number of blocks to wait which is randomly added to it,
+	// so that retries of many messages are spread out.
+	Jitter float64
+}
+
+// RetryPolicy holds the MessageRetryPolicy for each message type,
+// along with the delay for retrying after errors in processing messages.
+type RetryPolicy struct {
+	// Default applies to message types without an entry in MessageTypes.
+	Default      MessageRetryPolicy
+	MessageTypes map[MessageType]MessageRetryPolicy
+
+	// How long to wait before retrying in the case of failure to send transactions with IBC messages.
+	// Consecutive failures back off using the default BackoffMultiplier and Jitter, up to MaxErrorRetryDelay.
+	ErrorRetryDelay    time.Duration
+	MaxErrorRetryDelay time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy which is used when none is configured.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Default: MessageRetryPolicy{
+			MaxRetries:               5,
+			RetrySendAfterBlocks:     5,
+			RetryAssemblyAfterBlocks: 0,
+			BackoffMultiplier:        1,
+		},
+		ErrorRetryDelay:    5 * time.Second,
+		MaxErrorRetryDelay: 5 * time.Second,
+	}
+}
+
+// ParseMessageType validates a message type from the retry policy configuration.
+func ParseMessageType(t string) (MessageType, error) {
+	switch MessageType(t) {
+	case MessageTypePacket, MessageTypeConnection, MessageTypeChannel, MessageTypeClientICQ, MessageTypeClientUpdate:
+		return MessageType(t), nil
+	}
+	return "", fmt.Errorf("%s is not a valid message type, please ensure your message type is one of: [%s, %s, %s, %s, %s]",
+		t, MessageTypePacket, MessageTypeConnection, MessageTypeChannel, MessageTypeClientICQ, MessageTypeClientUpdate)
+}
+
+// Validate checks that the values of the policy are within their bounds.
+func (p MessageRetryPolicy) Validate() error {
+	if p.RetrySendAfterBlocks > maxRetryAfterBlocks {
+		return fmt.Errorf("retry send after blocks must be at most %d, got %d", maxRetryAfterBlocks, p.RetrySendAfterBlocks)
+	}
+	if p.RetryAssemblyAfterBlocks > maxRetryAfterBlocks {
+		return fmt.Errorf("retry assembly after blocks must be at most %d, got %d", maxRetryAfterBlocks, p.RetryAssemblyAfterBlocks)
+	}
+	if p.BackoffMultiplier < 1 {
+		return fmt.Errorf("backoff multiplier must be at least 1, got %v", p.BackoffMultiplier)
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1, got %v", p.Jitter)
+	}
+	return nil
+}
+
+// Validate checks the policies for all message types.
+func (p RetryPolicy) Validate() error {
+	if err := p.Default.Validate(); err != nil {
+		return err
+	}
+	for t, mp := range p.MessageTypes {
+		if _, err := ParseMessageType(string(t)); err != nil {
+			return err
+		}
+		if err := mp.Validate(); err != nil {
+			return fmt.Errorf("message type %s: %w", t, err)
+		}
+	}
+	if p.ErrorRetryDelay <= 0 {
+		return fmt.Errorf("error retry delay must be positive, got %s", p.ErrorRetryDelay)
+	}
+	if p.MaxErrorRetryDelay < p.ErrorRetryDelay {
+		return fmt.Errorf("max error retry delay %s must not be less than error retry delay %s", p.MaxErrorRetryDelay, p.ErrorRetryDelay)
+	}
+	return nil
+}
+
+// forMessageType returns the policy for the message type.
+func (p RetryPolicy) forMessageType(t MessageType) MessageRetryPolicy {
+	if mp, ok := p.MessageTypes[t]; ok {
+		return mp
+	}
+	return p.Default
+}
+
+// errorRetryDelay returns how long to wait before retrying after consecutiveErrors failed attempts to process messages.
+func (p RetryPolicy) errorRetryDelay(consecutiveErrors uint64) time.Duration {
+	delay := float64(p.ErrorRetryDelay) * math.Pow(p.Default.BackoffMultiplier, float64(consecutiveErrors))
+	if delay > float64(p.MaxErrorRetryDelay) {
+		delay = float64(p.MaxErrorRetryDelay)
+	}
+	return time.Duration(applyJitter(delay, p.Default.Jitter))
+}
+
+// retryAfterBlocks returns how many blocks to wait before retrying a message
+// which has been retried retryCount times.
+func (p MessageRetryPolicy) retryAfterBlocks(assembled bool, retryCount uint64) uint64 {
+	base := p.RetryAssemblyAfterBlocks
+	if assembled {
+		base = p.RetrySendAfterBlocks
+	}
+	blocks := float64(base) * math.Pow(p.BackoffMultiplier, float64(retryCount))
+	if p.MaxBackoffBlocks > 0 && blocks > float64(p.MaxBackoffBlocks) {
+		blocks = float64(p.MaxBackoffBlocks)
+	}
+	blocks = applyJitter(blocks, p.Jitter)
+	if blocks > maxRetryAfterBlocks {
+		return maxRetryAfterBlocks
+	}
+	return uint64(math.Round(blocks))
+}
+
+// applyJitter adds a random amount of up to jitter*v to v.
+func applyJitter(v, jitter float64) float64 {
+	if jitter == 0 {
+		return v
+	}
+	return v + v*jitter*rand.Float64()
+}
+
+// trackerMessageType returns the MessageType of the tracked message.
+func trackerMessageType(tracker messageToTrack) MessageType {
+	switch tracker.(type) {
+	case connectionMessageToTrack:
+		return MessageTypeConnection
+	case channelMessageToTrack:
+		return MessageTypeChannel
+	case clientICQMessageToTrack:
+		return MessageTypeClientICQ
+	}
+	return MessageTypePacket
+}
@@ -0,0 +1,106 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRetryAfterBlocks(t *testing.T) {
+	constant := MessageRetryPolicy{RetrySendAfterBlocks: 5, RetryAssemblyAfterBlocks: 1, BackoffMultiplier: 1}
+	for retryCount := uint64(0); retryCount < 5; retryCount++ {
+		require.Equal(t, uint64(5), constant.retryAfterBlocks(true, retryCount))
+		require.Equal(t, uint64(1), constant.retryAfterBlocks(false, retryCount))
+	}
+
+	backoff := MessageRetryPolicy{RetrySendAfterBlocks: 5, BackoffMultiplier: 2, MaxBackoffBlocks: 30}
+	var blocks []uint64
+	for retryCount := uint64(0); retryCount < 5; retryCount++ {
+		blocks = append(blocks, backoff.retryAfterBlocks(true, retryCount))
+	}
+	require.Equal(t, []uint64{5, 10, 20, 30, 30}, blocks, "doubled up to the maximum")
+
+	unbounded := MessageRetryPolicy{RetrySendAfterBlocks: 5, BackoffMultiplier: 2}
+	require.Equal(t, uint64(maxRetryAfterBlocks), unbounded.retryAfterBlocks(true, 100))
+
+	jitter := MessageRetryPolicy{RetrySendAfterBlocks: 10, BackoffMultiplier: 1, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		b := jitter.retryAfterBlocks(true, 0)
+		require.GreaterOrEqual(t, b, uint64(10))
+		require.LessOrEqual(t, b, uint64(15))
+	}
+}
+
+func TestErrorRetryDelay(t *testing.T) {
+	p := DefaultRetryPolicy()
+	require.Equal(t, 5*time.Second, p.errorRetryDelay(0))
+	require.Equal(t, 5*time.Second, p.errorRetryDelay(10), "constant without backoff")
+
+	p.Default.BackoffMultiplier = 2
+	p.MaxErrorRetryDelay = time.Minute
+	var delays []time.Duration
+	for consecutiveErrors := uint64(0); consecutiveErrors < 6; consecutiveErrors++ {
+		delays = append(delays, p.errorRetryDelay(consecutiveErrors))
+	}
+	require.Equal(t, []time.Duration{
+		5 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute,
+	}, delays)
+}
+
+func TestForMessageType(t *testing.T) {
+	p := DefaultRetryPolicy()
+	p.MessageTypes = map[MessageType]MessageRetryPolicy{
+		MessageTypeClientICQ: {MaxRetries: 1, BackoffMultiplier: 1},
+	}
+	require.Equal(t, uint64(1), p.forMessageType(MessageTypeClientICQ).MaxRetries)
+	require.Equal(t, p.Default, p.forMessageType(MessageTypePacket))
+}
+
+func TestShouldUpdateClientNowRetryPolicy(t *testing.T) {
+	mp := &messageProcessor{log: zap.NewNop()}
+	src := newPathEndRuntime(zap.NewNop(), PathEnd{ChainID: "chain-a"}, nil)
+	dst := newPathEndRuntime(zap.NewNop(), PathEnd{ChainID: "chain-b", ClientID: "07-tendermint-0"}, nil)
+
+	// past two thirds of the trusting period, last attempted 10 blocks ago.
+	dst.clientState = provider.ClientState{
+		TrustingPeriod: 3 * time.Hour,
+		ConsensusTime:  time.Now().Add(-2*time.Hour - time.Minute),
+	}
+	dst.latestBlock = provider.LatestBlock{Height: 110}
+	dst.lastClientUpdateHeight = 100
+
+	ok, err := mp.shouldUpdateClientNow(context.Background(), src, dst)
+	require.NoError(t, err)
+	require.True(t, ok, "default retry-send-after-blocks of 5 has passed")
+
+	dst.retryPolicy.MessageTypes = map[MessageType]MessageRetryPolicy{
+		MessageTypeClientUpdate: {RetrySendAfterBlocks: 20, BackoffMultiplier: 1},
+	}
+	ok, err = mp.shouldUpdateClientNow(context.Background(), src, dst)
+	require.NoError(t, err)
+	require.False(t, ok, "client-update retry-send-after-blocks of 20 has not passed")
+
+	dst.latestBlock = provider.LatestBlock{Height: 10}
+	dst.lastClientUpdateHeight = 0
+	ok, err = mp.shouldUpdateClientNow(context.Background(), src, dst)
+	require.NoError(t, err)
+	require.False(t, ok, "retry-send-after-blocks of 20 has not passed since the start of the chain")
+}
+
+func TestRetryPolicyValidate(t *testing.T) {
+	require.NoError(t, DefaultRetryPolicy().Validate())
+
+	p := DefaultRetryPolicy()
+	p.Default.RetrySendAfterBlocks = maxRetryAfterBlocks + 1
+	require.Error(t, p.Validate())
+
+	p = DefaultRetryPolicy()
+	p.MessageTypes = map[MessageType]MessageRetryPolicy{
+		MessageTypeClientUpdate: {RetrySendAfterBlocks: maxRetryAfterBlocks + 1, BackoffMultiplier: 1},
+	}
+	require.Error(t, p.Validate())
+}
@@ -0,0 +1,371 @@
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"go.uber.org/zap"
+)
+
+const (
+	stateStoreDir      = "state"
+	chainStateDir      = "chains"
+	pathStateDir       = "paths"
+	stateFileExtension = ".json"
+
+	// StateSaveInterval is how often the progress of ChainProcessors and PathProcessors is checkpointed while running.
+	// A final checkpoint is saved when they stop.
+	StateSaveInterval = 10 * time.Second
+)
+
+// StateStore persists the progress of ChainProcessors and PathProcessors to disk,
+// so that the relayer can resume where it stopped after a restart rather than
+// re-scanning block history and re-broadcasting messages which are already in flight.
+// A nil *StateStore disables persistence.
+type StateStore struct {
+	dir string
+}
+
+// NewStateStore returns a StateStore which keeps its files in the state directory under homePath.
+func NewStateStore(homePath string) (*StateStore, error) {
+	dir := filepath.Join(homePath, stateStoreDir)
+	for _, d := range []string{chainStateDir, pathStateDir} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create state directory: %w", err)
+		}
+	}
+	return &StateStore{dir: dir}, nil
+}
+
+// chainState is the persisted progress of a ChainProcessor.
+type chainState struct {
+	LatestQueriedBlock int64 `json:"latest_queried_block"`
+}
+
+// LatestQueriedBlock returns the last block height which was fully processed for the chain.
+// The returned bool is false if no checkpoint exists for the chain.
+func (s *StateStore) LatestQueriedBlock(chainID string) (int64, bool, error) {
+	var state chainState
+	ok, err := readJSONFile(s.chainStatePath(chainID), &state)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	return state.LatestQueriedBlock, true, nil
+}
+
+// SaveLatestQueriedBlock checkpoints the last block height which was fully processed for the chain.
+func (s *StateStore) SaveLatestQueriedBlock(chainID string, height int64) error {
+	return writeJSONFile(s.chainStatePath(chainID), chainState{LatestQueriedBlock: height})
+}
+
+func (s *StateStore) chainStatePath(chainID string) string {
+	return filepath.Join(s.dir, chainStateDir, chainID+stateFileExtension)
+}
+
+func (s *StateStore) pathStatePath(pathName string) string {
+	return filepath.Join(s.dir, pathStateDir, pathName+stateFileExtension)
+}
+
+// loadPathState returns the persisted caches for the path, or nil if none exist.
+func (s *StateStore) loadPathState(pathName string) (*pathState, error) {
+	var state pathState
+	ok, err := readJSONFile(s.pathStatePath(pathName), &state)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (s *StateStore) savePathState(pathName string, state pathState) error {
+	return writeJSONFile(s.pathStatePath(pathName), state)
+}
+
+// readJSONFile decodes the JSON file at path into v, returning false if the file does not exist.
+func readJSONFile(path string, v interface{}) (bool, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := json.Unmarshal(bz, v); err != nil {
+		return false, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// writeJSONFile atomically replaces the file at path with the JSON encoding of v,
+// so that a crash mid-write cannot leave a truncated file behind.
+func writeJSONFile(path string, v interface{}) error {
+	bz, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(bz); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// pathState is the persisted form of the pending and in-flight message caches of both path ends.
+// The caches are keyed by structs, which cannot be used as JSON object keys, so they are flattened into lists.
+type pathState struct {
+	PathEnd1 pathEndState `json:"path_end_1"`
+	PathEnd2 pathEndState `json:"path_end_2"`
+}
+
+type pathEndState struct {
+	ChainID  string `json:"chain_id"`
+	ClientID string `json:"client_id"`
+
+	PacketFlow          []packetMessageState     `json:"packet_flow,omitempty"`
+	ConnectionHandshake []connectionMessageState `json:"connection_handshake,omitempty"`
+	ChannelHandshake    []channelMessageState    `json:"channel_handshake,omitempty"`
+	ClientICQ           []clientICQMessageState  `json:"client_icq,omitempty"`
+
+	PacketProcessing     []packetProcessingState     `json:"packet_processing,omitempty"`
+	ConnectionProcessing []connectionProcessingState `json:"connection_processing,omitempty"`
+	ChannelProcessing    []channelProcessingState    `json:"channel_processing,omitempty"`
+	ClientICQProcessing  []clientICQProcessingState  `json:"client_icq_processing,omitempty"`
+}
+
+type packetMessageState struct {
+	ChannelKey ChannelKey          `json:"channel_key"`
+	EventType  string              `json:"event_type"`
+	Info       provider.PacketInfo `json:"info"`
+}
+
+type connectionMessageState struct {
+	ConnectionKey ConnectionKey           `json:"connection_key"`
+	EventType     string                  `json:"event_type"`
+	Info          provider.ConnectionInfo `json:"info"`
+}
+
+type channelMessageState struct {
+	ChannelKey ChannelKey           `json:"channel_key"`
+	EventType  string               `json:"event_type"`
+	Info       provider.ChannelInfo `json:"info"`
+}
+
+type clientICQMessageState struct {
+	Type ClientICQType          `json:"type"`
+	Info provider.ClientICQInfo `json:"info"`
+}
+
+// processingState is the persisted form of a processingMessage.
+type processingState struct {
+	Assembled           bool   `json:"assembled"`
+	LastProcessedHeight uint64 `json:"last_processed_height"`
+	RetryCount          uint64 `json:"retry_count"`
+	RetryAfterBlocks    uint64 `json:"retry_after_blocks"`
+}
+
+type packetProcessingState struct {
+	ChannelKey ChannelKey      `json:"channel_key"`
+	EventType  string          `json:"event_type"`
+	Sequence   uint64          `json:"sequence"`
+	Processing processingState `json:"processing"`
+}
+
+type connectionProcessingState struct {
+	ConnectionKey ConnectionKey   `json:"connection_key"`
+	EventType     string          `json:"event_type"`
+	Processing    processingState `json:"processing"`
+}
+
+type channelProcessingState struct {
+	ChannelKey ChannelKey      `json:"channel_key"`
+	EventType  string          `json:"event_type"`
+	Processing processingState `json:"processing"`
+}
+
+type clientICQProcessingState struct {
+	QueryID    provider.ClientICQQueryID `json:"query_id"`
+	Processing processingState           `json:"processing"`
+}
+
+func newProcessingState(m processingMessage) processingState {
+	return processingState{
+		Assembled:           m.assembled,
+		LastProcessedHeight: m.lastProcessedHeight,
+		RetryCount:          m.retryCount,
+		RetryAfterBlocks:    m.retryAfterBlocks,
+	}
+}
+
+func (s processingState) processingMessage() processingMessage {
+	return processingMessage{
+		assembled:           s.Assembled,
+		lastProcessedHeight: s.LastProcessedHeight,
+		retryCount:          s.RetryCount,
+		retryAfterBlocks:    s.RetryAfterBlocks,
+	}
+}
+
+// exportState flattens the pending and in-flight message caches of the path end.
+func (pathEnd *pathEndRuntime) exportState() pathEndState {
+	state := pathEndState{
+		ChainID:  pathEnd.info.ChainID,
+		ClientID: pathEnd.info.ClientID,
+	}
+	for k, pmc := range pathEnd.messageCache.PacketFlow {
+		for eventType, psc := range pmc {
+			for _, info := range psc {
+				state.PacketFlow = append(state.PacketFlow, packetMessageState{ChannelKey: k, EventType: eventType, Info: info})
+			}
+		}
+	}
+	for eventType, cmc := range pathEnd.messageCache.ConnectionHandshake {
+		for k, info := range cmc {
+			state.ConnectionHandshake = append(state.ConnectionHandshake, connectionMessageState{ConnectionKey: k, EventType: eventType, Info: info})
+		}
+	}
+	for eventType, cmc := range pathEnd.messageCache.ChannelHandshake {
+		for k, info := range cmc {
+			state.ChannelHandshake = append(state.ChannelHandshake, channelMessageState{ChannelKey: k, EventType: eventType, Info: info})
+		}
+	}
+	for icqType, cmc := range pathEnd.messageCache.ClientICQ {
+		for _, info := range cmc {
+			state.ClientICQ = append(state.ClientICQ, clientICQMessageState{Type: icqType, Info: info})
+		}
+	}
+	for k, pcmc := range pathEnd.packetProcessing {
+		for eventType, pmsc := range pcmc {
+			for seq, m := range pmsc {
+				state.PacketProcessing = append(state.PacketProcessing, packetProcessingState{ChannelKey: k, EventType: eventType, Sequence: seq, Processing: newProcessingState(m)})
+			}
+		}
+	}
+	for eventType, cksc := range pathEnd.connProcessing {
+		for k, m := range cksc {
+			state.ConnectionProcessing = append(state.ConnectionProcessing, connectionProcessingState{ConnectionKey: k, EventType: eventType, Processing: newProcessingState(m)})
+		}
+	}
+	for eventType, cksc := range pathEnd.channelProcessing {
+		for k, m := range cksc {
+			state.ChannelProcessing = append(state.ChannelProcessing, channelProcessingState{ChannelKey: k, EventType: eventType, Processing: newProcessingState(m)})
+		}
+	}
+	for queryID, m := range pathEnd.clientICQProcessing {
+		state.ClientICQProcessing = append(state.ClientICQProcessing, clientICQProcessingState{QueryID: queryID, Processing: newProcessingState(m)})
+	}
+	return state
+}
+
+// isStateFor returns true if the state was saved for the given path end.
+func (s pathEndState) isStateFor(info PathEnd) bool {
+	return s.ChainID == info.ChainID && s.ClientID == info.ClientID
+}
+
+// importState restores the pending and in-flight message caches of the path end.
+func (pathEnd *pathEndRuntime) importState(state pathEndState) {
+	for _, m := range state.PacketFlow {
+		pathEnd.messageCache.PacketFlow.Retain(m.ChannelKey, m.EventType, m.Info)
+	}
+	for _, m := range state.ConnectionHandshake {
+		pathEnd.messageCache.ConnectionHandshake.Retain(m.ConnectionKey, m.EventType, m.Info)
+	}
+	for _, m := range state.ChannelHandshake {
+		pathEnd.messageCache.ChannelHandshake.Retain(m.ChannelKey, m.EventType, m.Info)
+	}
+	for _, m := range state.ClientICQ {
+		pathEnd.messageCache.ClientICQ.Retain(m.Type, m.Info)
+	}
+	for _, m := range state.PacketProcessing {
+		if _, ok := pathEnd.packetProcessing[m.ChannelKey]; !ok {
+			pathEnd.packetProcessing[m.ChannelKey] = make(packetChannelMessageCache)
+		}
+		if _, ok := pathEnd.packetProcessing[m.ChannelKey][m.EventType]; !ok {
+			pathEnd.packetProcessing[m.ChannelKey][m.EventType] = make(packetMessageSendCache)
+		}
+		pathEnd.packetProcessing[m.ChannelKey][m.EventType][m.Sequence] = m.Processing.processingMessage()
+	}
+	for _, m := range state.ConnectionProcessing {
+		if _, ok := pathEnd.connProcessing[m.EventType]; !ok {
+			pathEnd.connProcessing[m.EventType] = make(connectionKeySendCache)
+		}
+		pathEnd.connProcessing[m.EventType][m.ConnectionKey] = m.Processing.processingMessage()
+	}
+	for _, m := range state.ChannelProcessing {
+		if _, ok := pathEnd.channelProcessing[m.EventType]; !ok {
+			pathEnd.channelProcessing[m.EventType] = make(channelKeySendCache)
+		}
+		pathEnd.channelProcessing[m.EventType][m.ChannelKey] = m.Processing.processingMessage()
+	}
+	for _, m := range state.ClientICQProcessing {
+		pathEnd.clientICQProcessing[m.QueryID] = m.Processing.processingMessage()
+	}
+}
+
+// restoreState loads the message caches persisted by a previous run of the path.
+// It returns true if the caches were restored.
+func (pp *PathProcessor) restoreState() bool {
+	if pp.stateStore == nil {
+		return false
+	}
+	pathName := pp.pathEnd1.info.PathName
+	state, err := pp.stateStore.loadPathState(pathName)
+	if err != nil {
+		pp.log.Warn("Failed to load path state, starting without it", zap.String("path_name", pathName), zap.Error(err))
+		return false
+	}
+	if state == nil {
+		return false
+	}
+	if !state.PathEnd1.isStateFor(pp.pathEnd1.info) || !state.PathEnd2.isStateFor(pp.pathEnd2.info) {
+		pp.log.Warn("Ignoring path state saved for different chains or clients", zap.String("path_name", pathName))
+		return false
+	}
+	pp.pathEnd1.importState(state.PathEnd1)
+	pp.pathEnd2.importState(state.PathEnd2)
+	pp.log.Info("Restored path state",
+		zap.String("path_name", pathName),
+		zap.Int("path_end_1_packets", len(state.PathEnd1.PacketFlow)),
+		zap.Int("path_end_2_packets", len(state.PathEnd2.PacketFlow)),
+	)
+	return true
+}
+
+// saveStateIfDue checkpoints the message caches of the path if StateSaveInterval has passed since the last checkpoint.
+func (pp *PathProcessor) saveStateIfDue() {
+	if pp.stateStore == nil || time.Since(pp.stateSaved) < StateSaveInterval {
+		return
+	}
+	pp.saveState()
+}
+
+// saveState checkpoints the message caches of the path.
+func (pp *PathProcessor) saveState() {
+	if pp.stateStore == nil {
+		return
+	}
+	pp.stateSaved = time.Now()
+	pathName := pp.pathEnd1.info.PathName
+	state := pathState{
+		PathEnd1: pp.pathEnd1.exportState(),
+		PathEnd2: pp.pathEnd2.exportState(),
+	}
+	if err := pp.stateStore.savePathState(pathName, state); err != nil {
+		pp.log.Warn("Failed to save path state", zap.String("path_name", pathName), zap.Error(err))
+	}
+}
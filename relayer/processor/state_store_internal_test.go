@@ -0,0 +1,132 @@
+package processor
+
+import (
+	"testing"
+
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+	conntypes "github.com/cosmos/ibc-go/v7/modules/core/03-connection/types"
+	chantypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testStatePathEnd returns a path end runtime with messages in each of the persisted caches.
+func testStatePathEnd(info PathEnd) *pathEndRuntime {
+	pathEnd := newPathEndRuntime(zap.NewNop(), info, nil)
+
+	channelKey := ChannelKey{ChannelID: "channel-0", PortID: "transfer", CounterpartyChannelID: "channel-1", CounterpartyPortID: "transfer"}
+	connectionKey := ConnectionKey{ClientID: info.ClientID, ConnectionID: "connection-0", CounterpartyClientID: "07-tendermint-1"}
+
+	for _, seq := range []uint64{1, 2} {
+		pathEnd.messageCache.PacketFlow.Retain(channelKey, chantypes.EventTypeSendPacket, provider.PacketInfo{
+			Sequence:      seq,
+			SourceChannel: channelKey.ChannelID,
+			SourcePort:    channelKey.PortID,
+			DestChannel:   channelKey.CounterpartyChannelID,
+			DestPort:      channelKey.CounterpartyPortID,
+			Data:          []byte("data"),
+			TimeoutHeight: clienttypes.NewHeight(1, 100),
+			ChannelOrder:  chantypes.UNORDERED.String(),
+		})
+	}
+	pathEnd.messageCache.ConnectionHandshake.Retain(connectionKey, conntypes.EventTypeConnectionOpenInit, provider.ConnectionInfo{
+		ConnID:               connectionKey.ConnectionID,
+		ClientID:             connectionKey.ClientID,
+		CounterpartyClientID: connectionKey.CounterpartyClientID,
+	})
+	pathEnd.messageCache.ChannelHandshake.Retain(channelKey, chantypes.EventTypeChannelOpenInit, provider.ChannelInfo{
+		ChannelID:             channelKey.ChannelID,
+		PortID:                channelKey.PortID,
+		CounterpartyChannelID: channelKey.CounterpartyChannelID,
+		CounterpartyPortID:    channelKey.CounterpartyPortID,
+	})
+	pathEnd.messageCache.ClientICQ.Retain(ClientICQTypeRequest, provider.ClientICQInfo{
+		Source:  info.ChainID,
+		QueryID: "query-0",
+		Type:    "store/bank/key",
+		Request: []byte("request"),
+		Height:  10,
+	})
+
+	sent := processingMessage{assembled: true, lastProcessedHeight: 10, retryCount: 2, retryAfterBlocks: 20}
+	pathEnd.packetProcessing[channelKey] = packetChannelMessageCache{
+		chantypes.EventTypeRecvPacket: packetMessageSendCache{1: sent},
+	}
+	pathEnd.connProcessing[conntypes.EventTypeConnectionOpenTry] = connectionKeySendCache{connectionKey: sent}
+	pathEnd.channelProcessing[chantypes.EventTypeChannelOpenTry] = channelKeySendCache{channelKey: sent}
+	pathEnd.clientICQProcessing["query-0"] = sent
+
+	return pathEnd
+}
+
+func TestPathStateRoundTrip(t *testing.T) {
+	store, err := NewStateStore(t.TempDir())
+	require.NoError(t, err)
+
+	info1 := PathEnd{PathName: "demo-path", ChainID: "chain-a", ClientID: "07-tendermint-0"}
+	info2 := PathEnd{PathName: "demo-path", ChainID: "chain-b", ClientID: "07-tendermint-1"}
+
+	saved := &PathProcessor{
+		log:        zap.NewNop(),
+		pathEnd1:   testStatePathEnd(info1),
+		pathEnd2:   testStatePathEnd(info2),
+		stateStore: store,
+	}
+	saved.saveState()
+
+	restored := &PathProcessor{
+		log:        zap.NewNop(),
+		pathEnd1:   newPathEndRuntime(zap.NewNop(), info1, nil),
+		pathEnd2:   newPathEndRuntime(zap.NewNop(), info2, nil),
+		stateStore: store,
+	}
+	require.True(t, restored.restoreState())
+
+	for i, pathEnds := range [][2]*pathEndRuntime{
+		{saved.pathEnd1, restored.pathEnd1},
+		{saved.pathEnd2, restored.pathEnd2},
+	} {
+		want, got := pathEnds[0], pathEnds[1]
+		require.Equal(t, want.messageCache.PacketFlow, got.messageCache.PacketFlow, "path end %d", i+1)
+		require.Equal(t, want.messageCache.ConnectionHandshake, got.messageCache.ConnectionHandshake, "path end %d", i+1)
+		require.Equal(t, want.messageCache.ChannelHandshake, got.messageCache.ChannelHandshake, "path end %d", i+1)
+		require.Equal(t, want.messageCache.ClientICQ, got.messageCache.ClientICQ, "path end %d", i+1)
+		require.Equal(t, want.packetProcessing, got.packetProcessing, "path end %d", i+1)
+		require.Equal(t, want.connProcessing, got.connProcessing, "path end %d", i+1)
+		require.Equal(t, want.channelProcessing, got.channelProcessing, "path end %d", i+1)
+		require.Equal(t, want.clientICQProcessing, got.clientICQProcessing, "path end %d", i+1)
+	}
+}
+
+func TestPathStateForOtherClient(t *testing.T) {
+	store, err := NewStateStore(t.TempDir())
+	require.NoError(t, err)
+
+	info1 := PathEnd{PathName: "demo-path", ChainID: "chain-a", ClientID: "07-tendermint-0"}
+	info2 := PathEnd{PathName: "demo-path", ChainID: "chain-b", ClientID: "07-tendermint-1"}
+
+	saved := &PathProcessor{
+		log:        zap.NewNop(),
+		pathEnd1:   testStatePathEnd(info1),
+		pathEnd2:   testStatePathEnd(info2),
+		stateStore: store,
+	}
+	saved.saveState()
+
+	// the path was recreated with a new client on chain-b
+	info2.ClientID = "07-tendermint-2"
+	restored := &PathProcessor{
+		log:        zap.NewNop(),
+		pathEnd1:   newPathEndRuntime(zap.NewNop(), info1, nil),
+		pathEnd2:   newPathEndRuntime(zap.NewNop(), info2, nil),
+		stateStore: store,
+	}
+	require.False(t, restored.restoreState())
+	require.Empty(t, restored.pathEnd1.messageCache.PacketFlow)
+	require.Empty(t, restored.pathEnd2.packetProcessing)
+
+	require.True(t, pathEndState{ChainID: "chain-a", ClientID: "07-tendermint-0"}.isStateFor(info1))
+	require.False(t, pathEndState{ChainID: "chain-b", ClientID: "07-tendermint-1"}.isStateFor(info2))
+	require.False(t, pathEndState{ChainID: "chain-c", ClientID: "07-tendermint-0"}.isStateFor(info1))
+}
@@ -0,0 +1,35 @@
+package processor_test
+
+import (
+	"testing"
+
+	"github.com/cosmos/relayer/v2/relayer/processor"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateStoreLatestQueriedBlock(t *testing.T) {
+	home := t.TempDir()
+
+	store, err := processor.NewStateStore(home)
+	require.NoError(t, err)
+
+	_, ok, err := store.LatestQueriedBlock(testChain0)
+	require.NoError(t, err)
+	require.False(t, ok, "no checkpoint should exist for a new store")
+
+	require.NoError(t, store.SaveLatestQueriedBlock(testChain0, 100))
+	require.NoError(t, store.SaveLatestQueriedBlock(testChain0, 101))
+
+	// checkpoint should survive re-opening the store
+	store, err = processor.NewStateStore(home)
+	require.NoError(t, err)
+
+	height, ok, err := store.LatestQueriedBlock(testChain0)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(101), height)
+
+	_, ok, err = store.LatestQueriedBlock("other-chain")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -329,6 +329,7 @@ type processingMessage struct {
 	assembled           bool
 	lastProcessedHeight uint64
 	retryCount          uint64
+	retryAfterBlocks    uint64
 }
 
 type packetProcessingCache map[ChannelKey]packetChannelMessageCache
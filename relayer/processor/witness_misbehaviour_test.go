@@ -0,0 +1,69 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+	ibcexported "github.com/cosmos/ibc-go/v7/modules/core/exported"
+	tmclient "github.com/cosmos/ibc-go/v7/modules/light-clients/07-tendermint"
+	"github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// witnessProvider is a chain provider whose misbehaviour witnesses report misbehaviour
+// once release is closed, and which records the client the misbehaviour is submitted for.
+type witnessProvider struct {
+	provider.ChainProvider
+	release   chan struct{}
+	submitted chan string
+}
+
+func (p *witnessProvider) QueryWitnessMisbehaviour(ctx context.Context, clientID string, _ clienttypes.Height, _ int64, _ time.Duration) (ibcexported.ClientMessage, error) {
+	select {
+	case <-p.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &tmclient.Misbehaviour{ClientId: clientID}, nil
+}
+
+func (p *witnessProvider) MsgSubmitMisbehaviour(clientID string, _ ibcexported.ClientMessage) (provider.RelayerMessage, error) {
+	p.submitted <- clientID
+	return mockRelayerMessage{}, nil
+}
+
+func (p *witnessProvider) SendMessage(context.Context, provider.RelayerMessage, string) (*provider.RelayerTxResponse, bool, error) {
+	return &provider.RelayerTxResponse{}, true, nil
+}
+
+func TestCheckForWitnessMisbehaviourAsync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := &witnessProvider{release: make(chan struct{}), submitted: make(chan string, 1)}
+	pathEnd := newPathEndRuntime(zap.NewNop(), PathEnd{ChainID: "chain-a", ClientID: "07-tendermint-0"}, nil)
+	pathEnd.chainProvider = p
+	counterparty := newPathEndRuntime(zap.NewNop(), PathEnd{ChainID: "chain-b"}, nil)
+	counterparty.chainProvider = p
+
+	pathEnd.clientState = provider.ClientState{ConsensusHeight: clienttypes.NewHeight(1, 20)}
+
+	// the witnesses are still being queried, which must not block merging new data.
+	pathEnd.checkForWitnessMisbehaviour(ctx, clienttypes.NewHeight(1, 10), counterparty)
+	select {
+	case <-pathEnd.misbehaviourDetected:
+		t.Fatal("misbehaviour should not be detected before the witnesses answer")
+	default:
+	}
+
+	close(p.release)
+	select {
+	case <-pathEnd.misbehaviourDetected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("misbehaviour detected by the witnesses should be reported")
+	}
+	require.Equal(t, "07-tendermint-0", <-p.submitted)
+}
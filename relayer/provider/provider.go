@@ -9,6 +9,7 @@ import (
 	"github.com/cometbft/cometbft/types"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/gogoproto/proto"
+	feetypes "github.com/cosmos/ibc-go/v7/modules/apps/29-fee/types"
 	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
 	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
 	conntypes "github.com/cosmos/ibc-go/v7/modules/core/03-connection/types"
@@ -52,6 +53,42 @@ type RelayerEvent struct {
 	Attributes map[string]string
 }
 
+// FeeBudgetExceededError is returned by SendMessagesToMempool when broadcasting the transaction
+// with Key would exceed a fee budget of the chain. No more transactions can be sent
+// with the key until the budget window rolls over at ResetAt.
+type FeeBudgetExceededError struct {
+	ChainID string
+	Key     string
+	Denom   string
+	Limit   string
+	Spent   string
+	ResetAt time.Time
+}
+
+func (e *FeeBudgetExceededError) Error() string {
+	return fmt.Sprintf("fee budget of %s exceeded for key %s on chain %s, %s already spent, budget resets at %s",
+		e.Limit, e.Key, e.ChainID, e.Spent, e.ResetAt.Format(time.RFC3339))
+}
+
+// TxGasExceedsBlockLimitError is returned by SendMessagesToMempool when the gas wanted by the
+// transaction is more than the maximum gas of a block on the chain, so it can never be included.
+// The messages must be split into smaller transactions.
+type TxGasExceedsBlockLimitError struct {
+	ChainID   string
+	GasWanted uint64
+	MaxGas    int64
+}
+
+func (e *TxGasExceedsBlockLimitError) Error() string {
+	return fmt.Sprintf("gas wanted %d exceeds the block max gas %d on chain %s", e.GasWanted, e.MaxGas, e.ChainID)
+}
+
+// BlockLimits are the consensus limits on the size of each block of a chain. Zero means unlimited.
+type BlockLimits struct {
+	MaxBytes int64
+	MaxGas   int64
+}
+
 type LatestBlock struct {
 	Height uint64
 	Time   time.Time
@@ -375,6 +412,19 @@ type ChainProvider interface {
 
 	// [End] Client ICQ message assembly
 
+	// [Begin] Fee middleware message assembly
+
+	// MsgRegisterPayee assembles a MsgRegisterPayee message formatted for this chain, registering
+	// payeeAddr as the recipient of the ack and timeout fees earned by relayerAddr on the given channel.
+	MsgRegisterPayee(portID, channelID, relayerAddr, payeeAddr string) (RelayerMessage, error)
+
+	// MsgRegisterCounterpartyPayee assembles a MsgRegisterCounterpartyPayee message formatted for this chain,
+	// registering counterpartyPayeeAddr as the recipient of the recv fees earned by relayerAddr
+	// when relaying packets on the given channel to the counterparty chain.
+	MsgRegisterCounterpartyPayee(portID, channelID, relayerAddr, counterpartyPayeeAddr string) (RelayerMessage, error)
+
+	// [End] Fee middleware message assembly
+
 	// Query heavy relay methods. Only used for flushing old packets.
 
 	RelayPacketFromSequence(ctx context.Context, src ChainProvider, srch, dsth, seq uint64, srcChanID, srcPortID string, order chantypes.Order) (RelayerMessage, RelayerMessage, error)
@@ -412,9 +462,18 @@ type QueryProvider interface {
 	QueryTxs(ctx context.Context, page, limit int, events []string) ([]*RelayerTxResponse, error)
 	QueryLatestHeight(ctx context.Context) (int64, error)
 
+	// QueryBlockLimits returns the consensus limits of each block, which may be cached by the provider.
+	// On error, the previously queried limits are returned along with the error.
+	QueryBlockLimits(ctx context.Context) (BlockLimits, error)
+
 	// QueryIBCHeader returns the IBC compatible block header at a specific height.
 	QueryIBCHeader(ctx context.Context, h int64) (IBCHeader, error)
 
+	// QueryWitnessMisbehaviour compares the block header at height h with the headers of the independent witness nodes
+	// of the chain, returning the misbehaviour for the client trusting the header at trustedHeight
+	// if a witness has a conflicting header. Nil is returned if no witnesses are configured or no conflict is found.
+	QueryWitnessMisbehaviour(ctx context.Context, clientID string, trustedHeight clienttypes.Height, h int64, trustingPeriod time.Duration) (ibcexported.ClientMessage, error)
+
 	// query packet info for sequence
 	QuerySendPacket(ctx context.Context, srcChanID, srcPortID string, sequence uint64) (PacketInfo, error)
 	QueryRecvPacket(ctx context.Context, dstChanID, dstPortID string, sequence uint64) (PacketInfo, error)
@@ -429,6 +488,7 @@ type QueryProvider interface {
 	// ics 02 - client
 	QueryClientState(ctx context.Context, height int64, clientid string) (ibcexported.ClientState, error)
 	QueryClientStateResponse(ctx context.Context, height int64, srcClientId string) (*clienttypes.QueryClientStateResponse, error)
+	QueryClientStatus(ctx context.Context, clientID string) (ibcexported.Status, error)
 	QueryClientConsensusState(ctx context.Context, chainHeight int64, clientid string, clientHeight ibcexported.Height) (*clienttypes.QueryConsensusStateResponse, error)
 	QueryUpgradedClient(ctx context.Context, height int64) (*clienttypes.QueryClientStateResponse, error)
 	QueryUpgradedConsState(ctx context.Context, height int64) (*clienttypes.QueryConsensusStateResponse, error)
@@ -460,6 +520,9 @@ type QueryProvider interface {
 	// ics 20 - transfer
 	QueryDenomTrace(ctx context.Context, denom string) (*transfertypes.DenomTrace, error)
 	QueryDenomTraces(ctx context.Context, offset, limit uint64, height int64) ([]transfertypes.DenomTrace, error)
+
+	// ics 29 - fee
+	QueryIncentivizedPacketsForChannel(ctx context.Context, height uint64, channelid, portid string) ([]*feetypes.IdentifiedPacketFees, error)
 }
 
 type RelayPacket interface {
@@ -0,0 +1,108 @@
+package relayer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cosmos/relayer/v2/relayer/processor"
+)
+
+// RetryPolicy configures when messages which failed to be assembled or sent are retried,
+// and when they are given up on. It can be set globally and per path.
+// Unset fields fall back to the global policy, then to processor.DefaultRetryPolicy.
+type RetryPolicy struct {
+	MessageRetryPolicy `yaml:",inline"`
+
+	// ErrorRetryDelay and MaxErrorRetryDelay are durations, e.g. "5s".
+	ErrorRetryDelay    string `yaml:"error-retry-delay,omitempty" json:"error-retry-delay,omitempty"`
+	MaxErrorRetryDelay string `yaml:"max-error-retry-delay,omitempty" json:"max-error-retry-delay,omitempty"`
+
+	// MessageTypes overrides the policy for the message types packet, connection, channel, client-icq and client-update.
+	MessageTypes map[string]MessageRetryPolicy `yaml:"message-types,omitempty" json:"message-types,omitempty"`
+}
+
+// MessageRetryPolicy is the configuration for processor.MessageRetryPolicy.
+type MessageRetryPolicy struct {
+	MaxRetries               *uint64  `yaml:"max-retries,omitempty" json:"max-retries,omitempty"`
+	RetrySendAfterBlocks     *uint64  `yaml:"retry-send-after-blocks,omitempty" json:"retry-send-after-blocks,omitempty"`
+	RetryAssemblyAfterBlocks *uint64  `yaml:"retry-assembly-after-blocks,omitempty" json:"retry-assembly-after-blocks,omitempty"`
+	BackoffMultiplier        *float64 `yaml:"backoff-multiplier,omitempty" json:"backoff-multiplier,omitempty"`
+	MaxBackoffBlocks         *uint64  `yaml:"max-backoff-blocks,omitempty" json:"max-backoff-blocks,omitempty"`
+	Jitter                   *float64 `yaml:"jitter,omitempty" json:"jitter,omitempty"`
+}
+
+// applyTo overrides the fields of p which are set in the configuration.
+func (c MessageRetryPolicy) applyTo(p *processor.MessageRetryPolicy) {
+	if c.MaxRetries != nil {
+		p.MaxRetries = *c.MaxRetries
+	}
+	if c.RetrySendAfterBlocks != nil {
+		p.RetrySendAfterBlocks = *c.RetrySendAfterBlocks
+	}
+	if c.RetryAssemblyAfterBlocks != nil {
+		p.RetryAssemblyAfterBlocks = *c.RetryAssemblyAfterBlocks
+	}
+	if c.BackoffMultiplier != nil {
+		p.BackoffMultiplier = *c.BackoffMultiplier
+	}
+	if c.MaxBackoffBlocks != nil {
+		p.MaxBackoffBlocks = *c.MaxBackoffBlocks
+	}
+	if c.Jitter != nil {
+		p.Jitter = *c.Jitter
+	}
+}
+
+// ResolveRetryPolicy builds the processor.RetryPolicy from the configured policies,
+// with later policies taking precedence, e.g. ResolveRetryPolicy(global, path).
+// Message type overrides are applied on top of the resolved default policy.
+func ResolveRetryPolicy(policies ...RetryPolicy) (processor.RetryPolicy, error) {
+	resolved := processor.DefaultRetryPolicy()
+
+	messageTypes := make(map[processor.MessageType]struct{})
+	for _, c := range policies {
+		c.MessageRetryPolicy.applyTo(&resolved.Default)
+		if c.ErrorRetryDelay != "" {
+			d, err := time.ParseDuration(c.ErrorRetryDelay)
+			if err != nil {
+				return processor.RetryPolicy{}, fmt.Errorf("invalid error retry delay: %w", err)
+			}
+			resolved.ErrorRetryDelay = d
+			if resolved.MaxErrorRetryDelay < d {
+				resolved.MaxErrorRetryDelay = d
+			}
+		}
+		if c.MaxErrorRetryDelay != "" {
+			d, err := time.ParseDuration(c.MaxErrorRetryDelay)
+			if err != nil {
+				return processor.RetryPolicy{}, fmt.Errorf("invalid max error retry delay: %w", err)
+			}
+			resolved.MaxErrorRetryDelay = d
+		}
+		for t := range c.MessageTypes {
+			mt, err := processor.ParseMessageType(t)
+			if err != nil {
+				return processor.RetryPolicy{}, err
+			}
+			messageTypes[mt] = struct{}{}
+		}
+	}
+
+	if len(messageTypes) > 0 {
+		resolved.MessageTypes = make(map[processor.MessageType]processor.MessageRetryPolicy, len(messageTypes))
+		for mt := range messageTypes {
+			p := resolved.Default
+			for _, c := range policies {
+				if mc, ok := c.MessageTypes[string(mt)]; ok {
+					mc.applyTo(&p)
+				}
+			}
+			resolved.MessageTypes[mt] = p
+		}
+	}
+
+	if err := resolved.Validate(); err != nil {
+		return processor.RetryPolicy{}, err
+	}
+	return resolved, nil
+}
@@ -0,0 +1,82 @@
+package relayer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cosmos/relayer/v2/relayer/processor"
+	"github.com/stretchr/testify/require"
+)
+
+func uint64Ptr(v uint64) *uint64 { return &v }
+
+func float64Ptr(v float64) *float64 { return &v }
+
+func TestResolveRetryPolicyDefaults(t *testing.T) {
+	policy, err := ResolveRetryPolicy(RetryPolicy{}, RetryPolicy{})
+	require.NoError(t, err)
+	require.Equal(t, processor.DefaultRetryPolicy(), policy)
+}
+
+func TestResolveRetryPolicyPrecedence(t *testing.T) {
+	global := RetryPolicy{
+		MessageRetryPolicy: MessageRetryPolicy{
+			MaxRetries:        uint64Ptr(10),
+			BackoffMultiplier: float64Ptr(2),
+		},
+		ErrorRetryDelay: "10s",
+		MessageTypes: map[string]MessageRetryPolicy{
+			"packet": {RetrySendAfterBlocks: uint64Ptr(3)},
+		},
+	}
+	path := RetryPolicy{
+		MessageRetryPolicy: MessageRetryPolicy{
+			MaxRetries: uint64Ptr(20),
+			Jitter:     float64Ptr(0.5),
+		},
+		MaxErrorRetryDelay: "1m",
+		MessageTypes: map[string]MessageRetryPolicy{
+			"packet":  {MaxBackoffBlocks: uint64Ptr(50)},
+			"channel": {MaxRetries: uint64Ptr(1)},
+		},
+	}
+
+	policy, err := ResolveRetryPolicy(global, path)
+	require.NoError(t, err)
+
+	require.Equal(t, processor.MessageRetryPolicy{
+		MaxRetries:               20,
+		RetrySendAfterBlocks:     5,
+		RetryAssemblyAfterBlocks: 0,
+		BackoffMultiplier:        2,
+		Jitter:                   0.5,
+	}, policy.Default)
+
+	require.Equal(t, processor.MessageRetryPolicy{
+		MaxRetries:               20,
+		RetrySendAfterBlocks:     3,
+		RetryAssemblyAfterBlocks: 0,
+		BackoffMultiplier:        2,
+		MaxBackoffBlocks:         50,
+		Jitter:                   0.5,
+	}, policy.MessageTypes[processor.MessageTypePacket])
+
+	require.Equal(t, uint64(1), policy.MessageTypes[processor.MessageTypeChannel].MaxRetries)
+	require.NotContains(t, policy.MessageTypes, processor.MessageTypeConnection)
+
+	require.Equal(t, 10*time.Second, policy.ErrorRetryDelay)
+	require.Equal(t, time.Minute, policy.MaxErrorRetryDelay)
+}
+
+func TestResolveRetryPolicyInvalid(t *testing.T) {
+	for _, p := range []RetryPolicy{
+		{MessageRetryPolicy: MessageRetryPolicy{BackoffMultiplier: float64Ptr(0.5)}},
+		{MessageRetryPolicy: MessageRetryPolicy{Jitter: float64Ptr(1.5)}},
+		{ErrorRetryDelay: "soon"},
+		{ErrorRetryDelay: "10s", MaxErrorRetryDelay: "5s"},
+		{MessageTypes: map[string]MessageRetryPolicy{"update-client": {}}},
+	} {
+		_, err := ResolveRetryPolicy(p)
+		require.Error(t, err)
+	}
+}
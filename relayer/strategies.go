@@ -42,6 +42,9 @@ func StartRelayer(
 	processorType string,
 	initialBlockHistory uint64,
 	metrics *processor.PrometheusMetrics,
+	stateStore *processor.StateStore,
+	deadLetterStore *processor.DeadLetterStore,
+	retryPolicy RetryPolicy,
 ) chan error {
 	errorChan := make(chan error, 1)
 
@@ -50,7 +53,7 @@ func StartRelayer(
 		chainProcessors := make([]processor.ChainProcessor, 0, len(chains))
 
 		for _, chain := range chains {
-			chainProcessors = append(chainProcessors, chain.chainProcessor(log, metrics))
+			chainProcessors = append(chainProcessors, chain.chainProcessor(log, metrics, stateStore))
 		}
 
 		ePaths := make([]path, len(paths))
@@ -67,9 +70,37 @@ func StartRelayer(
 				filterSrc = append(filterSrc, ruleSrc)
 				filterDst = append(filterDst, ruleDst)
 			}
+			feePolicy, err := processor.ParseFeePolicy(p.FeePolicy)
+			if err != nil {
+				errorChan <- fmt.Errorf("invalid fee policy for path %s: %w", pathName, err)
+				close(errorChan)
+				return errorChan
+			}
+			packetFilter, err := processor.NewPacketFilter(p.PacketFilter.Allow, p.PacketFilter.Deny)
+			if err != nil {
+				errorChan <- fmt.Errorf("invalid packet filter for path %s: %w", pathName, err)
+				close(errorChan)
+				return errorChan
+			}
+			pathRetryPolicy, err := ResolveRetryPolicy(retryPolicy, p.RetryPolicy)
+			if err != nil {
+				errorChan <- fmt.Errorf("invalid retry policy for path %s: %w", pathName, err)
+				close(errorChan)
+				return errorChan
+			}
+			channelLimits, err := p.ChannelLimits.Resolve()
+			if err != nil {
+				errorChan <- fmt.Errorf("invalid channel limits for path %s: %w", pathName, err)
+				close(errorChan)
+				return errorChan
+			}
 			ePaths[i] = path{
-				src: processor.NewPathEnd(pathName, p.Src.ChainID, p.Src.ClientID, filter.Rule, filterSrc),
-				dst: processor.NewPathEnd(pathName, p.Dst.ChainID, p.Dst.ClientID, filter.Rule, filterDst),
+				src:           processor.NewPathEnd(pathName, p.Src.ChainID, p.Src.ClientID, filter.Rule, filterSrc),
+				dst:           processor.NewPathEnd(pathName, p.Dst.ChainID, p.Dst.ClientID, filter.Rule, filterDst),
+				feePolicy:     feePolicy,
+				packetFilter:  packetFilter,
+				retryPolicy:   pathRetryPolicy,
+				channelLimits: channelLimits,
 			}
 		}
 
@@ -87,6 +118,8 @@ func StartRelayer(
 			flushInterval,
 			errorChan,
 			metrics,
+			stateStore,
+			deadLetterStore,
 		)
 		return errorChan
 	case ProcessorLegacy:
@@ -107,16 +140,23 @@ func StartRelayer(
 // TODO: intermediate types. Should combine/replace with the relayer.Chain, relayer.Path, and relayer.PathEnd structs
 // as the stateless and stateful/event-based relaying mechanisms are consolidated.
 type path struct {
-	src processor.PathEnd
-	dst processor.PathEnd
+	src           processor.PathEnd
+	dst           processor.PathEnd
+	feePolicy     processor.FeePolicy
+	packetFilter  *processor.PacketFilter
+	retryPolicy   processor.RetryPolicy
+	channelLimits processor.ChannelLimits
 }
 
 // chainProcessor returns the corresponding ChainProcessor implementation instance for a pathChain.
-func (chain *Chain) chainProcessor(log *zap.Logger, metrics *processor.PrometheusMetrics) processor.ChainProcessor {
+// stateStore may be nil to disable checkpointing.
+func (chain *Chain) chainProcessor(log *zap.Logger, metrics *processor.PrometheusMetrics, stateStore *processor.StateStore) processor.ChainProcessor {
 	// Handle new ChainProcessor implementations as cases here
 	switch p := chain.ChainProvider.(type) {
 	case *cosmos.CosmosProvider:
-		return cosmos.NewCosmosChainProcessor(log, p, metrics)
+		ccp := cosmos.NewCosmosChainProcessor(log, p, metrics)
+		ccp.SetStateStore(stateStore)
+		return ccp
 	default:
 		panic(fmt.Errorf("unsupported chain provider type: %T", chain.ChainProvider))
 	}
@@ -137,22 +177,31 @@ func relayerStartEventProcessor(
 	flushInterval time.Duration,
 	errCh chan<- error,
 	metrics *processor.PrometheusMetrics,
+	stateStore *processor.StateStore,
+	deadLetterStore *processor.DeadLetterStore,
 ) {
 	defer close(errCh)
 
 	epb := processor.NewEventProcessor().WithChainProcessors(chainProcessors...)
 
 	for _, p := range paths {
-		epb = epb.
-			WithPathProcessors(processor.NewPathProcessor(
-				log,
-				p.src,
-				p.dst,
-				metrics,
-				memo,
-				clientUpdateThresholdTime,
-				flushInterval,
-			))
+		pp := processor.NewPathProcessor(
+			log,
+			p.src,
+			p.dst,
+			metrics,
+			memo,
+			clientUpdateThresholdTime,
+			flushInterval,
+		)
+		pp.SetFeePolicy(p.feePolicy)
+		pp.SetPacketFilter(p.packetFilter)
+		pp.SetRetryPolicy(p.retryPolicy)
+		pp.SetChannelLimits(p.channelLimits)
+		pp.SetBatchLimits(maxTxSize, maxMsgLength)
+		pp.SetStateStore(stateStore)
+		pp.SetDeadLetterStore(deadLetterStore)
+		epb = epb.WithPathProcessors(pp)
 	}
 
 	if messageLifecycle != nil {
@@ -98,3 +98,16 @@ func TestValidateChannelFilterRule(t *testing.T) {
 	}
 	require.Error(t, p.ValidateChannelFilterRule())
 }
+
+func TestValidateFeePolicy(t *testing.T) {
+	p := &Path{}
+	require.NoError(t, p.ValidateFeePolicy())
+
+	for _, policy := range []string{"all", "incentivized-only", "prioritize-by-fee"} {
+		p = &Path{FeePolicy: policy}
+		require.NoError(t, p.ValidateFeePolicy())
+	}
+
+	p = &Path{FeePolicy: "invalid"}
+	require.Error(t, p.ValidateFeePolicy())
+}